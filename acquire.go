@@ -0,0 +1,66 @@
+package pool
+
+import "time"
+
+// AcquireSource 取出的conn的来源
+type AcquireSource int
+
+const (
+	AcquireIdle AcquireSource = iota // 复用空闲conn
+	AcquireNew                       // 新建的conn
+)
+
+func (s AcquireSource) String() string {
+	switch s {
+	case AcquireIdle:
+		return "idle"
+	case AcquireNew:
+		return "new"
+	default:
+		return "unknown"
+	}
+}
+
+// AcquireInfo 一次取出conn的情况, 可用于在请求的trace中标注pool的行为
+type AcquireInfo struct {
+	Time time.Time // 取出时间
+
+	Source AcquireSource
+
+	Waited bool // 是否因达到maxConn等待了其他conn放回
+
+	WaitDuration time.Duration // 等待容量的时长
+
+	DialDuration time.Duration // Source为AcquireNew时factory的耗时
+
+	HandshakeDuration time.Duration // 本次取出时完成延迟TLS握手的耗时, 见HandshakeOnCheckout
+
+	Operation string // WithOperation记录的操作名
+}
+
+// recordAcquire 记录本次取出的情况和取出方, start为开始取出conn的时间, 之后创建的conn为新建的, 需持有p.mu
+func (p *ChannelPool) recordAcquire(m *connMeta, start time.Time, waited time.Duration, holder string) {
+	if m == nil {
+		return
+	}
+	m.holder = holder
+	m.uses++
+	info := AcquireInfo{Time: time.Now(), Waited: waited > 0, WaitDuration: waited}
+	if m.createdAt.After(start) {
+		info.Source = AcquireNew
+		info.DialDuration = m.dialDuration
+	}
+	m.acquired = info
+	m.workloadPending = p.workload != nil
+}
+
+// Acquisition 返回本次取出conn的情况, conn已被pool关闭时返回零值
+func (c *PoolConn) Acquisition() AcquireInfo {
+	c.p.mu.RLock()
+	defer c.p.mu.RUnlock()
+
+	if m, ok := c.p.conns[c.raw]; ok {
+		return m.acquired
+	}
+	return AcquireInfo{}
+}
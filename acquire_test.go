@@ -0,0 +1,60 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoolConn_Acquisition(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if info := conn.(*PoolConn).Acquisition(); info.Source != AcquireIdle || info.Waited {
+		t.Errorf("Acquisition error. Expecting idle without wait, got %+v", info)
+	}
+
+	// 等待其他conn放回
+	done := make(chan AcquireInfo, 1)
+	go func() {
+		conn, err := p.Get()
+		if err != nil {
+			t.Errorf("Get error: %s", err)
+			done <- AcquireInfo{}
+			return
+		}
+		done <- conn.(*PoolConn).Acquisition()
+		p.Put(conn)
+	}()
+	time.Sleep(time.Millisecond * 20)
+	p.Put(conn)
+	info := <-done
+	if info.Source != AcquireIdle || !info.Waited || info.WaitDuration < time.Millisecond*10 {
+		t.Errorf("Acquisition error. Expecting idle after wait, got %+v", info)
+	}
+
+	// 空闲conn被关闭后新建
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Discard(conn)
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	info = conn.(*PoolConn).Acquisition()
+	if info.Source != AcquireNew || info.Waited || info.DialDuration <= 0 {
+		t.Errorf("Acquisition error. Expecting new conn, got %+v", info)
+	}
+	if info.Source.String() != "new" {
+		t.Errorf("AcquireSource error. Expecting %q, got %q", "new", info.Source)
+	}
+	p.Put(conn)
+}
@@ -0,0 +1,99 @@
+package pool
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// poolAdapter 把 Pool 适配为 PoolV2
+type poolAdapter struct {
+	Pool
+}
+
+// AdaptPool 把只实现了 Pool 的pool适配为 PoolV2, 已实现 PoolV2 时原样返回;
+// Stats、Drain、CloseContext 在原pool实现了同名方法时调用原方法, 否则 Stats 只有采样时间, Drain 返回 ErrNotSupported
+func AdaptPool(p Pool) PoolV2 {
+	if v2, ok := p.(PoolV2); ok {
+		return v2
+	}
+	return &poolAdapter{Pool: p}
+}
+
+// GetContext 在后台调用Get, ctx先结束时返回超时错误, 之后取到的conn被放回
+func (a *poolAdapter) GetContext(ctx context.Context) (net.Conn, error) {
+	if ctx.Err() != nil {
+		return nil, timeoutErr(ctx)
+	}
+
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := a.Get()
+		done <- result{conn, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.conn, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.err == nil {
+				_ = a.Put(r.conn)
+			}
+		}()
+		return nil, timeoutErr(ctx)
+	}
+}
+
+func (a *poolAdapter) Stats() Stats {
+	if s, ok := a.Pool.(interface{ Stats() Stats }); ok {
+		return s.Stats()
+	}
+	return Stats{Time: time.Now()}
+}
+
+// Warmup 取出n个conn后全部放回
+func (a *poolAdapter) Warmup(ctx context.Context, n int) error {
+	conns := make([]net.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			_ = a.Put(conn)
+		}
+	}()
+	for i := 0; i < n; i++ {
+		conn, err := a.GetContext(ctx)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, conn)
+	}
+	return nil
+}
+
+func (a *poolAdapter) Drain(ctx context.Context) error {
+	if d, ok := a.Pool.(interface{ Drain(context.Context) error }); ok {
+		return d.Drain(ctx)
+	}
+	return ErrNotSupported
+}
+
+// CloseContext 在后台调用Close, ctx先结束时返回超时错误
+func (a *poolAdapter) CloseContext(ctx context.Context) error {
+	if c, ok := a.Pool.(interface{ CloseContext(context.Context) error }); ok {
+		return c.CloseContext(ctx)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Close()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return timeoutErr(ctx)
+	}
+}
@@ -0,0 +1,53 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// v1Pool 只暴露 Pool 接口
+type v1Pool struct {
+	Pool
+}
+
+func TestAdaptPool(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if AdaptPool(p) != PoolV2(p) {
+		t.Errorf("AdaptPool error. Expecting ChannelPool returned as is")
+	}
+
+	v2 := AdaptPool(v1Pool{p})
+	if err := v2.Warmup(context.Background(), 1); err != nil {
+		t.Errorf("Warmup error: %s", err)
+	}
+	if s := v2.Stats(); s.Time.IsZero() || s.Open != 0 {
+		t.Errorf("Stats error. Expecting time only, got %+v", s)
+	}
+	if err := v2.Drain(context.Background()); err != ErrNotSupported {
+		t.Errorf("Drain error. Expecting %v, got %v", ErrNotSupported, err)
+	}
+
+	conn, err := v2.GetContext(context.Background())
+	if err != nil {
+		t.Fatalf("GetContext error: %s", err)
+	}
+
+	// 唯一的conn已取出, ctx结束时返回超时错误, 之后取到的conn被放回
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	if _, err := v2.GetContext(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("GetContext error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	if err := v2.Put(conn); err != nil {
+		t.Error(err)
+	}
+
+	if err := v2.CloseContext(context.Background()); err != nil {
+		t.Errorf("CloseContext error: %s", err)
+	}
+}
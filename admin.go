@@ -0,0 +1,47 @@
+package pool
+
+import (
+	"errors"
+	"net"
+)
+
+var (
+	ErrConnNotFound = errors.New("conn not found")
+)
+
+// CloseConn 关闭指定ID的conn(见ConnID、RangeInUse、RangeIdle), 用于单独处理可疑的conn而不必Drain整个pool;
+// 空闲conn立即从pool中移除并关闭; 被取出的conn立即关闭, 持有方的读写返回错误, 放回时不再复用.
+// 没有该conn时返回ErrConnNotFound
+func (p *ChannelPool) CloseConn(id uint64) error {
+	p.mu.Lock()
+	var (
+		raw net.Conn
+		m   *connMeta
+	)
+	for c, cm := range p.conns {
+		if cm.id == id {
+			raw, m = c, cm
+			break
+		}
+	}
+	if m == nil || m.forced {
+		p.mu.Unlock()
+		return ErrConnNotFound
+	}
+
+	if m.idle {
+		list := &p.idle
+		if m.handoff {
+			list = &p.handoff
+		}
+		list.Pop(func(c net.Conn) bool { return c == raw })
+		c := p.forget(raw, CloseReasonForced)
+		p.mu.Unlock()
+		return p.closeConn(c)
+	}
+	// 名额由持有方放回时归还
+	m.forced = true
+	c := m.conn
+	p.mu.Unlock()
+	return p.closeConn(c)
+}
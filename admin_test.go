@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestChannelPool_CloseConn(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c1, _ := p.Get()
+	c2, _ := p.Get()
+	id1, _ := p.ConnID(c1)
+	id2, _ := p.ConnID(c2)
+	p.Put(c2)
+
+	// 空闲conn立即移除
+	if err := p.CloseConn(id2); err != nil {
+		t.Errorf("CloseConn error: %s", err)
+	}
+	if p.Len() != 0 || p.OpenNum() != 1 {
+		t.Errorf("CloseConn error. Expecting 0 idle 1 open, got %d %d", p.Len(), p.OpenNum())
+	}
+
+	// 取出的conn立即关闭, 放回时不再复用
+	if err := p.CloseConn(id1); err != nil {
+		t.Errorf("CloseConn error: %s", err)
+	}
+	if _, err := c1.Write([]byte("x")); err == nil {
+		t.Errorf("Write error. Expecting error on closed conn")
+	}
+	if err := p.CloseConn(id1); err != ErrConnNotFound {
+		t.Errorf("CloseConn error. Expecting %v, got %v", ErrConnNotFound, err)
+	}
+	p.Put(c1)
+	if p.Len() != 0 || p.OpenNum() != 0 || p.InUse() != 0 {
+		t.Errorf("Put error. Expecting forced conn closed, got %d idle %d open", p.Len(), p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonForced]; h.Count != 2 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 2, h.Count)
+	}
+
+	// 名额已归还
+	conns := make([]net.Conn, 0, 5)
+	for i := 0; i < 5; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		conns = append(conns, conn)
+	}
+	for _, c := range conns {
+		p.Put(c)
+	}
+}
+
+func TestChannelPool_DebugHandlerClose(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	var id uint64
+	p.RangeIdle(func(info ConnInfo) bool {
+		id = info.ID
+		return false
+	})
+	url := "/?close=" + strconv.FormatUint(id, 10)
+
+	rec := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DebugHandler error. Expecting %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(rec, httptest.NewRequest("POST", url, nil))
+	if rec.Code != http.StatusOK || p.Len() != 2 {
+		t.Errorf("DebugHandler error. Expecting conn closed, got %d %d idle", rec.Code, p.Len())
+	}
+	rec = httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(rec, httptest.NewRequest("POST", url, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("DebugHandler error. Expecting %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+// closeErrConn Close返回错误的conn
+type closeErrConn struct {
+	net.Conn
+}
+
+func (c closeErrConn) Close() error {
+	_ = c.Conn.Close()
+	return errors.New("close failed")
+}
+
+func TestChannelPool_DebugHandlerCloseError(t *testing.T) {
+	p, err := NewChannelPool(1, 1, func() (net.Conn, error) {
+		client, _ := net.Pipe()
+		return closeErrConn{client}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	var id uint64
+	p.RangeIdle(func(info ConnInfo) bool {
+		id = info.ID
+		return false
+	})
+	rec := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(rec, httptest.NewRequest("POST", "/?close="+strconv.FormatUint(id, 10), nil))
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "close failed") {
+		t.Errorf("DebugHandler error. Expecting %d with close error, got %d %q", http.StatusInternalServerError, rec.Code, rec.Body.String())
+	}
+}
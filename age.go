@@ -0,0 +1,180 @@
+package pool
+
+import "time"
+
+// CloseReason pool关闭conn的原因
+type CloseReason int
+
+const (
+	CloseReasonOverflow        CloseReason = iota // 放回时空闲已满
+	CloseReasonPoolClosed                         // pool已关闭
+	CloseReasonHealthCheck                        // 健康检查失败
+	CloseReasonBroken                             // 使用方报告conn出错
+	CloseReasonHalfClosed                         // conn已被半关闭
+	CloseReasonIdleTimeout                        // 空闲时间超过上限
+	CloseReasonMaxLifetime                        // 存活时间超过上限
+	CloseReasonUnreadData                         // 放回时还有未读数据
+	CloseReasonDrained                            // 被Drain淘汰
+	CloseReasonAuthFailed                         // 重新认证失败
+	CloseReasonDonated                            // 转给其他pool, conn未关闭
+	CloseReasonCertExpiry                         // TLS对端证书即将过期
+	CloseReasonForced                             // 被CloseConn强制关闭
+	CloseReasonQuarantined                        // 后端处于隔离期
+	CloseReasonHandshakeFailed                    // 取出时延迟的TLS握手失败
+	CloseReasonMigrated                           // 被MigrateTo淘汰
+)
+
+func (r CloseReason) String() string {
+	switch r {
+	case CloseReasonOverflow:
+		return "overflow"
+	case CloseReasonPoolClosed:
+		return "pool_closed"
+	case CloseReasonHealthCheck:
+		return "health_check"
+	case CloseReasonBroken:
+		return "broken"
+	case CloseReasonHalfClosed:
+		return "half_closed"
+	case CloseReasonIdleTimeout:
+		return "idle_timeout"
+	case CloseReasonMaxLifetime:
+		return "max_lifetime"
+	case CloseReasonUnreadData:
+		return "unread_data"
+	case CloseReasonDrained:
+		return "drained"
+	case CloseReasonAuthFailed:
+		return "auth_failed"
+	case CloseReasonDonated:
+		return "donated"
+	case CloseReasonCertExpiry:
+		return "cert_expiry"
+	case CloseReasonForced:
+		return "forced"
+	case CloseReasonQuarantined:
+		return "quarantined"
+	case CloseReasonHandshakeFailed:
+		return "handshake_failed"
+	case CloseReasonMigrated:
+		return "migrated"
+	default:
+		return "unknown"
+	}
+}
+
+// ageBounds 存活时长分布的桶上界, 最后一个桶不设上界
+var ageBounds = []time.Duration{
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+}
+
+// AgeBucket 存活时长分布中的一个桶
+type AgeBucket struct {
+	UpperBound time.Duration // 桶上界, 0 表示不设上界
+
+	Count int64 // 存活时长落在 (上一个桶上界, UpperBound] 的conn数
+}
+
+// AgeHistogram conn关闭时的存活时长分布
+type AgeHistogram struct {
+	Count int64
+
+	Sum time.Duration
+
+	Min time.Duration
+
+	Max time.Duration
+
+	Buckets []AgeBucket
+}
+
+// Mean 平均存活时长
+func (h AgeHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile 估算分位数, 返回所在桶的上界, 落在最后一个桶时返回Max
+func (h AgeHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	rank := int64(q * float64(h.Count))
+	if rank >= h.Count {
+		rank = h.Count - 1
+	}
+	var seen int64
+	for _, b := range h.Buckets {
+		seen += b.Count
+		if seen > rank {
+			if b.UpperBound == 0 || b.UpperBound > h.Max {
+				return h.Max
+			}
+			return b.UpperBound
+		}
+	}
+	return h.Max
+}
+
+func newAgeHistogram() *AgeHistogram {
+	h := &AgeHistogram{Buckets: make([]AgeBucket, len(ageBounds)+1)}
+	for i, bound := range ageBounds {
+		h.Buckets[i].UpperBound = bound
+	}
+	return h
+}
+
+func (h *AgeHistogram) observe(age time.Duration) {
+	if h.Count == 0 || age < h.Min {
+		h.Min = age
+	}
+	if age > h.Max {
+		h.Max = age
+	}
+	h.Count++
+	h.Sum += age
+
+	i := 0
+	for i < len(ageBounds) && age > ageBounds[i] {
+		i++
+	}
+	h.Buckets[i].Count++
+}
+
+func (h *AgeHistogram) clone() AgeHistogram {
+	c := *h
+	c.Buckets = append([]AgeBucket(nil), h.Buckets...)
+	return c
+}
+
+// observeAge 记录conn关闭时的存活时长, 需持有p.mu
+func (p *ChannelPool) observeAge(m *connMeta, reason CloseReason) {
+	h, ok := p.ages[reason]
+	if !ok {
+		h = newAgeHistogram()
+		p.ages[reason] = h
+	}
+	h.observe(time.Since(m.createdAt))
+}
+
+// ConnAgeStats 按关闭原因统计的conn存活时长分布
+func (p *ChannelPool) ConnAgeStats() map[CloseReason]AgeHistogram {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make(map[CloseReason]AgeHistogram, len(p.ages))
+	for reason, h := range p.ages {
+		stats[reason] = h.clone()
+	}
+	return stats
+}
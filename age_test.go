@@ -0,0 +1,60 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgeHistogram(t *testing.T) {
+	h := newAgeHistogram()
+	for _, age := range []time.Duration{
+		time.Millisecond * 500,
+		time.Second * 5,
+		time.Second * 30,
+		time.Minute * 2,
+		time.Hour * 48,
+	} {
+		h.observe(age)
+	}
+
+	if h.Count != 5 {
+		t.Errorf("observe error. Expecting %d, got %d", 5, h.Count)
+	}
+	if h.Min != time.Millisecond*500 {
+		t.Errorf("observe error. Expecting %s, got %s", time.Millisecond*500, h.Min)
+	}
+	if h.Max != time.Hour*48 {
+		t.Errorf("observe error. Expecting %s, got %s", time.Hour*48, h.Max)
+	}
+	if q := h.Quantile(0.5); q != time.Minute {
+		t.Errorf("Quantile error. Expecting %s, got %s", time.Minute, q)
+	}
+	if q := h.Quantile(1); q != time.Hour*48 {
+		t.Errorf("Quantile error. Expecting %s, got %s", time.Hour*48, q)
+	}
+	if last := h.Buckets[len(h.Buckets)-1]; last.UpperBound != 0 || last.Count != 1 {
+		t.Errorf("observe error. Expecting last bucket {0 1}, got %v", last)
+	}
+}
+
+func TestChannelPool_ConnAgeStats(t *testing.T) {
+	p, _ := NewChannelPool(1, 2, factory)
+
+	c1, _ := p.Get()
+	c2, _ := p.Get()
+	_ = p.Put(c1)
+	_ = p.Put(c2) // 空闲已满
+
+	_ = p.Close()
+
+	stats := p.ConnAgeStats()
+	if h := stats[CloseReasonOverflow]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+	if h := stats[CloseReasonPoolClosed]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+	if _, ok := stats[CloseReasonHealthCheck]; ok {
+		t.Errorf("ConnAgeStats error. Expecting no %s stats", CloseReasonHealthCheck)
+	}
+}
@@ -0,0 +1,50 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+var (
+	ErrProtocolMismatch = errors.New("negotiated protocol mismatch")
+)
+
+type protocolKey struct{}
+
+// GetProtocol 获取ALPN协商了proto(如"h2"、"http/1.1")的conn, 只从协商了该协议的空闲conn中选取;
+// 没有时新建conn, 新建的conn协商了其他协议时放回pool供其他调用方使用, 并返回ErrProtocolMismatch
+func (p *ChannelPool) GetProtocol(ctx context.Context, proto string) (net.Conn, error) {
+	if proto != "" {
+		ctx = context.WithValue(ctx, protocolKey{}, proto)
+	}
+	return p.GetWitchContext(p.withCaller(ctx, 2))
+}
+
+// protocolOf ctx中要求的ALPN协议, 不要求时为空
+func protocolOf(ctx context.Context) string {
+	proto, _ := ctx.Value(protocolKey{}).(string)
+	return proto
+}
+
+// speaks conn是否协商了proto
+func (m *connMeta) speaks(proto string) bool {
+	return m.tls != nil && m.tls.NegotiatedProtocol == proto
+}
+
+// checkProtocol 检查取出的底层conn是否协商了proto
+func (p *ChannelPool) checkProtocol(conn net.Conn, proto string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	m, ok := p.conns[conn]
+	if !ok || m.speaks(proto) {
+		return nil
+	}
+	got := ""
+	if m.tls != nil {
+		got = m.tls.NegotiatedProtocol
+	}
+	return fmt.Errorf("%w: want %q, got %q", ErrProtocolMismatch, proto, got)
+}
@@ -0,0 +1,70 @@
+package pool
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestChannelPool_GetProtocol(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{NextProtos: []string{"h2", "http/1.1"}}
+	srv.StartTLS()
+	defer srv.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+
+	// 新建的conn交替协商h2和http/1.1
+	var mu sync.Mutex
+	dials := 0
+	p, err := NewChannelPool(2, 4, func() (net.Conn, error) {
+		mu.Lock()
+		proto := []string{"h2", "http/1.1"}[dials%2]
+		dials++
+		mu.Unlock()
+		return tls.Dial("tcp", srv.Listener.Addr().String(),
+			&tls.Config{RootCAs: roots, ServerName: "example.com", NextProtos: []string{proto}})
+	}, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx := context.Background()
+	if _, err := p.GetProtocol(ctx, "http/1.1"); !errors.Is(err, ErrProtocolMismatch) {
+		t.Fatalf("GetProtocol error. Expecting %v, got %v", ErrProtocolMismatch, err)
+	}
+	if p.Len() != 1 || p.InUse() != 0 {
+		t.Errorf("GetProtocol error. Expecting mismatched conn kept idle, got %d idle %d inUse", p.Len(), p.InUse())
+	}
+
+	// 跳过空闲的h2 conn
+	conn, err := p.GetProtocol(ctx, "http/1.1")
+	if err != nil {
+		t.Fatalf("GetProtocol error: %s", err)
+	}
+	if info, _ := conn.(*PoolConn).TLS(); info.NegotiatedProtocol != "http/1.1" {
+		t.Errorf("GetProtocol error. Expecting %q, got %q", "http/1.1", info.NegotiatedProtocol)
+	}
+	p.Put(conn)
+
+	for _, proto := range []string{"h2", "http/1.1"} {
+		conn, err := p.GetProtocol(ctx, proto)
+		if err != nil {
+			t.Fatalf("GetProtocol error: %s", err)
+		}
+		if info, _ := conn.(*PoolConn).TLS(); info.NegotiatedProtocol != proto {
+			t.Errorf("GetProtocol error. Expecting %q, got %q", proto, info.NegotiatedProtocol)
+		}
+		defer p.Put(conn)
+	}
+	if s := p.Stats(); s.Hits != 2 || s.Created != 2 {
+		t.Errorf("Stats error. Expecting %d hits %d created, got %d %d", 2, 2, s.Hits, s.Created)
+	}
+}
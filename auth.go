@@ -0,0 +1,100 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// Credential conn上的认证凭据, 如会话token
+type Credential struct {
+	Value interface{} // 凭据内容, 由Authenticate决定
+
+	ExpiresAt time.Time // 过期时间, 零值表示不过期
+}
+
+// Authenticate 在conn上认证并返回凭据, 新建conn时调用, 复用前凭据即将过期时再次调用;
+// 返回error时conn被关闭
+type Authenticate func(ctx context.Context, conn net.Conn, old Credential) (Credential, error)
+
+// authError 认证失败, 用于区分关闭原因
+type authError struct {
+	err error
+}
+
+func (e *authError) Error() string {
+	return "authenticate: " + e.err.Error()
+}
+
+func (e *authError) Unwrap() error {
+	return e.err
+}
+
+// needsRefresh 凭据是否将在refreshBefore内过期
+func (c Credential) needsRefresh(now time.Time, refreshBefore time.Duration) bool {
+	return !c.ExpiresAt.IsZero() && !now.Add(refreshBefore).Before(c.ExpiresAt)
+}
+
+// authenticate 新建conn后获取凭据
+func (p *ChannelPool) authenticate(ctx context.Context, conn net.Conn) (Credential, error) {
+	if p.auth == nil {
+		return Credential{}, nil
+	}
+	cred, err := p.auth(ctx, conn, Credential{})
+	if err != nil {
+		return Credential{}, &authError{err}
+	}
+	return cred, nil
+}
+
+// refreshAuth 复用前凭据即将过期时重新认证
+func (p *ChannelPool) refreshAuth(ctx context.Context, conn net.Conn) error {
+	p.mu.RLock()
+	m, ok := p.conns[conn]
+	var cred Credential
+	if ok {
+		cred = m.cred
+	}
+	p.mu.RUnlock()
+	if !ok || !cred.needsRefresh(time.Now(), p.authRefreshBefore) {
+		return nil
+	}
+
+	cred, err := p.auth(ctx, m.conn, cred)
+	if err != nil {
+		return &authError{err}
+	}
+	p.mu.Lock()
+	m.cred = cred
+	p.mu.Unlock()
+	return nil
+}
+
+// checkFailReason 检查失败时的关闭原因
+func checkFailReason(err error) CloseReason {
+	var ae *authError
+	if errors.As(err, &ae) {
+		return CloseReasonAuthFailed
+	}
+	return CloseReasonHealthCheck
+}
+
+// Credential 返回conn当前的凭据
+func (c *PoolConn) Credential() Credential {
+	c.p.mu.RLock()
+	defer c.p.mu.RUnlock()
+	if m, ok := c.p.conns[c.raw]; ok {
+		return m.cred
+	}
+	return Credential{}
+}
+
+// SetCredential 设置conn的凭据, 用于使用方自行完成认证的情况
+func (c *PoolConn) SetCredential(cred Credential) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+	if m, ok := c.p.conns[c.raw]; ok {
+		m.cred = cred
+	}
+}
@@ -0,0 +1,113 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_AuthRefresh(t *testing.T) {
+	var (
+		calls      int
+		failRenew  bool
+		errExpired = errors.New("token expired")
+	)
+	p, err := NewChannelPool(1, 1, factory,
+		WithAuth(func(ctx context.Context, conn net.Conn, old Credential) (Credential, error) {
+			calls++
+			if old.Value != nil && failRenew {
+				return Credential{}, errExpired
+			}
+			return Credential{Value: calls, ExpiresAt: time.Now().Add(time.Millisecond * 50)}, nil
+		}, time.Millisecond*20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 凭据未临近过期, 不重新认证
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if cred := conn.(*PoolConn).Credential(); cred.Value != 1 {
+		t.Errorf("Credential error. Expecting %d, got %v", 1, cred.Value)
+	}
+	p.Put(conn)
+
+	// 临近过期, 复用前重新认证
+	time.Sleep(time.Millisecond * 40)
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if cred := conn.(*PoolConn).Credential(); cred.Value != 2 {
+		t.Errorf("Credential error. Expecting %d, got %v", 2, cred.Value)
+	}
+	if id, _ := p.ConnID(conn); id != 1 {
+		t.Errorf("Get error. Expecting conn %d reused, got %d", 1, id)
+	}
+	p.Put(conn)
+
+	// 重新认证失败, conn被关闭并新建
+	failRenew = true
+	time.Sleep(time.Millisecond * 40)
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if id, _ := p.ConnID(conn); id != 2 {
+		t.Errorf("Get error. Expecting new conn %d, got %d", 2, id)
+	}
+	if h := p.ConnAgeStats()[CloseReasonAuthFailed]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+	p.Put(conn)
+}
+
+func TestPoolConn_SetCredential(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc := conn.(*PoolConn)
+	pc.SetCredential(Credential{Value: "token"})
+	p.Put(pc)
+
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if cred := conn.(*PoolConn).Credential(); cred.Value != "token" {
+		t.Errorf("Credential error. Expecting %q, got %v", "token", cred.Value)
+	}
+	p.Put(conn)
+}
+
+func TestChannelPool_AuthOnCreate(t *testing.T) {
+	errDenied := errors.New("denied")
+	p, err := NewChannelPool(1, 1, factory,
+		WithInitialConns(0),
+		WithAuth(func(ctx context.Context, conn net.Conn, old Credential) (Credential, error) {
+			return Credential{}, errDenied
+		}, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Get(); !errors.Is(err, errDenied) {
+		t.Errorf("Get error. Expecting %v, got %v", errDenied, err)
+	}
+	if p.OpenNum() != 0 || p.InUse() != 0 {
+		t.Errorf("Get error. Expecting %d, got %d open %d inUse", 0, p.OpenNum(), p.InUse())
+	}
+}
@@ -0,0 +1,42 @@
+package pool
+
+import (
+	"context"
+	"net"
+)
+
+// OnBackend 每次成功取出conn后以调用方的ctx调用, backend为conn的对端地址;
+// 可用于在请求的trace span上记录后端, 如 trace.SpanFromContext(ctx).SetAttributes(attribute.String("net.peer.name", backend))
+type OnBackend func(ctx context.Context, backend string)
+
+// Backend 返回pool中conn的对端地址, conn不属于pool或已被关闭时返回false
+func (p *ChannelPool) Backend(conn net.Conn) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	m, ok := p.conns[rawConn(conn)]
+	if !ok {
+		return "", false
+	}
+	return m.backend, true
+}
+
+// Backend 返回conn的对端地址, conn已被pool关闭时返回空
+func (c *PoolConn) Backend() string {
+	backend, _ := c.p.Backend(c.raw)
+	return backend
+}
+
+// backendInterceptor 取出conn后调用onBackend的拦截器
+func (p *ChannelPool) backendInterceptor(onBackend OnBackend) GetInterceptor {
+	return func(ctx context.Context, next GetFunc) (net.Conn, error) {
+		conn, err := next(ctx)
+		if err != nil {
+			return conn, err
+		}
+		if backend, ok := p.Backend(conn); ok {
+			onBackend(ctx, backend)
+		}
+		return conn, nil
+	}
+}
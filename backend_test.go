@@ -0,0 +1,40 @@
+package pool
+
+import (
+	"context"
+	"testing"
+)
+
+func TestChannelPool_BackendTrace(t *testing.T) {
+	var traced []string
+	p, err := NewChannelPool(3, 5, factory, WithBackendTrace(func(ctx context.Context, backend string) {
+		if v, ok := ctx.Value(traceKey{}).(string); ok {
+			traced = append(traced, v+" "+backend)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.GetWitchContext(context.WithValue(context.Background(), traceKey{}, "span-1"))
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if len(traced) != 1 || traced[0] != "span-1 "+address {
+		t.Errorf("WithBackendTrace error. Expecting %q, got %v", "span-1 "+address, traced)
+	}
+	if b := conn.(*PoolConn).Backend(); b != address {
+		t.Errorf("Backend error. Expecting %s, got %s", address, b)
+	}
+	p.Put(conn)
+
+	conn, _ = p.GetBuffered(context.Background())
+	if b, ok := p.Backend(conn); !ok || b != address {
+		t.Errorf("Backend error. Expecting %s, got %s", address, b)
+	}
+	p.Put(conn)
+	if _, ok := p.Backend(nil); ok {
+		t.Errorf("Backend error. Expecting false for unknown conn")
+	}
+}
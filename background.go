@@ -0,0 +1,28 @@
+package pool
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// goBackground 启动后台goroutine并计数, 用于WaitStopped
+func (p *ChannelPool) goBackground(f func()) {
+	atomic.AddInt64(&p.bgNum, 1)
+	go func() {
+		defer atomic.AddInt64(&p.bgNum, -1)
+		f()
+	}()
+}
+
+// WaitStopped 等待pool关闭且后台goroutine(后台关闭、watchdog、健康检查、新建的取消监听)全部退出,
+// ctx结束时返回超时错误; 超过WithCloseTimeout仍阻塞在Close上的conn也会被等待
+func (p *ChannelPool) WaitStopped(ctx context.Context) error {
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return timeoutErr(ctx)
+	}
+	return p.waitUntil(ctx, func() bool {
+		return atomic.LoadInt64(&p.bgNum) == 0
+	})
+}
@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_WaitStopped(t *testing.T) {
+	p, err := NewChannelPool(2, 3, factory,
+		WithWatchdog(time.Millisecond*20, func(StallEvent) {}),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error { return nil }),
+		WithHealthCheckHedge(time.Millisecond, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+
+	// 未关闭时一直等待
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	var te *TimeoutError
+	if err := p.WaitStopped(ctx); !errors.As(err, &te) {
+		t.Errorf("WaitStopped error. Expecting timeout, got %v", err)
+	}
+
+	p.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.WaitStopped(ctx); err != nil {
+		t.Errorf("WaitStopped error: %s", err)
+	}
+}
+
+func TestChannelPool_WaitStoppedSlowClose(t *testing.T) {
+	p, err := NewChannelPool(1, 1, func() (net.Conn, error) {
+		conn, err := factory()
+		if err != nil {
+			return nil, err
+		}
+		return &slowCloseConn{Conn: conn, delay: time.Millisecond * 200}, nil
+	}, WithCloseTimeout(time.Millisecond*10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	// Close超时返回后conn仍在后台关闭
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	var te *TimeoutError
+	if err := p.WaitStopped(ctx); !errors.As(err, &te) {
+		t.Errorf("WaitStopped error. Expecting timeout, got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.WaitStopped(ctx); err != nil {
+		t.Errorf("WaitStopped error: %s", err)
+	}
+}
@@ -0,0 +1,246 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrBudgetExhausted = errors.New("connection budget exhausted")
+)
+
+// DonatePolicy Budget在成员pool之间转移空闲conn的策略
+type DonatePolicy struct {
+	Interval time.Duration // 后台平衡的间隔, <= 0 不在后台平衡, 只在新建被拒绝时转移
+
+	KeepIdle int // 转出方至少保留的空闲conn数
+
+	MaxPerRound int // 后台每次平衡转给一个成员的conn数上限, <= 0 为1
+}
+
+// BudgetStats Budget的状态
+type BudgetStats struct {
+	Max     int   // conn总数上限
+	Open    int   // 成员pool的conn数及正在新建的conn数
+	Members int   // 成员pool数
+	Donated int64 // 在成员之间转移的conn数
+	Refused int64 // 名额用完且没有可转入的空闲conn而拒绝新建的次数
+}
+
+// budgetMember 加入Budget的pool
+type budgetMember struct {
+	p *ChannelPool
+
+	dest string // 后端标识, 相同dest的成员之间才转移conn, 为空时不参与转移
+
+	starved bool // 上次平衡后新建因名额用完被拒绝过
+}
+
+// Budget 多个pool共享的conn总数上限, 通过WithBudget加入; 成员新建conn时占用名额, conn关闭时归还.
+// 名额用完时不新建, 而是从相同dest的其他成员转来一个多余的空闲conn, 没有可转的conn时返回ErrBudgetExhausted;
+// policy.Interval > 0 时在后台定期把多余的空闲conn转给最近名额不足的成员.
+// 转入的conn(Donate、AdoptSystemdConns等)同样计入名额但不受上限限制
+type Budget struct {
+	max int
+
+	policy DonatePolicy
+
+	mu sync.Mutex
+
+	open int
+
+	members []*budgetMember
+
+	donated int64
+
+	refused int64
+
+	done chan struct{}
+
+	closeOnce sync.Once
+}
+
+// NewBudget 创建conn总数上限为max的Budget, 不再使用时调用Close停止后台平衡
+func NewBudget(max int, policy DonatePolicy) (*Budget, error) {
+	if max <= 0 || policy.KeepIdle < 0 {
+		return nil, errors.New("invalid budget")
+	}
+	if policy.MaxPerRound <= 0 {
+		policy.MaxPerRound = 1
+	}
+	b := &Budget{max: max, policy: policy, done: make(chan struct{})}
+	if policy.Interval > 0 {
+		go b.rebalancer()
+	}
+	return b, nil
+}
+
+// Close 停止后台平衡, 成员pool仍受上限限制
+func (b *Budget) Close() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
+
+// Stats 返回Budget的状态
+func (b *Budget) Stats() BudgetStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return BudgetStats{Max: b.max, Open: b.open, Members: len(b.members), Donated: b.donated, Refused: b.refused}
+}
+
+// join 加入pool
+func (b *Budget) join(p *ChannelPool, dest string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.members = append(b.members, &budgetMember{p: p, dest: dest})
+}
+
+// reserve 占用一个名额, 名额用完时标记p名额不足并返回false
+func (b *Budget) reserve(p *ChannelPool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.open < b.max {
+		b.open++
+		return true
+	}
+	for _, m := range b.members {
+		if m.p == p {
+			m.starved = true
+		}
+	}
+	return false
+}
+
+// add 计入不受上限限制的conn, b为nil时不做处理
+func (b *Budget) add() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.open++
+	b.mu.Unlock()
+}
+
+// release 归还一个名额, b为nil时不做处理
+func (b *Budget) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.open--
+	b.mu.Unlock()
+}
+
+// leave 移除已关闭的pool, 取出的conn关闭时仍归还名额
+func (b *Budget) leave(p *ChannelPool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, m := range b.members {
+		if m.p == p {
+			b.members = append(b.members[:i], b.members[i+1:]...)
+			return
+		}
+	}
+}
+
+// donors 返回与p的dest相同的其他成员
+func (b *Budget) donors(p *ChannelPool) []*ChannelPool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	dest := ""
+	for _, m := range b.members {
+		if m.p == p {
+			dest = m.dest
+		}
+	}
+	if dest == "" {
+		return nil
+	}
+	var donors []*ChannelPool
+	for _, m := range b.members {
+		if m.p != p && m.dest == dest {
+			donors = append(donors, m.p)
+		}
+	}
+	return donors
+}
+
+// borrow 从相同dest的其他成员转来一个空闲conn, 登记为p取出的conn
+func (b *Budget) borrow(p *ChannelPool) (net.Conn, bool) {
+	for _, donor := range b.donors(p) {
+		conn, m, ok := donor.popSurplus(b.policy.KeepIdle)
+		if !ok {
+			continue
+		}
+		if !p.adoptBusy(conn, m) {
+			donor.restoreIdle(conn)
+			return nil, false
+		}
+		donor.donated(conn)
+		b.mu.Lock()
+		b.donated++
+		b.mu.Unlock()
+		return conn, true
+	}
+	return nil, false
+}
+
+// rebalancer 每隔policy.Interval把多余的空闲conn转给名额不足的成员
+func (b *Budget) rebalancer() {
+	ticker := time.NewTicker(b.policy.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			b.rebalance()
+		}
+	}
+}
+
+// rebalance 把多余的空闲conn转给上次平衡后名额不足的成员, 每个成员至多转入policy.MaxPerRound个
+func (b *Budget) rebalance() {
+	b.mu.Lock()
+	var starved []*ChannelPool
+	for _, m := range b.members {
+		if m.starved {
+			m.starved = false
+			starved = append(starved, m.p)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, p := range starved {
+		want := b.policy.MaxPerRound
+		for _, donor := range b.donors(p) {
+			if want == 0 {
+				break
+			}
+			n := donor.donate(p, want, b.policy.KeepIdle)
+			want -= n
+			b.mu.Lock()
+			b.donated += int64(n)
+			b.mu.Unlock()
+		}
+	}
+}
+
+// budgetAcquire 新建前占用Budget名额, 未设置WithBudget或占用成功时返回nil, nil;
+// 名额用完时返回从其他成员转来的conn, 没有可转的conn时返回错误
+func (p *ChannelPool) budgetAcquire() (net.Conn, error) {
+	if p.budget == nil || p.budget.reserve(p) {
+		return nil, nil
+	}
+	if conn, ok := p.budget.borrow(p); ok {
+		return conn, nil
+	}
+	p.budget.mu.Lock()
+	p.budget.refused++
+	p.budget.mu.Unlock()
+	p.mu.Lock()
+	p.dialThrottledNum++
+	p.mu.Unlock()
+	return nil, &DialThrottledError{Limiter: LimiterBudget, Err: ErrBudgetExhausted}
+}
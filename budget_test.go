@@ -0,0 +1,124 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBudget_Borrow(t *testing.T) {
+	b, err := NewBudget(3, DonatePolicy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	from, err := NewChannelPool(3, 3, factory, WithBudget(b, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer from.Close()
+	to, err := NewChannelPool(2, 3, factory, WithInitialConns(0), WithBudget(b, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer to.Close()
+	other, err := NewChannelPool(1, 1, factory, WithInitialConns(0), WithBudget(b, "other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	if s := b.Stats(); s.Open != 3 || s.Members != 3 {
+		t.Errorf("Budget error. Expecting 3 open 3 members, got %d %d", s.Open, s.Members)
+	}
+
+	// 名额用完时从相同dest的成员转来空闲conn, 不新建
+	conn, err := to.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Errorf("Write error: %s", err)
+	}
+	fs, ts := from.Stats(), to.Stats()
+	if fs.Donated != 1 || fs.Open != 2 || ts.Adopted != 1 || ts.Open != 1 {
+		t.Errorf("Budget error. Expecting 1 donated 2 open, 1 adopted 1 open, got %d %d, %d %d",
+			fs.Donated, fs.Open, ts.Adopted, ts.Open)
+	}
+	if s := b.Stats(); s.Open != 3 || s.Donated != 1 {
+		t.Errorf("Budget error. Expecting 3 open 1 donated, got %d %d", s.Open, s.Donated)
+	}
+
+	// dest不同的成员不转移
+	if _, err := other.Get(); !errors.Is(err, ErrBudgetExhausted) || !errors.Is(err, ErrDialBudgetExceeded) {
+		t.Errorf("Get error. Expecting %v, got %v", ErrBudgetExhausted, err)
+	}
+	if s := b.Stats(); s.Refused != 1 {
+		t.Errorf("Budget error. Expecting %d refused, got %d", 1, s.Refused)
+	}
+
+	// 关闭conn归还名额
+	to.Put(conn)
+	_ = to.Close()
+	if s := b.Stats(); s.Open != 2 {
+		t.Errorf("Budget error. Expecting %d open, got %d", 2, s.Open)
+	}
+	c, err := other.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	other.Put(c)
+	if s := b.Stats(); s.Open != 3 || s.Members != 2 {
+		t.Errorf("Budget error. Expecting 3 open 2 members, got %d %d", s.Open, s.Members)
+	}
+}
+
+func TestBudget_Rebalance(t *testing.T) {
+	b, err := NewBudget(4, DonatePolicy{Interval: time.Millisecond * 20, KeepIdle: 1, MaxPerRound: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	from, err := NewChannelPool(4, 4, factory, WithBudget(b, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer from.Close()
+	to, err := NewChannelPool(3, 3, factory, WithInitialConns(0), WithBudget(b, "echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer to.Close()
+
+	// 名额不足后, 后台转入至多MaxPerRound个conn, 转出方保留KeepIdle个
+	conn, err := to.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	to.Put(conn)
+	deadline := time.Now().Add(time.Second)
+	for to.Len() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 5)
+	}
+	if to.Len() != 3 || from.Len() != 1 {
+		t.Errorf("Rebalance error. Expecting 3 and 1 idle, got %d %d", to.Len(), from.Len())
+	}
+	if s := b.Stats(); s.Open != 4 || s.Donated != 3 {
+		t.Errorf("Budget error. Expecting 4 open 3 donated, got %d %d", s.Open, s.Donated)
+	}
+
+	// 没有名额不足的成员时不再转移
+	time.Sleep(time.Millisecond * 60)
+	if from.Len() != 1 {
+		t.Errorf("Rebalance error. Expecting %d idle kept, got %d", 1, from.Len())
+	}
+}
+
+func TestNewBudget_Invalid(t *testing.T) {
+	if _, err := NewBudget(0, DonatePolicy{}); err == nil {
+		t.Error("NewBudget error. Expecting invalid max rejected")
+	}
+	if _, err := NewBudget(1, DonatePolicy{KeepIdle: -1}); err == nil {
+		t.Error("NewBudget error. Expecting invalid keep idle rejected")
+	}
+}
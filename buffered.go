@@ -0,0 +1,159 @@
+package pool
+
+import (
+	"bufio"
+	"context"
+	"sync"
+)
+
+// 默认读写缓冲区大小
+const defaultBufferSize = 4096
+
+// BufferedPoolConn 带读写缓冲的PoolConn, 缓冲区在pool内复用, 每次取出时重置;
+// 通过Put放回时先Flush, 读缓冲中还有未读数据时conn被关闭
+type BufferedPoolConn struct {
+	*PoolConn
+
+	Reader *bufio.Reader // 放回后为nil
+
+	Writer *bufio.Writer // 放回后为nil
+
+	mu sync.Mutex
+
+	released bool // 缓冲区已归还
+}
+
+// bufferPool 复用的读写缓冲区
+type bufferPool struct {
+	readers sync.Pool
+	writers sync.Pool
+}
+
+func newBufferPool(size int) *bufferPool {
+	return &bufferPool{
+		readers: sync.Pool{New: func() interface{} { return bufio.NewReaderSize(nil, size) }},
+		writers: sync.Pool{New: func() interface{} { return bufio.NewWriterSize(nil, size) }},
+	}
+}
+
+// GetBuffered 同GetWitchContext, 返回带复用读写缓冲的conn
+func (p *ChannelPool) GetBuffered(ctx context.Context) (*BufferedPoolConn, error) {
+	conn, err := p.GetWitchContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pc, ok := conn.(*PoolConn)
+	if !ok {
+		// 拦截器返回了其他conn
+		pc = &PoolConn{Conn: conn, p: p, raw: rawConn(conn)}
+	}
+
+	r := p.buffers.readers.Get().(*bufio.Reader)
+	w := p.buffers.writers.Get().(*bufio.Writer)
+	r.Reset(pc)
+	w.Reset(pc)
+	return &BufferedPoolConn{PoolConn: pc, Reader: r, Writer: w}, nil
+}
+
+// Read 从读缓冲读取
+func (c *BufferedPoolConn) Read(b []byte) (int, error) {
+	if err := c.checkReturned(); err != nil {
+		return 0, err
+	}
+	r := c.Reader
+	if r == nil {
+		return 0, ErrConnReturned
+	}
+	return r.Read(b)
+}
+
+// Write 写入写缓冲, 需调用Flush发送
+func (c *BufferedPoolConn) Write(b []byte) (int, error) {
+	if err := c.checkReturned(); err != nil {
+		return 0, err
+	}
+	w := c.Writer
+	if w == nil {
+		return 0, ErrConnReturned
+	}
+	return w.Write(b)
+}
+
+// Flush 发送写缓冲中的数据
+func (c *BufferedPoolConn) Flush() error {
+	if err := c.checkReturned(); err != nil {
+		return err
+	}
+	w := c.Writer
+	if w == nil {
+		return ErrConnReturned
+	}
+	return w.Flush()
+}
+
+// put 发送未发送的数据后放回conn, 之后归还缓冲区
+func (c *BufferedPoolConn) put() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p := c.p
+	if c.released {
+		// 重复放回
+		return p.Put(c.PoolConn)
+	}
+
+	err := c.Writer.Flush()
+	unread := c.Reader.Buffered() > 0
+	r, w := c.detach()
+	// conn放回或关闭、PoolConn失效后才归还缓冲区, 避免仍持有本次取出的调用方写坏其他取出方的缓冲区
+	defer p.recycleBuffers(r, w)
+
+	switch {
+	case err != nil:
+		p.discard(c.PoolConn, CloseReasonBroken)
+		return err
+	case unread:
+		// 上一个使用方没有读完响应, 复用会打乱下一个使用方的协议状态
+		p.discard(c.PoolConn, CloseReasonUnreadData)
+		return nil
+	}
+	return p.Put(c.PoolConn)
+}
+
+// Close 同Put: 发送写缓冲中的数据并归还缓冲区后放回conn, 发送失败时关闭conn; 已放回时返回ErrConnReturned
+func (c *BufferedPoolConn) Close() error {
+	if c.stale() && !c.p.devMode {
+		return ErrConnReturned
+	}
+	return c.put()
+}
+
+// discard 归还缓冲区并关闭conn
+func (c *BufferedPoolConn) discard(reason CloseReason) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.released {
+		c.p.discard(c.PoolConn, reason)
+		return
+	}
+	r, w := c.detach()
+	c.p.discard(c.PoolConn, reason)
+	c.p.recycleBuffers(r, w)
+}
+
+// detach 取下缓冲区, 之后Reader和Writer为nil, 需持有c.mu
+func (c *BufferedPoolConn) detach() (*bufio.Reader, *bufio.Writer) {
+	c.released = true
+	r, w := c.Reader, c.Writer
+	c.Reader, c.Writer = nil, nil
+	return r, w
+}
+
+// recycleBuffers 把缓冲区归还给pool复用
+func (p *ChannelPool) recycleBuffers(r *bufio.Reader, w *bufio.Writer) {
+	r.Reset(nil)
+	w.Reset(nil)
+	p.buffers.readers.Put(r)
+	p.buffers.writers.Put(w)
+}
@@ -0,0 +1,193 @@
+package pool
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestChannelPool_GetBuffered(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory, WithBufferSize(512))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	bc, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatalf("GetBuffered error: %s", err)
+	}
+	if bc.Reader.Size() != 512 || bc.Writer.Size() != 512 {
+		t.Errorf("GetBuffered error. Expecting size %d, got %d/%d", 512, bc.Reader.Size(), bc.Writer.Size())
+	}
+	raw := bc.RawConn()
+
+	// 未Flush的数据在放回时发送
+	if _, err := bc.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write error: %s", err)
+	}
+	if err := p.Put(bc); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 1 {
+		t.Fatalf("Put error. Expecting %d, got %d", 1, p.Len())
+	}
+
+	bc, err = p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatalf("GetBuffered error: %s", err)
+	}
+	if bc.RawConn() != raw {
+		t.Errorf("GetBuffered error. Expecting the same pooled conn")
+	}
+	buf := make([]byte, 256)
+	if _, err := io.ReadFull(bc, buf); err != nil {
+		t.Fatalf("Read error: %s", err)
+	}
+	if string(buf[:5]) != "hello" {
+		t.Errorf("Read error. Expecting %q, got %q", "hello", buf[:5])
+	}
+	if err := p.Put(bc); err != nil {
+		t.Error(err)
+	}
+	// 重复放回被忽略
+	if err := p.Put(bc); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d", 1, p.Len())
+	}
+}
+
+func TestChannelPool_GetBufferedUnread(t *testing.T) {
+	p, err := NewChannelPool(1, 1, func() (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			server.Write([]byte("a\nb\n"))
+		}()
+		return client, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	bc, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatalf("GetBuffered error: %s", err)
+	}
+	line, err := bc.Reader.ReadString('\n')
+	if err != nil || line != "a\n" {
+		t.Fatalf("ReadString error. Expecting %q, got %q %v", "a\n", line, err)
+	}
+
+	// 读缓冲中还有 "b\n", conn被关闭
+	if err := p.Put(bc); err != nil {
+		t.Error(err)
+	}
+	if p.OpenNum() != 0 {
+		t.Errorf("Put error. Expecting %d, got %d", 0, p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonUnreadData]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestBufferedPoolConn_Close(t *testing.T) {
+	received := make(chan string, 1)
+	p, err := NewChannelPool(1, 1, func() (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			buf := make([]byte, 16)
+			n, _ := server.Read(buf)
+			received <- string(buf[:n])
+		}()
+		return client, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	bc, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatalf("GetBuffered error: %s", err)
+	}
+	if _, err := bc.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write error: %s", err)
+	}
+	// Close 发送未Flush的数据后放回conn
+	if err := bc.Close(); err != nil {
+		t.Errorf("Close error: %s", err)
+	}
+	if got := <-received; got != "hello" {
+		t.Errorf("Close error. Expecting %q, got %q", "hello", got)
+	}
+	if p.Len() != 1 || p.OpenNum() != 1 {
+		t.Errorf("Close error. Expecting 1 idle 1 open, got %d %d", p.Len(), p.OpenNum())
+	}
+	if err := bc.Close(); err != ErrConnReturned {
+		t.Errorf("Close error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+}
+
+func TestBufferedPoolConn_CloseFlushError(t *testing.T) {
+	servers := make(chan net.Conn, 1)
+	p, err := NewChannelPool(1, 1, func() (net.Conn, error) {
+		client, server := net.Pipe()
+		servers <- server
+		return client, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	bc, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatalf("GetBuffered error: %s", err)
+	}
+	// 对端关闭后发送失败
+	(<-servers).Close()
+	_, _ = bc.Write([]byte("hello"))
+	// 发送失败时关闭conn而不是放回
+	if err := bc.Close(); err == nil {
+		t.Error("Close error. Expecting flush error")
+	}
+	if p.Len() != 0 || p.OpenNum() != 0 {
+		t.Errorf("Close error. Expecting no conns, got %d idle %d open", p.Len(), p.OpenNum())
+	}
+}
+
+func TestBufferedPoolConn_PutDetachesBuffers(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	old, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatalf("GetBuffered error: %s", err)
+	}
+	if err := p.Put(old); err != nil {
+		t.Error(err)
+	}
+	// 放回后不再持有已归还的缓冲区
+	if old.Reader != nil || old.Writer != nil {
+		t.Errorf("Put error. Expecting buffers detached, got %v %v", old.Reader, old.Writer)
+	}
+
+	bc, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatalf("GetBuffered error: %s", err)
+	}
+	defer p.Put(bc)
+	if _, err := old.Write([]byte("stale")); err != ErrConnReturned {
+		t.Errorf("Write error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if n := bc.Writer.Buffered(); n != 0 {
+		t.Errorf("Write error. Expecting %d buffered for the new holder, got %d", 0, n)
+	}
+}
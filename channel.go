@@ -2,156 +2,884 @@ package pool
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
 	"sync"
+	"time"
+
+	"ConnPool/poolcore"
 )
 
-type channelPool struct {
+// ChannelPool 由 NewChannelPool 创建, 实现 Pool 和 PoolV2
+type ChannelPool struct {
 
-	//保证并发安全(connCh的修改)
+	//保证并发安全(idle及各计数的修改)
 	mu sync.RWMutex
 
-	//存储未使用的conn
-	connCh chan net.Conn
+	//存储未使用的conn, 先放回的先取出
+	idle poolcore.IdleList
+
+	// 有等待者时放回的conn, 只由等待者取出, 不计入空闲conn
+	handoff poolcore.IdleList
+
+	// 总容量, 取出的conn和正在新建的conn各占一个单位, nil 不限制
+	sem poolcore.WaitQueue
 
 	closed bool // pool是否已关闭
 
 	// net.Conn 生产者
-	factory Factory
+	factory FactoryContext
+
+	maxConn int64 // 最大conn数量, unlimited 时为0
+
+	unlimited bool // 不限制conn总数
+
+	autoMaxConn bool // 通过New创建且未设置WithMaxConn, maxConn按maxFree取默认值
+
+	maxFree int64 // 最大空闲conn数量, 未设置maxIdle时为Put保留的空闲conn上限和初始conn数
+
+	maxIdle int64 // Put时保留的空闲conn上限, 超过时关闭放回的conn, 默认为maxFree
+
+	warmIdle int // 保持的空闲conn数, 空闲conn被取出后少于该值时在后台新建, 0 不保持
+
+	warmWake chan struct{} // 通知warmKeeper空闲conn少于warmIdle, 未设置warmIdle时为nil
+
+	initialConns int64 // 创建pool时建立的conn数量, 默认为maxFree
+
+	initialSet bool // 设置了WithInitialConns
+
+	opts []Option // 创建时的配置, 用于CloneWith
+
+	inheriting bool // 正在应用CloneWith继承的配置
+
+	getTimeout time.Duration // Get的默认超时时间, <= 0 不限制
+
+	acct poolcore.Accounting // 已创建未关闭、累计创建和累计关闭的conn数
+
+	healthCheck HealthCheck // 空闲conn取出时的健康检查, nil 不检查
+
+	healthCheckTimeout time.Duration // 单次健康检查超时时间
+
+	checkOnPut bool // 放回时也执行健康检查
+
+	hedgeDelay time.Duration // 健康检查超过该时间未完成时并行检查其他空闲conn
+
+	hedgeParallel int // 最多同时检查的空闲conn数
+
+	closeTimeout time.Duration // 关闭底层conn的超时时间
+
+	closeQueueSize int // 后台关闭队列长度
+
+	closeCh chan net.Conn // 等待后台关闭的conn
+
+	closerDone chan struct{} // 后台closer退出
+
+	conns map[net.Conn]*connMeta // 已创建未关闭的conn
+
+	nextID uint64 // 最近分配的conn序号
+
+	hits int64 // 取到已有conn的次数
+
+	misses int64 // 需要新建conn的次数
+
+	waits int64 // 需要等待conn放回的次数
+
+	waitDuration time.Duration // 累计等待时间
+
+	timeouts int64 // 等待超时次数
+
+	waitHist [len(waitBounds) + 1]int64 // 等待时长分布
+
+	acquireNum int64 // 获取conn的次数, 原子访问
+
+	acquireErrNum int64 // 获取conn失败的次数, 原子访问
+
+	ages map[CloseReason]*AgeHistogram // 按关闭原因统计的conn存活时长
+
+	onCreate OnCreate // 新建conn后调用
+
+	interceptors []GetInterceptor // 获取conn的拦截器
+
+	get GetFunc // 串上拦截器后的获取函数
+
+	traceSize int // 每个conn保留的事件数, <= 0 不记录
+
+	closedTraces []closedTrace // 最近关闭的conn的事件
+
+	wrapConn func(net.Conn) net.Conn // 包装每个新建的conn, nil 不包装
+
+	keepAlive time.Duration // TCP keepalive 探测间隔, <= 0 不设置
+
+	portBackoffMin, portBackoffMax time.Duration // 本地端口耗尽后暂停新建的时长范围
+
+	portBackoff time.Duration // 当前退避时长, 新建成功后清零
+
+	portBackoffUntil time.Time // 退避期结束时间
+
+	portExhaustedNum int64 // 因本地端口耗尽导致的新建失败次数
+
+	onPortExhausted OnPortExhausted // 本地端口耗尽时调用
+
+	dialProbe bool // 新建失败后同一时间只允许一个探测新建
+
+	probePolicy ProbePolicy // 探测进行中时其他新建的处理方式
+
+	dialErr error // 最近一次新建失败的错误, 成功后清空, 仅开启dialProbe时记录
+
+	probe *dialProbe // 进行中的探测新建
+
+	copyIdleTimeout time.Duration // CopyTo/CopyFrom每次读写的超时时间, <= 0 不限制
+
+	onFull OnFull // Put因空闲已满关闭conn时调用
+
+	overflowNum int64 // Put因空闲已满关闭的conn数
+
+	handoffNum int64 // Put时直接交给等待者的conn数
+
+	waitProtos map[string]int // 正在等待容量的调用按要求的ALPN协议计数, 不要求时为""
+
+	churnLimit int // churnWindow内允许新建的conn数, <= 0 不限制
+
+	churnWindow time.Duration
+
+	churnRefuse bool // 超过上限时拒绝新建, 否则只调用onChurn
+
+	onChurn OnChurn
+
+	bestEffortFill bool // 初始conn新建失败时不返回错误, 在后台补建
+
+	onFill OnFill
+
+	churnDials []time.Time // churnWindow内的新建时间, 至多churnLimit个
+
+	churnAlerted time.Time // 最近一次调用onChurn的时间
+
+	churnLimitedNum int64 // 因新建速率超限被拒绝的新建次数
+
+	dialThrottledNum int64 // 新建被爬坡推迟、被WithChurnGuard拒绝或因Budget名额用完被拒绝的次数
+
+	spinWait int // 容量已满时进入等待队列前自旋重试的次数, 0 不自旋
+
+	spinHits int64 // 自旋期间获得容量的次数
+
+	quarantine *quarantine // 健康检查连续失败的后端, 未开启时为nil
+
+	quarantinedNum int64 // 后端进入隔离期的次数
+
+	dialTries int // 新建时后端不可用的最多尝试次数, <= 1 不重试
 
-	maxConn int64 // 最大conn数量, <= 0 不限制
+	dialRetryNum int64 // 新建因后端不可用而重试的次数
 
-	maxFree int64 // 最大空闲conn数量
+	standbyFactory FactoryContext // 备用后端的factory, 未设置或已切换到备用后端时为nil
 
-	openNum int64 // 已创建连接数
+	standbySize int // 保持的备用conn数
+
+	standby []*preparedConn // 已建立的备用conn, 不登记在conns中
+
+	standbyWake chan struct{} // 通知standbyKeeper已切换到备用后端
+
+	failoverNum int64 // 切换到备用后端的次数
+
+	migrating bool // 正在MigrateTo
+
+	migrateTotal int // 最近一次迁移开始时的conn数
+
+	migratePending int // 尚未关闭的旧conn数
+
+	budget *Budget // 共享conn总数上限, 未设置时为nil
+
+	budgetDest string // 在Budget中的后端标识
+
+	degradeThreshold int // 进入降级的连续新建或健康检查失败次数, 0 不检测
+
+	onDegrade OnDegrade
+
+	failStreak int // 连续新建或健康检查失败的次数
+
+	degraded bool // 是否处于降级状态
+
+	strictAccounting bool // 统计异常时返回AccountingError而不是忽略
+
+	anomalyNum int64 // 统计异常的次数, 仅开启strictAccounting时记录
+
+	closedPutPolicy ClosedPutPolicy // pool关闭后Put的处理方式
+
+	onClosedPut OnClosedPut
+
+	tlsConfig *tls.Config // WithTLSClient的配置, 为nil时不包装TLS
+
+	handshakeStage HandshakeStage // TLS握手的时机
+
+	name string // pool名称, 用于指标和错误信息
+
+	metricsConfig MetricsConfig // WriteMetrics输出的指标和标签
+
+	getErrContext bool // Get的错误用GetError包装
+
+	liveness LivenessProbe // 空闲conn取出时的存活探测, nil 只在开启keepalive时用PeekLiveness探测TCP conn
+
+	opStats map[string]*OperationStats // 按WithOperation的操作名统计, 没有记录操作时为nil
+
+	lastDialErr error // 最近一次新建失败的错误
+
+	lastDialErrAt time.Time
+
+	dialLatency latencyWindow // 新建conn时factory的耗时
+
+	handshakeLatency latencyWindow // 新建conn时OnCreate的耗时
+
+	maxIdleTime time.Duration // 空闲conn的最大空闲时间, <= 0 不限制
+
+	maxLifetime time.Duration // conn的最大存活时间, <= 0 不限制
+
+	reaping bool // 后台正在清理超过最大存活时间的空闲conn
+
+	reapWake chan struct{} // 通知reaper最大存活时间已修改
+
+	expiryJitter float64 // 过期时长随机缩短的最大比例
+
+	tlsExpiryMargin time.Duration // TLS conn在对端证书过期前多久淘汰
+
+	rampCurve RampCurve // 爬坡期间的并发新建上限, nil 不限制
+
+	rampStart time.Time // 本次爬坡开始时间, 零值表示未在爬坡
+
+	bgNum int64 // 正在运行的后台goroutine数, 原子访问
+
+	dialing int // 正在新建的conn数, 仅设置rampCurve时统计
+
+	dialWake chan struct{} // 有新建结束时关闭
+
+	stallTimeout time.Duration // 等待者超过该时间无进展时调用onStall
+
+	onStall OnStall // 检测到等待无进展时调用, nil 不检测
+
+	progressNum int64 // 累计归还的容量单位数, 原子访问
+
+	done chan struct{} // pool关闭时关闭
+
+	bufferSize int // GetBuffered的读写缓冲区大小
+
+	buffers *bufferPool // GetBuffered复用的读写缓冲区
+
+	unreadCheck bool // 放回时检查conn上是否有未读数据
+
+	gen uint64 // 当前代数, 每次Drain加一
+
+	holdProfiler *holdProfiler // 持有时间采样, nil 不采样
+
+	auth Authenticate // 新建conn及凭据即将过期时的认证, nil 不认证
+
+	authRefreshBefore time.Duration // 凭据在过期前多久重新认证
+
+	pinnedNum int // 通过GetPinned取出未放回的conn数
+
+	pinnedGets int64 // GetPinned的次数
+
+	maxPinned int64 // 长期持有的conn数上限, <= 0 不限制
+
+	pinSem poolcore.WaitQueue // 长期持有名额, nil 不限制
+
+	weighted poolcore.Weighted // 外部提供的容量, nil 使用maxConn
+
+	weight int64 // 每个conn占用weighted的单位数
+
+	donatedNum int64 // 转给其他pool的conn数
+
+	adoptedNum int64 // 从其他pool转入的conn数
+
+	readinessProbe HealthCheck // Healthy在取出的conn上执行的检查, nil 只检查能否取出
+
+	workload *workloadRecorder // 记录每次取出的时间, nil 不记录
+
+	devMode bool // 发现误用时panic, 见WithDevelopmentMode
 }
 
 var (
 	ErrTimeOut = errors.New("time out")
+	ErrNilConn = errors.New("connection is nil")
 )
 
 // Factory net.Conn 生产者
 type Factory func() (net.Conn, error)
 
-func NewChannelPool(maxFree, maxConn int64, factory Factory) (*channelPool, error) {
+// FactoryContext 接收ctx的net.Conn生产者, ctx在调用方放弃等待或pool关闭时取消,
+// 实现应把ctx传给 net.Dialer.DialContext 等, 避免pool关闭后还有未完成的新建
+type FactoryContext func(ctx context.Context) (net.Conn, error)
 
-	if maxFree <= 0 || maxConn < 0 || maxFree > maxConn {
-		return nil, errors.New("invalid capacity settings")
+// NewChannelPool 创建pool, maxConn 须不小于 maxFree; 使用 WithUnlimitedConns 时不限制conn总数, maxConn 须为0
+func NewChannelPool(maxFree, maxConn int64, factory Factory, opts ...Option) (*ChannelPool, error) {
+	var f FactoryContext
+	if factory != nil {
+		f = func(context.Context) (net.Conn, error) {
+			return factory()
+		}
 	}
+	return NewChannelPoolContext(maxFree, maxConn, f, opts...)
+}
 
-	p := &channelPool{
-		connCh:  make(chan net.Conn, maxFree),
+// New 未设置时的默认容量
+const (
+	defaultMaxFree = 2
+	defaultMaxConn = 16
+)
+
+// New 只需要factory的构造方式, 容量等配置都通过opts设置, 未设置时使用默认值:
+// maxFree(WithMaxFree)为2, maxConn(WithMaxConn)为maxFree和16中较大的值, 使用WithUnlimitedConns时不限制;
+// 其余配置的默认值与NewChannelPoolContext相同, 同样在创建时校验
+func New(factory FactoryContext, opts ...Option) (*ChannelPool, error) {
+	all := make([]Option, 0, len(opts)+1)
+	all = append(all, func(p *ChannelPool) { p.autoMaxConn = true })
+	all = append(all, opts...)
+	return NewChannelPoolContext(defaultMaxFree, 0, factory, all...)
+}
+
+// NewChannelPoolContext 同 NewChannelPool, 使用接收ctx的factory
+func NewChannelPoolContext(maxFree, maxConn int64, factory FactoryContext, opts ...Option) (*ChannelPool, error) {
+	return newChannelPool(maxFree, maxConn, factory, nil, opts)
+}
+
+// newChannelPool 先应用CloneWith继承的配置inherited, 再应用opts
+func newChannelPool(maxFree, maxConn int64, factory FactoryContext, inherited, opts []Option) (*ChannelPool, error) {
+	p := &ChannelPool{
 		factory: factory,
 		maxConn: maxConn,
 		maxFree: maxFree,
+		conns:   make(map[net.Conn]*connMeta),
+		ages:    make(map[CloseReason]*AgeHistogram),
+	}
+	p.opts = append(append([]Option(nil), inherited...), opts...)
+	p.portBackoffMin = defaultPortBackoffMin
+	p.portBackoffMax = defaultPortBackoffMax
+	p.inheriting = true
+	for _, opt := range inherited {
+		opt(p)
+	}
+	p.inheriting = false
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.autoMaxConn {
+		switch {
+		case p.unlimited:
+			p.maxConn = 0
+		case p.maxFree > defaultMaxConn:
+			p.maxConn = p.maxFree
+		default:
+			p.maxConn = defaultMaxConn
+		}
+	}
+	maxFree, maxConn = p.maxFree, p.maxConn
+	if p.maxIdle == 0 {
+		p.maxIdle = maxFree
+	}
+	if !p.initialSet {
+		p.initialConns = p.maxIdle
+		if p.warmIdle > 0 {
+			p.initialConns = int64(p.warmIdle)
+		}
+	}
+
+	if factory == nil {
+		return nil, errors.New("invalid factory")
+	}
+	if p.unlimited {
+		if maxFree <= 0 || maxConn != 0 {
+			return nil, errors.New("invalid capacity settings")
+		}
+	} else if maxFree <= 0 || maxConn < 0 || maxFree > maxConn {
+		return nil, errors.New("invalid capacity settings")
+	}
+	if p.portBackoffMin <= 0 || p.portBackoffMax < p.portBackoffMin {
+		return nil, errors.New("invalid port exhaustion backoff")
+	}
+	if p.maxPinned < 0 || (p.maxPinned > 0 && !p.unlimited && p.maxPinned >= maxConn) {
+		return nil, errors.New("invalid max pinned conns")
+	}
+	if p.expiryJitter < 0 || p.expiryJitter >= 1 {
+		return nil, errors.New("invalid expiry jitter")
+	}
+	if p.churnLimit < 0 || (p.churnLimit > 0 && p.churnWindow <= 0) {
+		return nil, errors.New("invalid churn guard")
+	}
+	if p.tlsExpiryMargin < 0 {
+		return nil, errors.New("invalid tls expiry margin")
+	}
+	if p.maxIdle < 0 || (!p.unlimited && p.maxIdle > maxConn) {
+		return nil, errors.New("invalid max idle conns")
+	}
+	if p.warmIdle < 0 || int64(p.warmIdle) > p.maxIdle {
+		return nil, errors.New("invalid warm idle conns")
+	}
+	if p.dialTries < 0 {
+		return nil, errors.New("invalid dial retry tries")
+	}
+	if p.standbySize < 0 || (p.standbySize > 0 && p.standbyFactory == nil) {
+		return nil, errors.New("invalid standby settings")
+	}
+	if p.initialConns < 0 || p.initialConns > p.maxIdle {
+		return nil, errors.New("invalid initial conns")
+	}
+	if p.spinWait < 0 {
+		return nil, errors.New("invalid spin wait")
+	}
+	if p.weighted != nil && p.weight <= 0 {
+		return nil, errors.New("invalid weighted semaphore")
+	}
+	if q := p.quarantine; q != nil && (q.threshold <= 0 || q.min <= 0 || q.max < q.min) {
+		return nil, errors.New("invalid quarantine")
+	}
+	if p.closedPutPolicy == ClosedPutCallback && p.onClosedPut == nil {
+		return nil, errors.New("closed put callback is nil")
+	}
+	if err := p.metricsConfig.validate(); err != nil {
+		return nil, err
+	}
+	if p.degradeThreshold < 0 {
+		return nil, errors.New("invalid degrade threshold")
+	}
+	switch {
+	case p.weighted != nil:
+		p.sem = poolcore.NewWeightedQueue(p.weighted, p.weight)
+	case !p.unlimited:
+		p.sem = poolcore.NewSemaphore(maxConn)
+	}
+	if p.maxPinned > 0 {
+		p.pinSem = poolcore.NewSemaphore(p.maxPinned)
+	}
+	p.dialWake = make(chan struct{})
+	if p.budget != nil {
+		p.budget.join(p, p.budgetDest)
+	}
+	if p.bufferSize <= 0 {
+		p.bufferSize = defaultBufferSize
+	}
+	p.buffers = newBufferPool(p.bufferSize)
+	p.get = chainInterceptors(p.interceptors, p.getConn)
+	if p.closeQueueSize <= 0 {
+		p.closeQueueSize = defaultCloseQueueSize
+	}
+	p.closeCh = make(chan net.Conn, p.closeQueueSize)
+	p.closerDone = make(chan struct{})
+	p.done = make(chan struct{})
+	p.goBackground(p.closer)
+	if p.onStall != nil && p.stallTimeout > 0 {
+		p.goBackground(p.watchdog)
+	}
+	if p.workload != nil {
+		p.goBackground(p.writeWorkload)
+	}
+	if p.warmIdle > 0 {
+		p.warmWake = make(chan struct{}, 1)
+		p.goBackground(p.warmKeeper)
+	}
+	p.standbyWake = make(chan struct{}, 1)
+	if p.standbyFactory != nil && p.standbySize > 0 {
+		p.goBackground(p.standbyKeeper)
+	}
+	p.reapWake = make(chan struct{}, 1)
+	if p.maxLifetime > 0 {
+		p.reaping = true
+		p.goBackground(p.reaper)
 	}
 
 	// 初始化链接
-	for i := 0; i < int(maxFree); i++ {
-		conn, err := factory()
+	for i := 0; i < int(p.initialConns); i++ {
+		conn, err := p.dial(context.Background())
+		if err != nil && p.bestEffortFill {
+			p.fillProgress(0, int(p.initialConns), err)
+			p.goBackground(func() { p.refill(int(p.initialConns)) })
+			break
+		}
 		if err != nil {
 			_ = p.Close()
 			return nil, fmt.Errorf("factory is not able to fill the pool: %s", err)
 		}
-		p.connCh <- conn
+		p.mu.Lock()
+		p.idle.Push(conn)
+		p.markIdle(conn)
+		p.mu.Unlock()
 	}
-	p.openNum = maxFree
 	return p, nil
 }
 
-func (p *channelPool) Get() (net.Conn, error) {
-	return p.GetWitchContext(context.Background())
+func (p *ChannelPool) Get() (net.Conn, error) {
+	ctx := p.withCaller(context.Background(), 2)
+	if p.getTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.getTimeout)
+		defer cancel()
+	}
+	return p.GetWitchContext(ctx)
 }
 
-func (p *channelPool) GetWitchContext(ctx context.Context) (net.Conn, error) {
-
-	p.mu.Lock()
-
-	if p.closed {
-		p.mu.Unlock()
-		return nil, ErrClosed
+func (p *ChannelPool) GetWitchContext(ctx context.Context) (net.Conn, error) {
+	start := time.Now()
+	conn, err := p.get(p.withCaller(ctx, 2))
+	p.countAcquire(err)
+	if err != nil || conn == nil {
+		p.recordGetFailure(start)
+	}
+	if errors.Is(err, ErrClosed) {
+		p.misuse(MisuseGetAfterClose, nil, "")
 	}
 
-	// 有空闲链接, 或者已达到最大链接数，都只能从connCh中获取
-	if len(p.connCh) > 0 || (p.maxConn > 0 && p.openNum >= p.maxConn) {
-		p.mu.Unlock()
-		select {
-		case <-ctx.Done():
-			return nil, ErrTimeOut
-		case conn := <-p.connCh:
-			if conn == nil {
-				return nil, ErrClosed
-			}
-			return conn, nil
-		}
+	// 保证 (conn == nil) == (err != nil), 拦截器可能破坏这一点
+	switch {
+	case err != nil && conn != nil:
+		p.discard(conn, CloseReasonBroken)
+		return nil, p.getErr(err)
+	case err != nil:
+		return nil, p.getErr(err)
+	case conn == nil:
+		return nil, p.getErr(ErrNilConn)
 	}
+	return conn, nil
+}
 
-	defer p.mu.Unlock()
-	// 未达到最大链接数，可以创建新链接
-	conn, err := p.factory()
+func (p *ChannelPool) getConn(ctx context.Context) (net.Conn, error) {
+	r, err := p.Reserve(ctx)
 	if err != nil {
 		return nil, err
 	}
-	p.openNum++
-	return conn, nil
+	return r.activate(ctx)
 }
 
-func (p *channelPool) Put(conn net.Conn) error {
+// Put 放回取出的conn, 可以与Close并发调用; conn为PoolConn时放回其底层conn;
+// conn为nil时返回ErrNilConn, pool已关闭时按WithClosedPut处理, conn已半关闭时关闭conn, 重复放回的conn被忽略;
+// 开启WithStrictAccounting时重复放回或放回未知conn返回AccountingError; 已失效的PoolConn(conn已被Transfer或再次取出)返回ErrConnReturned.
+// 有等待者时conn直接交给被唤醒的等待者, 不放入空闲列表, 空闲已满时也不关闭;
+// Put不等待: 需要关闭的conn交给后台关闭, 等待者由信号量直接唤醒, 只在很短的临界区内持有锁;
+// WithHealthCheckOnPut的检查在后台执行, 通过后conn才进入空闲列表;
+// WithOnFull的回调、WithClosedPut的处理和非unix平台上的WithUnreadCheck在调用方执行
+func (p *ChannelPool) Put(conn net.Conn) error {
 
 	if conn == nil {
-		return errors.New("connection is nil. rejecting")
+		return ErrNilConn
+	}
+	if bc, ok := conn.(*BufferedPoolConn); ok {
+		return bc.put()
+	}
+	pc, _ := conn.(*PoolConn)
+	conn = rawConn(conn)
+
+	// 已失效的PoolConn不检查, conn由当前持有方使用
+	if p.unreadCheck && !pc.stale() && !p.checkUnread(conn) {
+		return nil
 	}
+	if p.checkOnPut && !pc.stale() && p.validateOnPut(conn) {
+		return nil
+	}
+	return p.put(conn, pc, false)
+}
 
+// put Put的放回部分, validated为true时conn刚通过后台的放回检查
+func (p *ChannelPool) put(conn net.Conn, pc *PoolConn, validated bool) error {
 	p.mu.Lock()
-	defer p.mu.Unlock()
+
+	m, ok := p.conns[conn]
+	if !ok && validated {
+		// 检查期间被关闭
+		p.mu.Unlock()
+		return nil
+	}
+	if !ok {
+		// 不是pool创建的conn, 或已被pool关闭
+		anomaly := p.anomaly(AnomalyUnknownConn, 0)
+		p.mu.Unlock()
+		p.closeAsync(conn)
+		p.misuse(MisuseForeignPut, nil, "")
+		return anomaly
+	}
+	if validated {
+		m.validating = false
+	} else if m.idle || m.validating {
+		// 重复放回
+		anomaly := p.anomaly(AnomalyDoublePut, m.id)
+		id := m.id
+		p.mu.Unlock()
+		p.misuse(MisuseDoublePut, &connMeta{id: id}, "")
+		return anomaly
+	}
+	if pc.stale() {
+		// 已失效的PoolConn, conn已被Transfer或再次取出, 由当前持有方放回
+		p.mu.Unlock()
+		_ = pc.checkReturned()
+		return ErrConnReturned
+	}
+	p.recordCheckin(m)
+	p.poison(m)
 
 	// 已关闭
 	if p.closed {
-		err := conn.Close()
-		if err == nil {
-			p.openNum--
+		c := p.forget(conn, CloseReasonPoolClosed)
+		p.mu.Unlock()
+		p.release()
+		return p.putClosed(c)
+	}
+
+	// 被标记不可用、半关闭、已被Drain或MigrateTo淘汰、被CloseConn关闭、超过最大存活时间或对端证书即将过期的conn不能复用
+	now := time.Now()
+	if m.unusable || m.halfClosed || m.gen < p.gen || m.forced || m.migrated || p.lifetimeExceeded(m, now) || p.certExpiring(m, now) {
+		reason := CloseReasonCertExpiry
+		switch {
+		case m.unusable:
+			reason = CloseReasonBroken
+		case m.forced:
+			reason = CloseReasonForced
+		case m.halfClosed:
+			reason = CloseReasonHalfClosed
+		case m.gen < p.gen:
+			reason = CloseReasonDrained
+		case m.migrated:
+			reason = CloseReasonMigrated
+		case p.lifetimeExceeded(m, now):
+			reason = CloseReasonMaxLifetime
 		}
-		return err
+		c := p.forget(conn, reason)
+		p.mu.Unlock()
+		p.release()
+		p.closeAsync(c)
+		return nil
 	}
 
-	select {
-	case p.connCh <- conn:
+	if p.handOff(conn) || int64(p.idle.Len()) < p.maxIdle {
+		if !m.handoff {
+			p.idle.Push(conn)
+		}
+		p.markIdle(conn)
+		p.mu.Unlock()
+		p.release()
 		return nil
+	}
+
+	// 空闲已满, 交给后台关闭
+	c := p.forget(conn, CloseReasonOverflow)
+	queued := p.enqueueClose(c)
+	p.overflowNum++
+	overflows := p.overflowNum
+	p.mu.Unlock()
+	p.release()
+	if !queued {
+		p.goBackground(func() { _ = p.closeConn(c) })
+	}
+	if p.onFull != nil {
+		p.onFull(overflows)
+	}
+	return nil
+}
+
+// checkUnread 检查取出的conn上是否有未读数据, 有数据或conn已失效时丢弃conn并返回false
+func (p *ChannelPool) checkUnread(conn net.Conn) bool {
+	p.mu.RLock()
+	m, ok := p.conns[conn]
+	busy := ok && !m.idle
+	p.mu.RUnlock()
+	if !busy {
+		return true
+	}
+
+	switch err := p.livenessProbe()(conn); err {
+	case nil:
+		return true
+	case ErrUnexpectedRead:
+		p.discard(conn, CloseReasonUnreadData)
 	default:
-		err := conn.Close()
-		if err == nil {
-			p.openNum--
-		}
-		return err
+		p.discard(conn, CloseReasonBroken)
+	}
+	return false
+}
+
+// Discard 关闭取出的conn并释放其名额, 用于conn已不可用的情况
+func (p *ChannelPool) Discard(conn net.Conn) error {
+	if conn == nil {
+		return ErrNilConn
 	}
+	p.discard(conn, CloseReasonBroken)
+	return nil
 }
 
-func (p *channelPool) Close() error {
+func (p *ChannelPool) Close() error {
 
 	p.mu.Lock()
-	defer p.mu.Unlock()
 
 	if p.closed {
+		p.mu.Unlock()
 		return ErrClosed
 	}
 
 	p.closed = true
-	close(p.connCh)
-	for c := range p.connCh {
-		if err := c.Close(); err != nil {
-			return err
+	close(p.closeCh)
+	close(p.done)
+	conns := append(p.idle.Drain(), p.handoff.Drain()...)
+	for i, c := range conns {
+		conns[i] = p.forget(c, CloseReasonPoolClosed)
+	}
+	p.mu.Unlock()
+	if p.budget != nil {
+		p.budget.leave(p)
+	}
+
+	// 唤醒所有等待者
+	if p.sem != nil {
+		p.sem.Close()
+	}
+	if p.pinSem != nil {
+		p.pinSem.Close()
+	}
+
+	// 在锁外关闭, 不阻塞其他操作
+	var err error
+	for _, c := range conns {
+		if cerr := p.closeConn(c); cerr != nil && err == nil {
+			err = cerr
 		}
-		p.openNum--
 	}
-	return nil
+
+	// 等待后台队列中的conn关闭完成
+	<-p.closerDone
+	return err
+}
+
+func (p *ChannelPool) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.idle.Len()
+}
+
+func (p *ChannelPool) OpenNum() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return int(p.acct.Open)
+}
+
+// InUse 已被取出的conn数, 即已创建的conn数减去空闲conn数
+func (p *ChannelPool) InUse() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.inUse()
+}
+
+// inUse 需持有p.mu
+func (p *ChannelPool) inUse() int {
+	return p.acct.InUse(p.idle.Len())
 }
 
-func (p *channelPool) Len() int {
-	return len(p.connCh)
+// acquire 获得一个容量单位, 已满时等待
+func (p *ChannelPool) acquire(ctx context.Context) error {
+	_, err := p.acquireWait(ctx)
+	return err
 }
 
-func (p *channelPool) OpenNum() int {
-	return int(p.openNum)
+// acquireWait 同acquire, 同时返回等待的时长, 没有等待时为0
+func (p *ChannelPool) acquireWait(ctx context.Context) (time.Duration, error) {
+	if p.sem == nil || p.sem.TryAcquire(1) || p.spinAcquire() {
+		return 0, nil
+	}
+
+	proto := protocolOf(ctx)
+	p.mu.Lock()
+	p.waits++
+	if s := p.operationStats(ctx); s != nil {
+		s.Waits++
+	}
+	if p.waitProtos == nil {
+		p.waitProtos = make(map[string]int)
+	}
+	p.waitProtos[proto]++
+	p.mu.Unlock()
+
+	start := time.Now()
+	err := p.sem.Acquire(ctx, 1)
+	waited := time.Since(start)
+
+	p.mu.Lock()
+	if p.waitProtos[proto]--; p.waitProtos[proto] == 0 {
+		delete(p.waitProtos, proto)
+	}
+	p.waitDuration += waited
+	p.observeWait(waited)
+	if err != nil {
+		p.settleHandoff()
+	}
+	timeout := err != nil && err != ErrClosed
+	if timeout {
+		p.timeouts++
+	}
+	if s := p.operationStats(ctx); s != nil {
+		s.WaitDuration += waited
+		if timeout {
+			s.Timeouts++
+		}
+	}
+	p.mu.Unlock()
+
+	switch err {
+	case nil:
+		return waited, nil
+	case ErrClosed:
+		return waited, ErrClosed
+	default:
+		return waited, timeoutErr(ctx)
+	}
+}
+
+// tryAcquire 不等待地获得一个容量单位
+func (p *ChannelPool) tryAcquire() bool {
+	return p.sem == nil || p.sem.TryAcquire(1)
+}
+
+// release 归还一个容量单位
+func (p *ChannelPool) release() {
+	p.progress()
+	if p.sem != nil {
+		p.sem.Release(1)
+	}
+}
+
+// popIdle 取出最早放回的未过期的空闲conn, proto不为空时只取协商了该ALPN协议的conn,
+// 过期的conn交给后台关闭, 需持有p.mu
+func (p *ChannelPool) popIdle(proto string) (net.Conn, bool) {
+	return p.popFrom(&p.idle, proto)
+}
+
+// popFrom 同popIdle, 从list中取出
+func (p *ChannelPool) popFrom(list *poolcore.IdleList, proto string) (net.Conn, bool) {
+	var match func(net.Conn) bool
+	if proto != "" {
+		match = func(conn net.Conn) bool {
+			m, ok := p.conns[conn]
+			return !ok || m.speaks(proto)
+		}
+	}
+	now := time.Now()
+	for {
+		conn, ok := list.Pop(match)
+		if !ok {
+			return nil, false
+		}
+		if m, ok := p.conns[conn]; ok {
+			m.handoff = false
+			if reason, ok := p.expired(m, now); ok {
+				c := p.forget(conn, reason)
+				if !p.enqueueClose(c) {
+					p.goBackground(func() { _ = p.closeConn(c) })
+				}
+				continue
+			}
+		}
+		p.markBusy(conn)
+		p.wakeWarm()
+		return conn, true
+	}
+}
+
+// waiterCount 正在等待容量的调用数
+func (p *ChannelPool) waiterCount() int {
+	if p.sem == nil {
+		return 0
+	}
+	return p.sem.Waiters()
 }
@@ -1,10 +1,13 @@
 package pool
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/rand"
 	"net"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -149,9 +152,9 @@ func TestChannelPool_Close(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	if p.openNum != 0 {
+	if p.acct.Open != 0 {
 		t.Errorf("Close error. Expecting %d, got %d",
-			0, p.openNum)
+			0, p.acct.Open)
 	}
 
 }
@@ -187,9 +190,10 @@ func TestChannelPool_MaxConn(t *testing.T) {
 		t.Errorf("Get error: %s", err)
 	}
 
-	if conn != newConn {
+	// 每次取出返回新的PoolConn, 底层conn相同
+	if conn.(*PoolConn).RawConn() != newConn.(*PoolConn).RawConn() {
 		t.Errorf("Get error. Expecting %v, got %v",
-			conn, newConn)
+			conn.(*PoolConn).RawConn(), newConn.(*PoolConn).RawConn())
 	}
 
 }
@@ -241,3 +245,222 @@ func simpleTCPServer() {
 		}()
 	}
 }
+
+func TestChannelPool_InitialConns(t *testing.T) {
+	p, err := NewChannelPool(int64(maxFree), int64(maxConn), factory, WithInitialConns(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if p.Len() != 1 || p.OpenNum() != 1 {
+		t.Errorf("New error. Expecting len=1 open=1, got len=%d open=%d",
+			p.Len(), p.OpenNum())
+	}
+
+	if _, err := NewChannelPool(int64(maxFree), int64(maxConn), factory, WithInitialConns(maxFree+1)); err == nil {
+		t.Errorf("New error. Expecting invalid initial conns")
+	}
+}
+
+func TestChannelPool_Discard(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	conn, _ := p.Get()
+	if err := p.Discard(conn); err != nil {
+		t.Error(err)
+	}
+	if p.OpenNum() != maxFree-1 {
+		t.Errorf("Discard error. Expecting %d, got %d",
+			maxFree-1, p.OpenNum())
+	}
+	if p.Len() != maxFree-1 {
+		t.Errorf("Discard error. Expecting %d, got %d",
+			maxFree-1, p.Len())
+	}
+}
+
+func TestChannelPool_Concurrent(t *testing.T) {
+	p, _ := NewChannelPool(2, 4, factory)
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				conn, err := p.Get()
+				if err != nil {
+					t.Errorf("Get error: %s", err)
+					return
+				}
+				if open := p.OpenNum(); open > 4 {
+					t.Errorf("Get error. Expecting open <= %d, got %d", 4, open)
+				}
+				if err := p.Put(conn); err != nil {
+					t.Errorf("Put error: %s", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p.Len() > 2 || p.OpenNum() != p.Len() {
+		t.Errorf("Put error. Expecting all conns idle, got open=%d idle=%d",
+			p.OpenNum(), p.Len())
+	}
+}
+
+func TestChannelPool_CloseWakesWaiters(t *testing.T) {
+	p, _ := NewChannelPool(1, 1, factory)
+
+	if _, err := p.Get(); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := p.Get()
+		done <- err
+	}()
+	time.Sleep(time.Millisecond * 50)
+
+	_ = p.Close()
+	select {
+	case err := <-done:
+		if err != ErrClosed {
+			t.Errorf("Get error. Expecting %v, got %v", ErrClosed, err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Close error. Expecting waiter woken")
+	}
+}
+
+func TestChannelPool_UnlimitedConns(t *testing.T) {
+	if _, err := NewChannelPool(int64(maxFree), 0, factory); err == nil {
+		t.Errorf("New error. Expecting maxConn=0 rejected without WithUnlimitedConns")
+	}
+	if _, err := NewChannelPool(int64(maxFree), int64(maxConn), factory, WithUnlimitedConns()); err == nil {
+		t.Errorf("New error. Expecting maxConn>0 rejected with WithUnlimitedConns")
+	}
+
+	p, err := NewChannelPool(int64(maxFree), 0, factory, WithUnlimitedConns())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 超过maxFree后继续新建, 不会等待
+	conns := make([]net.Conn, 0, maxFree*3)
+	for i := 0; i < maxFree*3; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		conns = append(conns, conn)
+	}
+	if p.OpenNum() != maxFree*3 {
+		t.Errorf("Get error. Expecting %d, got %d", maxFree*3, p.OpenNum())
+	}
+
+	// 放回时仍只保留maxFree个空闲
+	for _, conn := range conns {
+		if err := p.Put(conn); err != nil {
+			t.Error(err)
+		}
+	}
+	s := p.Stats()
+	if s.Idle != maxFree || s.Open != maxFree || s.MaxConn != 0 || s.Waits != 0 {
+		t.Errorf("Stats error. Expecting idle=open=%d maxConn=0 waits=0, got %+v", maxFree, s)
+	}
+	if str := p.String(); !strings.Contains(str, "maxConn=unlimited") {
+		t.Errorf("String error. Expecting maxConn=unlimited, got %q", str)
+	}
+}
+
+func TestChannelPool_InUse(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	if p.InUse() != 0 {
+		t.Errorf("InUse error. Expecting %d, got %d", 0, p.InUse())
+	}
+
+	conns := make([]net.Conn, 0, maxConn)
+	for i := 0; i < maxConn; i++ {
+		conn, _ := p.Get()
+		conns = append(conns, conn)
+	}
+	if p.InUse() != maxConn {
+		t.Errorf("InUse error. Expecting %d, got %d", maxConn, p.InUse())
+	}
+
+	for _, conn := range conns {
+		_ = p.Put(conn)
+	}
+	if p.InUse() != 0 {
+		t.Errorf("InUse error. Expecting %d, got %d", 0, p.InUse())
+	}
+	if s := p.Stats(); s.InUse != 0 || s.Idle != maxFree {
+		t.Errorf("Stats error. Expecting inUse=0 idle=%d, got %+v", maxFree, s)
+	}
+}
+
+func TestChannelPool_DefaultGetTimeout(t *testing.T) {
+	p, _ := NewChannelPool(1, 1, factory, WithDefaultGetTimeout(time.Millisecond*50))
+	defer p.Close()
+
+	if _, err := p.Get(); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	start := time.Now()
+	_, err := p.Get()
+	if !errors.Is(err, ErrTimeOut) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*500 {
+		t.Errorf("Get error. Expecting return after default timeout, cost %s", cost)
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	f := func(ctx context.Context) (net.Conn, error) { return factory() }
+	p, err := New(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if s := p.Stats(); s.MaxFree != defaultMaxFree || s.MaxConn != defaultMaxConn || s.Idle != defaultMaxFree {
+		t.Errorf("New error. Expecting %d/%d with %d idle, got %d/%d with %d idle",
+			defaultMaxFree, defaultMaxConn, defaultMaxFree, s.MaxFree, s.MaxConn, s.Idle)
+	}
+
+	for _, c := range []struct {
+		opts             []Option
+		maxFree, maxConn int
+	}{
+		{[]Option{WithMaxFree(4), WithMaxConn(8)}, 4, 8},
+		{[]Option{WithMaxFree(32)}, 32, 32},
+		{[]Option{WithUnlimitedConns()}, defaultMaxFree, 0},
+	} {
+		p, err := New(f, append(c.opts, WithInitialConns(0))...)
+		if err != nil {
+			t.Fatalf("New error: %s", err)
+		}
+		if s := p.Stats(); s.MaxFree != c.maxFree || s.MaxConn != c.maxConn {
+			t.Errorf("New error. Expecting %d/%d, got %d/%d", c.maxFree, c.maxConn, s.MaxFree, s.MaxConn)
+		}
+		p.Close()
+	}
+
+	// 同样校验配置
+	if _, err := New(f, WithMaxFree(4), WithMaxConn(2)); err == nil {
+		t.Error("New error. Expecting invalid capacity rejected")
+	}
+	if _, err := New(nil); err == nil {
+		t.Error("New error. Expecting invalid factory rejected")
+	}
+}
@@ -0,0 +1,58 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrChurnLimit = errors.New("connection churn limit exceeded")
+)
+
+// ChurnEvent 新建conn的速率超过上限
+type ChurnEvent struct {
+	Time time.Time
+
+	Limit int // window内允许新建的conn数
+
+	Window time.Duration
+
+	Refused bool // 是否拒绝了本次新建
+}
+
+// OnChurn 新建速率超过上限时调用, 每个window至多调用一次;
+// 稳定运行时频繁新建通常说明健康检查误判或后端主动断开了conn
+type OnChurn func(ChurnEvent)
+
+// churnCheck 记录一次新建, window内新建数已达上限时返回事件, 拒绝新建时同时返回error, 需持有p.mu
+func (p *ChannelPool) churnCheck(now time.Time) (*ChurnEvent, error) {
+	if p.churnLimit <= 0 {
+		return nil, nil
+	}
+	cutoff := now.Add(-p.churnWindow)
+	i := 0
+	for i < len(p.churnDials) && !p.churnDials[i].After(cutoff) {
+		i++
+	}
+	p.churnDials = p.churnDials[i:]
+	if len(p.churnDials) < p.churnLimit {
+		p.churnDials = append(p.churnDials, now)
+		return nil, nil
+	}
+
+	var event *ChurnEvent
+	if now.Sub(p.churnAlerted) >= p.churnWindow {
+		p.churnAlerted = now
+		event = &ChurnEvent{Time: now, Limit: p.churnLimit, Window: p.churnWindow, Refused: p.churnRefuse}
+	}
+	if p.churnRefuse {
+		p.churnLimitedNum++
+		p.dialThrottledNum++
+		return event, &DialThrottledError{
+			Limiter: LimiterChurnGuard,
+			Err:     fmt.Errorf("%w: %d dials in %s", ErrChurnLimit, p.churnLimit, p.churnWindow),
+		}
+	}
+	return event, nil
+}
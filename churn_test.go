@@ -0,0 +1,89 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_ChurnGuardRefuse(t *testing.T) {
+	var events []ChurnEvent
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0),
+		WithChurnGuard(2, time.Millisecond*100, true, func(e ChurnEvent) {
+			events = append(events, e)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	var held []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		held = append(held, conn)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := p.Get(); !errors.Is(err, ErrChurnLimit) || !errors.Is(err, ErrDialBudgetExceeded) {
+			t.Errorf("Get error. Expecting %v, got %v", ErrChurnLimit, err)
+		}
+	}
+	if len(events) != 1 || !events[0].Refused || events[0].Limit != 2 {
+		t.Errorf("OnChurn error. Expecting 1 refused event, got %+v", events)
+	}
+	if s := p.Stats(); s.ChurnLimited != 2 || s.DialThrottled != 2 || s.InUse != 2 {
+		t.Errorf("Stats error. Expecting %d churn limited %d inUse, got %d %d", 2, 2, s.ChurnLimited, s.InUse)
+	}
+
+	// 复用空闲conn不受限制
+	p.Put(held[0])
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	held[0] = conn
+
+	// 窗口过去后恢复新建
+	time.Sleep(time.Millisecond * 120)
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	held = append(held, conn)
+	for _, conn := range held {
+		p.Put(conn)
+	}
+}
+
+func TestChannelPool_ChurnGuardAlert(t *testing.T) {
+	var events []ChurnEvent
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0),
+		WithChurnGuard(1, time.Minute, false, func(e ChurnEvent) {
+			events = append(events, e)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for i := 0; i < 3; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		defer p.Put(conn)
+	}
+	if len(events) != 1 || events[0].Refused {
+		t.Errorf("OnChurn error. Expecting 1 event, got %+v", events)
+	}
+	if s := p.Stats(); s.ChurnLimited != 0 || s.Created != 3 {
+		t.Errorf("Stats error. Expecting %d churn limited %d created, got %d %d", 0, 3, s.ChurnLimited, s.Created)
+	}
+
+	if _, err := NewChannelPool(3, 5, factory, WithChurnGuard(1, 0, false, nil)); err == nil {
+		t.Errorf("NewChannelPool error. Expecting invalid churn guard")
+	}
+}
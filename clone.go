@@ -0,0 +1,15 @@
+package pool
+
+// CloneWith 用p的factory和创建时的配置创建一个新pool, opts在原配置之后应用, 可覆盖maxFree(WithMaxFree)、
+// maxConn(WithMaxConn)等限制, 用于临时建立高优先级的pool等. 新pool需单独Close.
+//
+// 新pool与p共用外部传入的对象: factory、WithWeightedSemaphore的容量、WithBudget的名额(以相同dest加入)以及各种回调,
+// 回调可能被两个pool并发调用. conn和pool自身的状态不共用, 按配置重新创建: 空闲conn、WithStandby的备用conn、
+// WithHoldProfile的采样、WithQuarantine的隔离记录、WithConnTrace的事件等.
+// WithWorkloadRecorder不被继承, 两个pool写入同一个io.Writer会产生竞争, 需要时在opts中传入另一个io.Writer
+func (p *ChannelPool) CloneWith(opts ...Option) (*ChannelPool, error) {
+	p.mu.RLock()
+	factory := p.factory
+	p.mu.RUnlock()
+	return newChannelPool(p.maxFree, p.maxConn, factory, p.opts, opts)
+}
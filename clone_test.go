@@ -0,0 +1,122 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ConnPool/poolcore"
+)
+
+func TestChannelPool_CloneWith(t *testing.T) {
+	shared := poolcore.NewSemaphore(10)
+	p, err := NewChannelPool(2, 3, factory, WithInitialConns(1), WithWeightedSemaphore(shared, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c, err := p.CloneWith(WithMaxFree(4), WithMaxConn(8))
+	if err != nil {
+		t.Fatalf("CloneWith error: %s", err)
+	}
+	defer c.Close()
+
+	s := c.Stats()
+	if s.MaxFree != 4 || s.MaxConn != 8 {
+		t.Errorf("CloneWith error. Expecting 4/8, got %d/%d", s.MaxFree, s.MaxConn)
+	}
+	// 保留原配置
+	if c.Len() != 1 || c.Snapshot().InitialConns != 1 {
+		t.Errorf("CloneWith error. Expecting %d initial conn, got %d", 1, c.Len())
+	}
+	// 共用外部容量
+	conn, err := c.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if shared.Held() != 1 {
+		t.Errorf("CloneWith error. Expecting shared budget %d, got %d", 1, shared.Held())
+	}
+	c.Put(conn)
+	if p.Stats().MaxFree != 2 {
+		t.Errorf("CloneWith error. Expecting original unchanged, got %d", p.Stats().MaxFree)
+	}
+
+	// 未设置WithInitialConns时按新的maxFree建立
+	p2, err := NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+	c2, err := p2.CloneWith(WithMaxFree(2))
+	if err != nil {
+		t.Fatalf("CloneWith error: %s", err)
+	}
+	defer c2.Close()
+	if c2.Len() != 2 {
+		t.Errorf("CloneWith error. Expecting %d initial conns, got %d", 2, c2.Len())
+	}
+
+	if _, err := p2.CloneWith(WithMaxFree(3)); err == nil {
+		t.Errorf("CloneWith error. Expecting invalid capacity settings")
+	}
+}
+
+func TestChannelPool_CloneWithWorkloadRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := NewChannelPool(1, 2, factory, WithWorkloadRecorder(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.CloneWith()
+	if err != nil {
+		t.Fatalf("CloneWith error: %s", err)
+	}
+	// 不继承recorder, 两个pool不会同时写入buf
+	if c.workload != nil {
+		t.Error("CloneWith error. Expecting workload recorder not inherited")
+	}
+	var other bytes.Buffer
+	c2, err := c.CloneWith(WithWorkloadRecorder(&other))
+	if err != nil {
+		t.Fatalf("CloneWith error: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, pool := range []*ChannelPool{p, c, c2} {
+		wg.Add(1)
+		go func(pool *ChannelPool) {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				conn, err := pool.Get()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				_ = pool.Put(conn)
+			}
+		}(pool)
+	}
+	wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, pool := range []*ChannelPool{p, c, c2} {
+		_ = pool.Close()
+		if err := pool.WaitStopped(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, b := range []*bytes.Buffer{&buf, &other} {
+		recs, err := ReadWorkload(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(recs) != 20 {
+			t.Errorf("ReadWorkload error. Expecting %d records, got %d", 20, len(recs))
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package pool
+
+import (
+	"net"
+	"time"
+)
+
+// closeConn 关闭底层conn, 设置了closeTimeout时超时后不再等待, Close在后台继续执行
+func (p *ChannelPool) closeConn(conn net.Conn) error {
+	if p.closeTimeout <= 0 {
+		return conn.Close()
+	}
+
+	done := make(chan error, 1)
+	p.goBackground(func() {
+		done <- conn.Close()
+	})
+
+	timer := time.NewTimer(p.closeTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return nil
+	}
+}
+
+// 后台关闭队列默认长度
+const defaultCloseQueueSize = 64
+
+// closer 后台关闭被丢弃的conn, 避免慢Close阻塞持有锁的操作
+func (p *ChannelPool) closer() {
+	defer close(p.closerDone)
+	for conn := range p.closeCh {
+		_ = p.closeConn(conn)
+	}
+}
+
+// enqueueClose 把conn放入后台关闭队列, 需持有p.mu, 队列已满或pool已关闭时返回false
+func (p *ChannelPool) enqueueClose(conn net.Conn) bool {
+	if p.closed {
+		return false
+	}
+	select {
+	case p.closeCh <- conn:
+		return true
+	default:
+		return false
+	}
+}
+
+// closeAsync 在后台关闭conn, 无法入队时在新的goroutine中关闭, 不阻塞调用方
+func (p *ChannelPool) closeAsync(conn net.Conn) {
+	p.mu.RLock()
+	queued := p.enqueueClose(conn)
+	p.mu.RUnlock()
+	if !queued {
+		p.goBackground(func() { _ = p.closeConn(conn) })
+	}
+}
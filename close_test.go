@@ -0,0 +1,128 @@
+package pool
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// slowCloseConn Close会阻塞一段时间的conn
+type slowCloseConn struct {
+	net.Conn
+	delay time.Duration
+}
+
+func (c *slowCloseConn) Close() error {
+	time.Sleep(c.delay)
+	return c.Conn.Close()
+}
+
+func TestChannelPool_CloseTimeout(t *testing.T) {
+	slowFactory := func() (net.Conn, error) {
+		conn, err := factory()
+		if err != nil {
+			return nil, err
+		}
+		return &slowCloseConn{Conn: conn, delay: time.Second}, nil
+	}
+
+	p, err := NewChannelPool(1, 2, slowFactory, WithCloseTimeout(time.Millisecond*50))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c1, _ := p.Get()
+	c2, _ := p.Get()
+	if err := p.Put(c1); err != nil {
+		t.Error(err)
+	}
+
+	// 空闲已满, 关闭c2不应阻塞Put
+	start := time.Now()
+	if err := p.Put(c2); err != nil {
+		t.Error(err)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*500 {
+		t.Errorf("Put error. Expecting return after close timeout, cost %s", cost)
+	}
+	if p.OpenNum() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d",
+			1, p.OpenNum())
+	}
+
+	start = time.Now()
+	if err := p.Close(); err != nil {
+		t.Error(err)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*500 {
+		t.Errorf("Close error. Expecting return after close timeout, cost %s", cost)
+	}
+}
+
+// closeCountConn 记录是否已被关闭
+type closeCountConn struct {
+	net.Conn
+	delay  time.Duration
+	closed chan struct{}
+}
+
+func (c *closeCountConn) Close() error {
+	time.Sleep(c.delay)
+	close(c.closed)
+	return c.Conn.Close()
+}
+
+func TestChannelPool_AsyncClose(t *testing.T) {
+	var slow *closeCountConn
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		conn, err := factory()
+		if err != nil {
+			return nil, err
+		}
+		slow = &closeCountConn{Conn: conn, delay: time.Millisecond * 300, closed: make(chan struct{})}
+		return slow, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c1, _ := p.Get()
+	c2, _ := p.Get()
+	if err := p.Put(c1); err != nil {
+		t.Error(err)
+	}
+
+	// 空闲已满, c2在后台关闭, Put不等待
+	start := time.Now()
+	if err := p.Put(c2); err != nil {
+		t.Error(err)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*100 {
+		t.Errorf("Put error. Expecting async close, cost %s", cost)
+	}
+
+	// 后台关闭期间其他操作不受影响
+	conn, err := p.Get()
+	if err != nil {
+		t.Errorf("Get error: %s", err)
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*200 {
+		t.Errorf("Get error. Expecting not blocked by close, cost %s", cost)
+	}
+
+	// Close 等待后台关闭完成
+	if c2.(*PoolConn).RawConn() != slow {
+		t.Fatalf("Get error. Expecting the second conn to be the last created")
+	}
+	if err := p.Close(); err != nil {
+		t.Error(err)
+	}
+	select {
+	case <-slow.closed:
+	default:
+		t.Errorf("Close error. Expecting queued conn closed")
+	}
+}
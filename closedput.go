@@ -0,0 +1,28 @@
+package pool
+
+import "net"
+
+// ClosedPutPolicy pool关闭后Put的处理方式; 任何方式下conn都不再计入pool, 记为CloseReasonPoolClosed并释放其容量单位
+type ClosedPutPolicy int
+
+const (
+	ClosedPutClose    ClosedPutPolicy = iota // 关闭conn, 返回关闭conn的错误, 默认
+	ClosedPutError                           // 关闭conn, 返回ErrClosed
+	ClosedPutCallback                        // 不关闭conn, 交给OnClosedPut处理并返回其错误
+)
+
+// OnClosedPut pool关闭后放回conn时调用, conn为取出时返回的conn(经过WrapConn包装), 由回调负责关闭
+type OnClosedPut func(conn net.Conn) error
+
+// putClosed 按WithClosedPut的方式处理pool关闭后放回的conn, conn已从pool移除
+func (p *ChannelPool) putClosed(conn net.Conn) error {
+	switch p.closedPutPolicy {
+	case ClosedPutError:
+		_ = p.closeConn(conn)
+		return ErrClosed
+	case ClosedPutCallback:
+		return p.onClosedPut(conn)
+	default:
+		return p.closeConn(conn)
+	}
+}
@@ -0,0 +1,66 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestChannelPool_ClosedPut(t *testing.T) {
+	var handed net.Conn
+	errHanded := errors.New("handed over")
+	onClosedPut := func(conn net.Conn) error {
+		handed = conn
+		return errHanded
+	}
+	tests := []struct {
+		name    string
+		opt     Option
+		wantErr error
+	}{
+		{"close", WithClosedPut(ClosedPutClose, nil), nil},
+		{"error", WithClosedPut(ClosedPutError, nil), ErrClosed},
+		{"callback", WithClosedPut(ClosedPutCallback, onClosedPut), errHanded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewChannelPool(3, 5, factory, WithInitialConns(0), tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := p.Get()
+			if err != nil {
+				t.Fatalf("Get error: %s", err)
+			}
+			raw := conn.(*PoolConn).RawConn()
+			p.Close()
+
+			if err := p.Put(conn); err != tt.wantErr {
+				t.Errorf("Put error. Expecting %v, got %v", tt.wantErr, err)
+			}
+			if p.OpenNum() != 0 || p.InUse() != 0 {
+				t.Errorf("Put error. Expecting 0 open 0 in use, got %d %d", p.OpenNum(), p.InUse())
+			}
+			if h := p.ConnAgeStats()[CloseReasonPoolClosed]; h.Count != 1 {
+				t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+			}
+
+			if _, err := conn.Write([]byte("x")); err != ErrConnReturned {
+				t.Errorf("Write error. Expecting %v, got %v", ErrConnReturned, err)
+			}
+			_, werr := raw.Write([]byte("x"))
+			if tt.name == "callback" {
+				if werr != nil || handed == nil {
+					t.Errorf("Put error. Expecting conn handed to callback open, got %v", werr)
+				}
+				handed.Close()
+			} else if werr == nil {
+				t.Errorf("Put error. Expecting conn closed")
+			}
+		})
+	}
+
+	if _, err := NewChannelPool(3, 5, factory, WithClosedPut(ClosedPutCallback, nil)); err == nil {
+		t.Errorf("NewChannelPool error. Expecting nil callback rejected")
+	}
+}
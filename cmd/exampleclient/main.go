@@ -0,0 +1,111 @@
+// exampleclient 演示pool的推荐用法: Do 执行请求, 健康检查过滤失效连接, 收到信号后优雅退出
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	pool "ConnPool"
+)
+
+var (
+	address     = flag.String("addr", "127.0.0.1:7777", "exampleserver 地址")
+	concurrency = flag.Int("c", 4, "并发数")
+	requests    = flag.Int("n", 100, "每个并发发送的请求数")
+)
+
+func main() {
+	flag.Parse()
+
+	p, err := pool.NewChannelPoolContext(2, int64(*concurrency),
+		pool.TCPFactoryContext("tcp", *address, pool.WithDialTimeout(time.Second*3)),
+		// 取出空闲conn时先 PING 一次, 失效的conn会被关闭并重新创建
+		pool.WithHealthCheck(ping),
+		pool.WithHealthCheckTimeout(time.Millisecond*200),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// 退出时关闭pool管理的所有conn
+	defer p.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for n := 0; n < *requests && ctx.Err() == nil; n++ {
+				reply, err := call(ctx, p, fmt.Sprintf("hello %d-%d", worker, n))
+				if err != nil {
+					log.Printf("worker %d: %s", worker, err)
+					continue
+				}
+				if n == 0 {
+					log.Printf("worker %d: %s", worker, reply)
+				}
+			}
+		}(i)
+	}
+	// 收到信号后不再发起新请求, 等待进行中的请求结束
+	wg.Wait()
+
+	log.Print(p)
+}
+
+// call 通过pool发送一行请求并读取一行响应, 出错时Do会关闭该conn
+func call(ctx context.Context, p *pool.ChannelPool, msg string) (string, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	var reply string
+	err := p.Do(ctx, func(conn net.Conn) error {
+		deadline, _ := ctx.Deadline()
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+
+		if _, err := conn.Write([]byte(msg + "\n")); err != nil {
+			return err
+		}
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return err
+		}
+		reply = line[:len(line)-1]
+		return nil
+	})
+	return reply, err
+}
+
+// ping 健康检查, 在ctx的期限内完成一次 PING/PONG
+func ping(ctx context.Context, conn net.Conn) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	if _, err := conn.Write([]byte("PING\n")); err != nil {
+		return err
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if line != "PONG\n" {
+		return errors.New("unexpected ping reply: " + line)
+	}
+	return nil
+}
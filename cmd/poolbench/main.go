@@ -0,0 +1,216 @@
+// poolbench 通过pool持续压测目标地址, 定时输出pool统计信息, 用于上线前验证参数配置
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pool "ConnPool"
+)
+
+var (
+	network     = flag.String("network", "tcp", "目标网络类型")
+	address     = flag.String("addr", "127.0.0.1:7777", "目标地址")
+	serve       = flag.Bool("serve", false, "在目标地址启动内置的echo server")
+	concurrency = flag.Int("c", 32, "并发数")
+	duration    = flag.Duration("d", time.Minute, "压测时长")
+	hold        = flag.Duration("hold", time.Millisecond*5, "每次持有conn的时间")
+	holdJitter  = flag.Duration("hold-jitter", 0, "持有时间的随机抖动")
+	payload     = flag.Int("payload", 64, "每次写入的字节数, 0 不读写")
+	maxFree     = flag.Int64("maxfree", 8, "最大空闲conn数")
+	maxConn     = flag.Int64("maxconn", 16, "最大conn数")
+	getTimeout  = flag.Duration("get-timeout", time.Second, "获取conn的超时时间")
+	health      = flag.Bool("health", false, "取出空闲conn时做健康检查")
+	interval    = flag.Duration("interval", time.Second*5, "输出统计信息的间隔")
+
+	chaosBreak = flag.Float64("chaos-break", 0, "用完后关闭底层conn再放回的概率, 模拟对端断开")
+	chaosSlow  = flag.Float64("chaos-slow", 0, "持有时间放大10倍的概率, 模拟慢调用")
+)
+
+// counters 压测过程中的计数
+type counters struct {
+	ops       int64
+	getErrors int64
+	ioErrors  int64
+	broken    int64
+}
+
+func main() {
+	flag.Parse()
+
+	if *serve {
+		l, err := net.Listen(*network, *address)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer l.Close()
+		go echoServer(l)
+	}
+
+	var opts []pool.Option
+	if *health {
+		opts = append(opts,
+			pool.WithHealthCheck(readCheck),
+			pool.WithHealthCheckTimeout(time.Millisecond*100))
+	}
+
+	dialer := &net.Dialer{Timeout: time.Second * 3}
+	p, err := pool.NewChannelPool(*maxFree, *maxConn, func() (net.Conn, error) {
+		return dialer.Dial(*network, *address)
+	}, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	var (
+		c  counters
+		wg sync.WaitGroup
+	)
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			worker(ctx, p, rand.New(rand.NewSource(seed)), &c)
+		}(time.Now().UnixNano() + int64(i))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	start := time.Now()
+	first := p.Stats()
+	last := first
+	lastOps := int64(0)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s := p.Stats()
+			ops := atomic.LoadInt64(&c.ops)
+			report(time.Since(start), s, pool.DiffStats(last, s), ops-lastOps, &c)
+			last, lastOps = s, ops
+		case <-done:
+			// 整个压测期间的汇总
+			s := p.Stats()
+			fmt.Print("total: ")
+			report(time.Since(start), s, pool.DiffStats(first, s), atomic.LoadInt64(&c.ops), &c)
+			fmt.Print(p.DebugString())
+			if err := p.Close(); err != nil {
+				log.Println("close pool:", err)
+			}
+			return
+		}
+	}
+}
+
+func worker(ctx context.Context, p *pool.ChannelPool, r *rand.Rand, c *counters) {
+
+	buf := make([]byte, *payload)
+	for ctx.Err() == nil {
+		getCtx, cancel := context.WithTimeout(ctx, *getTimeout)
+		conn, err := p.GetWitchContext(getCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() == nil {
+				atomic.AddInt64(&c.getErrors, 1)
+			}
+			continue
+		}
+
+		if *payload > 0 {
+			if err := roundTrip(conn, buf); err != nil {
+				atomic.AddInt64(&c.ioErrors, 1)
+			}
+		}
+
+		d := *hold
+		if *holdJitter > 0 {
+			d += time.Duration(r.Int63n(int64(*holdJitter)))
+		}
+		if r.Float64() < *chaosSlow {
+			d *= 10
+		}
+		time.Sleep(d)
+
+		if r.Float64() < *chaosBreak {
+			_ = conn.(*pool.PoolConn).RawConn().Close()
+			atomic.AddInt64(&c.broken, 1)
+		}
+		_ = p.Put(conn)
+		atomic.AddInt64(&c.ops, 1)
+	}
+}
+
+// roundTrip 写入buf并读回同样长度的数据
+func roundTrip(conn net.Conn, buf []byte) error {
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+	defer conn.SetDeadline(time.Time{})
+
+	if _, err := conn.Write(buf); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(conn, buf)
+	return err
+}
+
+// readCheck 以极短的读超时探测conn, 超时说明连接正常且没有未读数据
+func readCheck(ctx context.Context, conn net.Conn) error {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+	defer conn.SetReadDeadline(time.Time{})
+
+	var b [1]byte
+	_, err := conn.Read(b[:])
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return nil
+	}
+	if err == nil {
+		return errors.New("unexpected data on idle conn")
+	}
+	return err
+}
+
+func report(elapsed time.Duration, s pool.Stats, d pool.StatsDelta, ops int64, c *counters) {
+	fmt.Printf("t=%-6s open=%d idle=%d waiters=%d ops/s=%.0f dials/s=%.1f churn/s=%.1f reuse=%.1f%% wait=%.1f%% avgwait=%s timeouts=%d overflows=%d portExhausted=%d getErr=%d ioErr=%d broken=%d\n",
+		elapsed.Round(time.Second), s.Open, s.Idle, s.Waiters,
+		float64(ops)/d.Interval.Seconds(), d.DialRate(), d.ChurnRate(),
+		d.ReuseRate()*100, d.WaitRate()*100, d.AvgWait().Round(time.Microsecond), d.Timeouts, d.Overflows, d.PortExhausted,
+		atomic.LoadInt64(&c.getErrors), atomic.LoadInt64(&c.ioErrors), atomic.LoadInt64(&c.broken))
+}
+
+func echoServer(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			_, _ = io.Copy(conn, conn)
+		}()
+	}
+}
@@ -0,0 +1,96 @@
+// poolreplay 在新参数的pool上回放WithWorkloadRecorder记录的负载, 输出回放期间的SLO指标, 用于离线评估pool参数的调整
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	pool "ConnPool"
+)
+
+var (
+	trace      = flag.String("trace", "", "WithWorkloadRecorder写入的记录文件")
+	network    = flag.String("network", "tcp", "目标网络类型")
+	address    = flag.String("addr", "127.0.0.1:7777", "目标地址")
+	serve      = flag.Bool("serve", false, "在目标地址启动内置的server, 只接收连接不处理数据")
+	speed      = flag.Float64("speed", 1, "回放倍速")
+	maxFree    = flag.Int64("maxfree", 8, "最大空闲conn数")
+	maxConn    = flag.Int64("maxconn", 16, "最大conn数")
+	maxIdle    = flag.Int("maxidle", 0, "Put时保留的空闲conn上限, 0 使用maxfree")
+	warmIdle   = flag.Int("warmidle", 0, "保持的空闲conn数, 0 不保持")
+	getTimeout = flag.Duration("get-timeout", time.Second, "获取conn的超时时间")
+	objective  = flag.Float64("objective", 0.999, "获取conn成功率目标, 用于计算错误预算消耗速度")
+)
+
+func main() {
+	flag.Parse()
+	if *trace == "" {
+		log.Fatal("-trace is required")
+	}
+
+	f, err := os.Open(*trace)
+	if err != nil {
+		log.Fatal(err)
+	}
+	recs, err := pool.ReadWorkload(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *serve {
+		l, err := net.Listen(*network, *address)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer l.Close()
+		go sinkServer(l)
+	}
+
+	dialer := &net.Dialer{Timeout: time.Second * 3}
+	p, err := pool.NewChannelPool(*maxFree, *maxConn, func() (net.Conn, error) {
+		return dialer.Dial(*network, *address)
+	}, pool.WithMaxIdle(*maxIdle), pool.WithWarmIdle(*warmIdle), pool.WithDefaultGetTimeout(*getTimeout))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	res := pool.ReplayWorkload(ctx, p, recs, *speed)
+	s := res.SLO
+	fmt.Printf("records=%d/%d recordedErr=%d replayErr=%d interval=%s\n",
+		res.Records, len(recs), res.RecordedErrors, res.Errors, s.Interval.Round(time.Millisecond))
+	fmt.Printf("success=%.4f%% burn(%.4g)=%.2f wait=%.1f%% p99wait<=%s churn/s=%.1f churnRatio=%.2f\n",
+		s.AcquireSuccessRate*100, *objective, s.BurnRate(*objective), s.WaitRate*100, s.P99Wait, s.ChurnRate, s.ChurnRatio)
+	fmt.Print(p.DebugString())
+}
+
+// sinkServer 接收连接并丢弃读到的数据
+func sinkServer(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			_, _ = io.Copy(io.Discard, conn)
+		}()
+	}
+}
@@ -0,0 +1,214 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrHalfCloseUnsupported = errors.New("half close not supported")
+	ErrConnReturned         = errors.New("connection already returned to pool")
+)
+
+// PoolConn Get返回的conn, 包装factory创建的底层conn, Close时放回pool;
+// 放回pool时应Put或Close PoolConn本身, 而不是RawConn或WrapConn包装后的conn.
+// 每次取出返回新的PoolConn, 放回后原持有方再读写返回ErrConnReturned, 不会写入已被其他调用方取出的conn;
+// 交给其他goroutine持有时使用Transfer
+type PoolConn struct {
+	net.Conn // 当前使用的conn, 调用WrapConn后为包装后的conn
+
+	p *ChannelPool
+
+	raw net.Conn // factory创建的底层conn
+
+	dialDuration time.Duration // factory耗时
+
+	handshakeDuration time.Duration // OnCreate耗时
+
+	id uint64 // conn的创建序号
+
+	holder string // 取出conn的调用位置
+
+	returned int32 // 本次取出已结束, 原子访问
+
+	returnedAt string // 放回的调用位置, 仅开发模式记录, 在returned置位前写入
+}
+
+// stale 本次取出是否已结束(放回或Transfer), c为nil时返回false
+func (c *PoolConn) stale() bool {
+	return c != nil && atomic.LoadInt32(&c.returned) != 0
+}
+
+// checkReturned 本次取出已结束时返回ErrConnReturned, 开发模式下panic
+func (c *PoolConn) checkReturned() error {
+	if atomic.LoadInt32(&c.returned) == 0 {
+		return nil
+	}
+	c.p.misuse(MisuseUseAfterPut, &connMeta{id: c.id, holder: c.holder}, c.returnedAt)
+	return ErrConnReturned
+}
+
+func (c *PoolConn) Read(b []byte) (int, error) {
+	if err := c.checkReturned(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *PoolConn) Write(b []byte) (int, error) {
+	if err := c.checkReturned(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+func (c *PoolConn) SetDeadline(t time.Time) error {
+	if err := c.checkReturned(); err != nil {
+		return err
+	}
+	return c.Conn.SetDeadline(t)
+}
+
+func (c *PoolConn) SetReadDeadline(t time.Time) error {
+	if err := c.checkReturned(); err != nil {
+		return err
+	}
+	return c.Conn.SetReadDeadline(t)
+}
+
+func (c *PoolConn) SetWriteDeadline(t time.Time) error {
+	if err := c.checkReturned(); err != nil {
+		return err
+	}
+	return c.Conn.SetWriteDeadline(t)
+}
+
+// RawConn 返回factory创建的底层conn, 不经过WrapConn包装
+func (c *PoolConn) RawConn() net.Conn {
+	return c.raw
+}
+
+// WrapConn 用wrap包装当前使用的conn, 如TLS或RPC编解码;
+// 包装结果随连接保留在pool中, 之后取出时仍使用包装后的conn, 关闭时关闭包装后的conn;
+// 只能由持有conn的一方调用, 不能与Read/Write并发
+func (c *PoolConn) WrapConn(wrap func(net.Conn) net.Conn) {
+	if c.checkReturned() != nil {
+		return
+	}
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+
+	c.Conn = wrap(c.Conn)
+	if m, ok := c.p.conns[c.raw]; ok {
+		m.conn = c.Conn
+	}
+}
+
+type closeWriter interface {
+	CloseWrite() error
+}
+
+type closeReader interface {
+	CloseRead() error
+}
+
+// CloseWrite 关闭conn的写端, conn不支持时返回ErrHalfCloseUnsupported;
+// 调用后conn不再复用, Put时直接关闭
+func (c *PoolConn) CloseWrite() error {
+	if err := c.checkReturned(); err != nil {
+		return err
+	}
+	cw, ok := c.Conn.(closeWriter)
+	if !ok {
+		return ErrHalfCloseUnsupported
+	}
+	c.p.markHalfClosed(c.raw)
+	return cw.CloseWrite()
+}
+
+// CloseRead 关闭conn的读端, conn不支持时返回ErrHalfCloseUnsupported;
+// 调用后conn不再复用, Put时直接关闭
+func (c *PoolConn) CloseRead() error {
+	if err := c.checkReturned(); err != nil {
+		return err
+	}
+	cr, ok := c.Conn.(closeReader)
+	if !ok {
+		return ErrHalfCloseUnsupported
+	}
+	c.p.markHalfClosed(c.raw)
+	return cr.CloseRead()
+}
+
+// Close 把conn放回pool, 同Put; 被MarkUnusable标记时关闭conn并释放其在pool中的名额, 已放回时返回ErrConnReturned
+func (c *PoolConn) Close() error {
+	if atomic.LoadInt32(&c.returned) != 0 && !c.p.devMode {
+		return ErrConnReturned
+	}
+	return c.p.Put(c)
+}
+
+// MarkUnusable 标记conn不可用, 之后的Close或Put关闭conn而不是放回pool, 用于已知conn损坏的情况;
+// 本次取出已结束时不标记, 避免影响conn之后的取出方
+func (c *PoolConn) MarkUnusable() {
+	if c.checkReturned() != nil {
+		return
+	}
+	c.p.markUnusable(c)
+}
+
+// handle 返回conn本次取出的PoolConn, 需持有p.mu
+func (p *ChannelPool) handle(conn net.Conn) *PoolConn {
+	m, ok := p.conns[conn]
+	if !ok {
+		return &PoolConn{Conn: conn, p: p, raw: conn}
+	}
+	// 每次取出使用新的PoolConn, 放回后旧的PoolConn不能再使用
+	m.handle = &PoolConn{
+		Conn:              m.conn,
+		p:                 p,
+		raw:               conn,
+		dialDuration:      m.dialDuration,
+		handshakeDuration: m.handshakeDuration,
+		id:                m.id,
+		holder:            m.holder,
+	}
+	return m.handle
+}
+
+// poison 本次取出结束, 之后通过取出时的PoolConn读写返回ErrConnReturned, 需持有p.mu
+func (p *ChannelPool) poison(m *connMeta) {
+	c := m.handle
+	if c == nil {
+		return
+	}
+	if p.devMode {
+		c.returnedAt = misuseCaller()
+	}
+	atomic.StoreInt32(&c.returned, 1)
+	m.handle = nil
+}
+
+// userConn 返回底层conn当前使用的conn, 即WrapConn包装后的conn
+func (p *ChannelPool) userConn(conn net.Conn) net.Conn {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if m, ok := p.conns[conn]; ok {
+		return m.conn
+	}
+	return conn
+}
+
+// rawConn 取出PoolConn包装的底层conn, 其他conn原样返回
+func rawConn(conn net.Conn) net.Conn {
+	switch c := conn.(type) {
+	case *PoolConn:
+		return c.raw
+	case *BufferedPoolConn:
+		return c.raw
+	}
+	return conn
+}
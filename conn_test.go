@@ -0,0 +1,330 @@
+package pool
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// codecConn 模拟协议库对conn的包装
+type codecConn struct {
+	net.Conn
+}
+
+func TestPoolConn_RawConn(t *testing.T) {
+	var created []net.Conn
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		conn, err := factory()
+		if err == nil {
+			created = append(created, conn)
+		}
+		return conn, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc, ok := conn.(*PoolConn)
+	if !ok {
+		t.Fatalf("Get error. Expecting *PoolConn, got %T", conn)
+	}
+	if pc.RawConn() != created[0] {
+		t.Errorf("RawConn error. Expecting the conn created by factory")
+	}
+	if id, ok := p.ConnID(pc.RawConn()); !ok || id != 1 {
+		t.Errorf("ConnID error. Expecting %d, got %d", 1, id)
+	}
+
+	// 放回底层conn与放回PoolConn等价
+	if err := p.Put(pc.RawConn()); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d", 1, p.Len())
+	}
+}
+
+func TestPoolConn_WrapConn(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc := conn.(*PoolConn)
+	raw := pc.RawConn()
+	pc.WrapConn(func(c net.Conn) net.Conn {
+		return &codecConn{Conn: c}
+	})
+	if _, ok := pc.Conn.(*codecConn); !ok {
+		t.Fatalf("WrapConn error. Expecting *codecConn, got %T", pc.Conn)
+	}
+	if pc.RawConn() != raw {
+		t.Errorf("WrapConn error. Expecting RawConn unchanged")
+	}
+	if err := p.Put(pc); err != nil {
+		t.Error(err)
+	}
+
+	// 包装随连接保留
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc = conn.(*PoolConn)
+	if pc.RawConn() != raw {
+		t.Fatalf("Get error. Expecting the same pooled conn")
+	}
+	if _, ok := pc.Conn.(*codecConn); !ok {
+		t.Errorf("Get error. Expecting wrapped conn kept, got %T", pc.Conn)
+	}
+
+	// 标记不可用后Close关闭conn并释放名额
+	pc.MarkUnusable()
+	if err := pc.Close(); err != nil {
+		t.Error(err)
+	}
+	if p.OpenNum() != 0 {
+		t.Errorf("Close error. Expecting %d, got %d", 0, p.OpenNum())
+	}
+	if p.InUse() != 0 {
+		t.Errorf("Close error. Expecting %d, got %d", 0, p.InUse())
+	}
+}
+
+func TestChannelPool_WithWrapConn(t *testing.T) {
+	var created, checked net.Conn
+	p, err := NewChannelPool(1, 2, factory,
+		WithWrapConn(func(c net.Conn) net.Conn {
+			return &codecConn{Conn: c}
+		}),
+		WithOnCreate(func(ctx context.Context, conn net.Conn) error {
+			created = conn
+			return nil
+		}),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			checked = conn
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, ok := created.(*codecConn); !ok {
+		t.Errorf("OnCreate error. Expecting *codecConn, got %T", created)
+	}
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc := conn.(*PoolConn)
+	if _, ok := checked.(*codecConn); !ok {
+		t.Errorf("HealthCheck error. Expecting *codecConn, got %T", checked)
+	}
+	if _, ok := pc.Conn.(*codecConn); !ok {
+		t.Errorf("Get error. Expecting *codecConn, got %T", pc.Conn)
+	}
+	if _, ok := pc.RawConn().(*codecConn); ok {
+		t.Errorf("RawConn error. Expecting the unwrapped conn")
+	}
+	if err := p.Put(pc); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPoolConn_HalfClose(t *testing.T) {
+	p, err := NewChannelPool(2, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for _, half := range []func(*PoolConn) error{(*PoolConn).CloseWrite, (*PoolConn).CloseRead} {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		pc := conn.(*PoolConn)
+		if err := half(pc); err != nil {
+			t.Fatalf("half close error: %s", err)
+		}
+		open := p.OpenNum()
+		if err := p.Put(pc); err != nil {
+			t.Error(err)
+		}
+		if p.OpenNum() != open-1 {
+			t.Errorf("Put error. Expecting %d, got %d", open-1, p.OpenNum())
+		}
+		if p.InUse() != 0 {
+			t.Errorf("Put error. Expecting %d, got %d", 0, p.InUse())
+		}
+	}
+	if h := p.ConnAgeStats()[CloseReasonHalfClosed]; h.Count != 2 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 2, h.Count)
+	}
+
+	// 包装后的conn不支持半关闭
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc := conn.(*PoolConn)
+	pc.WrapConn(func(c net.Conn) net.Conn {
+		return &codecConn{Conn: c}
+	})
+	if err := pc.CloseWrite(); err != ErrHalfCloseUnsupported {
+		t.Errorf("CloseWrite error. Expecting %v, got %v", ErrHalfCloseUnsupported, err)
+	}
+	if err := p.Put(pc); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d", 1, p.Len())
+	}
+}
+
+func TestPoolConn_Close(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := p.ConnID(conn)
+	// Close 放回pool
+	if err := conn.Close(); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 1 || p.OpenNum() != 1 {
+		t.Errorf("Close error. Expecting 1 idle 1 open, got %d %d", p.Len(), p.OpenNum())
+	}
+
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := p.ConnID(conn); got != id {
+		t.Errorf("Get error. Expecting conn #%d reused, got #%d", id, got)
+	}
+	// 标记不可用后Put也关闭conn
+	conn.(*PoolConn).MarkUnusable()
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 0 || p.OpenNum() != 0 {
+		t.Errorf("Put error. Expecting 0 idle 0 open, got %d %d", p.Len(), p.OpenNum())
+	}
+	if n := p.ConnAgeStats()[CloseReasonBroken].Count; n != 1 {
+		t.Errorf("Put error. Expecting %d closed as broken, got %d", 1, n)
+	}
+
+	// 标记只对本次取出有效
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+	if p.Len() != 1 {
+		t.Errorf("Close error. Expecting %d idle, got %d", 1, p.Len())
+	}
+}
+
+func TestPoolConn_MarkUnusableAfterPut(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	old, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = p.Put(old)
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.(*PoolConn).RawConn() != old.(*PoolConn).RawConn() {
+		t.Fatalf("Get error. Expecting the same pooled conn")
+	}
+
+	// 旧的PoolConn不能标记新取出方的conn
+	old.(*PoolConn).MarkUnusable()
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 1 || p.OpenNum() != 1 {
+		t.Errorf("Put error. Expecting 1 idle 1 open, got %d %d", p.Len(), p.OpenNum())
+	}
+}
+
+func TestPoolConn_Returned(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	old, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = p.Put(old)
+
+	// 同一个底层conn被其他调用方取出
+	cur, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur == old || cur.(*PoolConn).RawConn() != old.(*PoolConn).RawConn() {
+		t.Fatal("Get error. Expecting a new PoolConn on the same conn")
+	}
+
+	if _, err := old.Write([]byte("x")); err != ErrConnReturned {
+		t.Errorf("Write error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if _, err := old.Read(make([]byte, 1)); err != ErrConnReturned {
+		t.Errorf("Read error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if err := old.SetDeadline(time.Now()); err != ErrConnReturned {
+		t.Errorf("SetDeadline error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if err := old.Close(); err != ErrConnReturned {
+		t.Errorf("Close error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if p.InUse() != 1 {
+		t.Errorf("Close error. Expecting current holder unaffected, got %d in use", p.InUse())
+	}
+
+	if _, err := cur.Write([]byte("x")); err != nil {
+		t.Errorf("Write error: %s", err)
+	}
+	_ = cur.Close()
+
+	// BufferedPoolConn同样失效
+	bc, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = p.Put(bc)
+	if _, err := bc.Write([]byte("x")); err != ErrConnReturned {
+		t.Errorf("Write error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+}
@@ -0,0 +1,92 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+)
+
+var (
+	ErrNoConnCache = errors.New("no conn cache in context")
+)
+
+type connCacheKey struct{}
+
+// connCache 一个请求内取出的conn, 每个pool一个
+type connCache struct {
+	mu sync.Mutex
+
+	conns map[*ChannelPool]net.Conn
+
+	released bool // ctx已结束, conn已放回
+}
+
+// WithConnCache 返回带conn缓存的ctx, 同一个请求内多次调用FromContext复用同一个conn;
+// 调用cancel或ctx结束时缓存的conn放回各自的pool
+func WithConnCache(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	cache := &connCache{conns: make(map[*ChannelPool]net.Conn)}
+	go func() {
+		<-ctx.Done()
+		cache.release()
+	}()
+	return context.WithValue(ctx, connCacheKey{}, cache), cancel
+}
+
+// FromContext 返回ctx的conn缓存中p的conn, 没有时取出一个并缓存; ctx须来自WithConnCache.
+// conn由缓存持有, 调用方不能Put或Close, 出错时应调用Discard, 之后的FromContext会重新取出;
+// 复用同一个conn的调用不能并发使用conn
+func (p *ChannelPool) FromContext(ctx context.Context) (net.Conn, error) {
+	cache, ok := ctx.Value(connCacheKey{}).(*connCache)
+	if !ok {
+		return nil, ErrNoConnCache
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.released {
+		return nil, timeoutErr(ctx)
+	}
+	if conn, ok := cache.conns[p]; ok {
+		if p.checkedOut(conn) {
+			return conn, nil
+		}
+		// 已被Discard或放回
+		delete(cache.conns, p)
+	}
+	conn, err := p.GetWitchContext(p.withCaller(ctx, 2))
+	if err != nil {
+		return nil, err
+	}
+	cache.conns[p] = conn
+	return conn, nil
+}
+
+// release 放回缓存的conn
+func (c *connCache) release() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.released = true
+	for p, conn := range c.conns {
+		if p.checkedOut(conn) {
+			_ = p.Put(conn)
+		}
+	}
+	c.conns = nil
+}
+
+// checkedOut conn是否仍处于取出状态, 且取出时返回的PoolConn未失效
+func (p *ChannelPool) checkedOut(conn net.Conn) bool {
+	if pc, _ := conn.(*PoolConn); pc.stale() {
+		// 已被放回, 底层conn可能已被其他调用方取出
+		return false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	m, ok := p.conns[rawConn(conn)]
+	return ok && !m.idle
+}
@@ -0,0 +1,89 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_FromContext(t *testing.T) {
+	p, err := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.FromContext(context.Background()); err != ErrNoConnCache {
+		t.Errorf("FromContext error. Expecting %v, got %v", ErrNoConnCache, err)
+	}
+
+	ctx, cancel := WithConnCache(context.Background())
+	c1, err := p.FromContext(ctx)
+	if err != nil {
+		t.Fatalf("FromContext error: %s", err)
+	}
+	c2, err := p.FromContext(ctx)
+	if err != nil {
+		t.Fatalf("FromContext error: %s", err)
+	}
+	if c1 != c2 || p.InUse() != 1 {
+		t.Errorf("FromContext error. Expecting same conn, got %p %p, %d in use", c1, c2, p.InUse())
+	}
+
+	// Discard之后重新取出
+	p.Discard(c1)
+	c3, err := p.FromContext(ctx)
+	if err != nil {
+		t.Fatalf("FromContext error: %s", err)
+	}
+	if c3 == c1 || p.InUse() != 1 {
+		t.Errorf("FromContext error. Expecting new conn after Discard, got %d in use", p.InUse())
+	}
+
+	// ctx结束后放回
+	cancel()
+	wctx, wcancel := context.WithTimeout(context.Background(), time.Second)
+	defer wcancel()
+	if err := p.waitUntil(wctx, func() bool { return p.inUse() == 0 }); err != nil {
+		t.Errorf("FromContext error. Expecting conn returned after cancel, got %d in use", p.InUse())
+	}
+	if _, err := p.FromContext(ctx); err == nil {
+		t.Errorf("FromContext error. Expecting error after cancel")
+	}
+}
+
+func TestChannelPool_FromContextStaleHandle(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := WithConnCache(context.Background())
+	defer cancel()
+	c1, err := p.FromContext(ctx)
+	if err != nil {
+		t.Fatalf("FromContext error: %s", err)
+	}
+	// 违反约定放回缓存的conn, 底层conn被其他调用方取出
+	_ = p.Put(c1)
+	other, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.(*PoolConn).RawConn() != c1.(*PoolConn).RawConn() {
+		t.Fatalf("Get error. Expecting the same pooled conn")
+	}
+
+	c2, err := p.FromContext(ctx)
+	if err != nil {
+		t.Fatalf("FromContext error: %s", err)
+	}
+	if c2 == c1 {
+		t.Errorf("FromContext error. Expecting a fresh conn instead of the returned handle")
+	}
+	if _, err := c2.Write([]byte("ping")); err != nil {
+		t.Errorf("Write error: %s", err)
+	}
+	_ = p.Put(other)
+}
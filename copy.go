@@ -0,0 +1,151 @@
+package pool
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// 流式复制的缓冲区大小, 与io.Copy相同
+const copyBufferSize = 32 << 10
+
+var copyBuffers = sync.Pool{New: func() interface{} {
+	b := make([]byte, copyBufferSize)
+	return &b
+}}
+
+// CopyTo 取出conn并把conn上收到的数据写入w, 直到对端结束发送(EOF); 对端结束后conn不能复用, 被关闭.
+// ctx结束时中断复制, 出错时关闭conn
+func (p *ChannelPool) CopyTo(ctx context.Context, w io.Writer) (int64, error) {
+	conn, err := p.GetWitchContext(p.withCaller(ctx, 2))
+	if err != nil {
+		return 0, err
+	}
+	n, err := p.copyConn(ctx, conn, w, conn)
+	if err != nil {
+		p.discard(conn, CloseReasonBroken)
+		return n, err
+	}
+	p.discard(conn, CloseReasonHalfClosed)
+	return n, nil
+}
+
+// CopyFrom 取出conn并把r中的数据写入conn, 直到r结束(EOF), 之后放回conn.
+// ctx结束时中断复制, 出错时conn上的协议状态未知, 关闭conn
+func (p *ChannelPool) CopyFrom(ctx context.Context, r io.Reader) (int64, error) {
+	conn, err := p.GetWitchContext(p.withCaller(ctx, 2))
+	if err != nil {
+		return 0, err
+	}
+	n, err := p.copyConn(ctx, conn, conn, r)
+	if err == nil {
+		err = conn.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		p.discard(conn, CloseReasonBroken)
+		return n, err
+	}
+	return n, p.Put(conn)
+}
+
+// copyConn 从src复制到dst直到src结束, conn为src或dst;
+// 设置了WithCopyIdleTimeout时每次读写前刷新conn的deadline, 否则使用io.CopyBuffer以保留splice等优化
+func (p *ChannelPool) copyConn(ctx context.Context, conn net.Conn, dst io.Writer, src io.Reader) (int64, error) {
+	d := &copyDeadline{ctx: ctx, conn: conn, idle: p.copyIdleTimeout}
+	if err := d.refresh(); err != nil {
+		return 0, err
+	}
+	if ctx.Done() != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		p.goBackground(func() {
+			select {
+			case <-ctx.Done():
+				d.interrupt()
+			case <-stop:
+			}
+		})
+	}
+
+	bufp := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(bufp)
+	buf := *bufp
+
+	if d.idle <= 0 {
+		n, err := io.CopyBuffer(dst, src, buf)
+		return n, d.err(err)
+	}
+
+	var written int64
+	for {
+		if err := d.refresh(); err != nil {
+			return written, err
+		}
+		nr, rerr := src.Read(buf)
+		if nr > 0 {
+			if err := d.refresh(); err != nil {
+				return written, err
+			}
+			nw, werr := dst.Write(buf[:nr])
+			written += int64(nw)
+			if werr != nil {
+				return written, d.err(werr)
+			}
+			if nw != nr {
+				return written, io.ErrShortWrite
+			}
+		}
+		switch {
+		case rerr == io.EOF:
+			return written, nil
+		case rerr != nil:
+			return written, d.err(rerr)
+		}
+	}
+}
+
+// copyDeadline 复制期间conn的deadline, ctx结束后不再刷新
+type copyDeadline struct {
+	mu sync.Mutex
+
+	ctx context.Context
+
+	conn net.Conn
+
+	idle time.Duration // 每次读写的超时时间, <= 0 只使用ctx的截止时间
+}
+
+// refresh 按idle和ctx的截止时间设置conn的deadline
+func (d *copyDeadline) refresh() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.ctx.Err() != nil {
+		return timeoutErr(d.ctx)
+	}
+	var deadline time.Time
+	if d.idle > 0 {
+		deadline = time.Now().Add(d.idle)
+	}
+	if ctxDeadline, ok := d.ctx.Deadline(); ok && (deadline.IsZero() || ctxDeadline.Before(deadline)) {
+		deadline = ctxDeadline
+	}
+	return d.conn.SetDeadline(deadline)
+}
+
+// interrupt ctx结束时中断正在进行的读写
+func (d *copyDeadline) interrupt() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_ = d.conn.SetDeadline(time.Unix(1, 0))
+}
+
+// err ctx结束导致的读写错误转换为TimeoutError
+func (d *copyDeadline) err(err error) error {
+	if err != nil && d.ctx.Err() != nil {
+		return timeoutErr(d.ctx)
+	}
+	return err
+}
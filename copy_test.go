@@ -0,0 +1,170 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamServer 每个conn上先发送payload再关闭, 收到的数据丢弃
+func streamServer(t testing.TB, payload []byte) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if payload != nil {
+					conn.Write(payload)
+					return
+				}
+				io.Copy(io.Discard, conn)
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestChannelPool_CopyTo(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 10000)
+	p, err := NewChannelPool(1, 2, TCPFactory("tcp", streamServer(t, payload)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	var buf bytes.Buffer
+	n, err := p.CopyTo(context.Background(), &buf)
+	if err != nil {
+		t.Fatalf("CopyTo error: %s", err)
+	}
+	if n != int64(len(payload)) || !bytes.Equal(buf.Bytes(), payload) {
+		t.Errorf("CopyTo error. Expecting %d bytes, got %d", len(payload), n)
+	}
+	// 对端已结束发送, conn不能复用
+	if p.OpenNum() != 0 {
+		t.Errorf("CopyTo error. Expecting %d open, got %d", 0, p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonHalfClosed]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestChannelPool_CopyFrom(t *testing.T) {
+	p, err := NewChannelPool(1, 2, TCPFactory("tcp", streamServer(t, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	data := strings.Repeat("x", 100000)
+	n, err := p.CopyFrom(context.Background(), strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("CopyFrom error: %s", err)
+	}
+	if n != int64(len(data)) {
+		t.Errorf("CopyFrom error. Expecting %d, got %d", len(data), n)
+	}
+	if p.Len() != 1 || p.OpenNum() != 1 {
+		t.Errorf("CopyFrom error. Expecting conn returned, got %d idle %d open", p.Len(), p.OpenNum())
+	}
+
+	// 读取r出错时conn上的数据不完整, 关闭conn
+	readErr := errors.New("read error")
+	_, err = p.CopyFrom(context.Background(), io.MultiReader(strings.NewReader(data), &errReader{readErr}))
+	if !errors.Is(err, readErr) {
+		t.Errorf("CopyFrom error. Expecting %v, got %v", readErr, err)
+	}
+	if p.OpenNum() != 0 {
+		t.Errorf("CopyFrom error. Expecting %d open, got %d", 0, p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+func TestChannelPool_CopyToCancel(t *testing.T) {
+	defer leakCheck(t)()
+
+	// server不发送数据, 复制一直阻塞到ctx结束
+	p, err := NewChannelPool(1, 2, TCPFactory("tcp", streamServer(t, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*30, cancel)
+	_, err = p.CopyTo(ctx, io.Discard)
+	var te *TimeoutError
+	if !errors.As(err, &te) || te.Cause != context.Canceled {
+		t.Errorf("CopyTo error. Expecting %v, got %v", context.Canceled, err)
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestChannelPool_CopyIdleTimeout(t *testing.T) {
+	p, err := NewChannelPool(1, 2, TCPFactory("tcp", streamServer(t, nil)), WithCopyIdleTimeout(time.Millisecond*30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	start := time.Now()
+	_, err = p.CopyTo(context.Background(), io.Discard)
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("CopyTo error. Expecting timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("CopyTo error. Expecting idle timeout, took %s", elapsed)
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+
+	// 持续有数据时每次读写刷新deadline, 总时长可以超过idle timeout
+	n, err := p.CopyFrom(context.Background(), &slowReader{n: 5, delay: time.Millisecond * 15})
+	if err != nil || n != 5 {
+		t.Errorf("CopyFrom error. Expecting %d bytes, got %d %v", 5, n, err)
+	}
+}
+
+// slowReader 每次间隔delay读出1字节, 共n字节
+type slowReader struct {
+	n int
+
+	delay time.Duration
+}
+
+func (r *slowReader) Read(b []byte) (int, error) {
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	r.n--
+	b[0] = 'x'
+	return 1, nil
+}
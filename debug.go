@@ -0,0 +1,160 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String 单行描述pool当前状态, 用于日志
+func (p *ChannelPool) String() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return fmt.Sprintf("ChannelPool{state=%s open=%d idle=%d inUse=%d waiters=%d maxFree=%d maxConn=%s oldestIdle=%s}",
+		p.state(), p.acct.Open, p.idle.Len(), p.inUse(), p.waiterCount(), p.maxFree, p.maxConnString(), p.oldestIdleAge())
+}
+
+// DebugString 多行描述pool当前状态和配置, 用于排查问题
+func (p *ChannelPool) DebugString() string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "ChannelPool %p\n", p)
+	fmt.Fprintf(&b, "  state:        %s\n", p.state())
+	fmt.Fprintf(&b, "  maxFree:      %d\n", p.maxFree)
+	fmt.Fprintf(&b, "  maxConn:      %s\n", p.maxConnString())
+	fmt.Fprintf(&b, "  maxIdle:      %d\n", p.maxIdle)
+	fmt.Fprintf(&b, "  warmIdle:     %d\n", p.warmIdle)
+	fmt.Fprintf(&b, "  open:         %d\n", p.acct.Open)
+	fmt.Fprintf(&b, "  idle:         %d\n", p.idle.Len())
+	fmt.Fprintf(&b, "  inUse:        %d\n", p.inUse())
+	fmt.Fprintf(&b, "  waiters:      %d\n", p.waiterCount())
+	fmt.Fprintf(&b, "  created:      %d\n", p.acct.Created)
+	fmt.Fprintf(&b, "  closed:       %d\n", p.acct.Closed)
+	fmt.Fprintf(&b, "  oldestIdle:   %s\n", p.oldestIdleAge())
+	fmt.Fprintf(&b, "  healthCheck:  %t (timeout %s)\n", p.healthCheck != nil, p.healthCheckTimeout)
+	fmt.Fprintf(&b, "  keepAlive:    %s\n", p.keepAlive)
+	fmt.Fprintf(&b, "  closeTimeout: %s\n", p.closeTimeout)
+	fmt.Fprintf(&b, "  closeQueue:   %d/%d\n", len(p.closeCh), cap(p.closeCh))
+	return b.String()
+}
+
+// state pool状态描述, 需持有p.mu
+func (p *ChannelPool) state() string {
+	if p.closed {
+		return "closed"
+	}
+	return "open"
+}
+
+// maxConnString 最大conn数描述
+func (p *ChannelPool) maxConnString() string {
+	if p.unlimited {
+		return "unlimited"
+	}
+	return strconv.FormatInt(p.maxConn, 10)
+}
+
+// oldestIdleAge 最久未使用的空闲conn的空闲时长, 需持有p.mu
+func (p *ChannelPool) oldestIdleAge() time.Duration {
+	oldest, ok := p.oldestIdle()
+	if !ok {
+		return 0
+	}
+	return time.Since(oldest).Round(time.Millisecond)
+}
+
+// DebugHandler 输出DebugString的http.Handler, 带 ?conn=<id> 参数时输出该conn的生命周期事件,
+// 带 ?holds 参数时输出持有时间采样, 带 ?inuse 或 ?idle 参数时输出被取出或空闲的conn,
+// 带 ?quarantine 参数时输出健康检查失败的后端;
+// POST ?close=<id> 调用CloseConn关闭该conn, conn不存在时返回404, 关闭出错时返回500和错误信息
+func (p *ChannelPool) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		if s := r.URL.Query().Get("close"); s != "" {
+			if r.Method != http.MethodPost {
+				http.Error(w, "close requires POST", http.StatusMethodNotAllowed)
+				return
+			}
+			id, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid conn id", http.StatusBadRequest)
+				return
+			}
+			switch err := p.CloseConn(id); {
+			case errors.Is(err, ErrConnNotFound):
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			case err != nil:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintf(w, "conn #%d closed\n", id)
+			return
+		}
+
+		if r.URL.Query().Has("holds") {
+			writeHoldProfile(w, p.HoldProfile())
+			return
+		}
+
+		if r.URL.Query().Has("inuse") {
+			now := time.Now()
+			p.RangeInUse(func(info ConnInfo) bool {
+				fmt.Fprintf(w, "conn #%d backend=%s age=%s uses=%d held=%s holder=%q op=%q pinned=%t\n", info.ID, info.Backend,
+					info.Age.Round(time.Millisecond), info.Uses, now.Sub(info.CheckedOutAt).Round(time.Millisecond), info.Holder,
+					info.Operation, info.Pinned)
+				return true
+			})
+			return
+		}
+
+		if r.URL.Query().Has("idle") {
+			now := time.Now()
+			p.RangeIdle(func(info ConnInfo) bool {
+				fmt.Fprintf(w, "conn #%d backend=%s age=%s uses=%d idle=%s\n", info.ID, info.Backend,
+					info.Age.Round(time.Millisecond), info.Uses, now.Sub(info.IdleSince).Round(time.Millisecond))
+				return true
+			})
+			return
+		}
+
+		if r.URL.Query().Has("quarantine") {
+			now := time.Now()
+			for _, e := range p.Quarantine() {
+				remaining := time.Duration(0)
+				if e.Active(now) {
+					remaining = e.Until.Sub(now).Round(time.Millisecond)
+				}
+				fmt.Fprintf(w, "backend=%s failures=%d backoff=%s remaining=%s\n", e.Backend, e.Failures, e.Backoff, remaining)
+			}
+			return
+		}
+
+		if s := r.URL.Query().Get("conn"); s != "" {
+			id, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid conn id", http.StatusBadRequest)
+				return
+			}
+			events, ok := p.ConnTrace(id)
+			if !ok {
+				http.Error(w, "conn not traced", http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, "conn #%d\n", id)
+			for _, e := range events {
+				fmt.Fprintf(w, "  %s\n", e)
+			}
+			return
+		}
+
+		fmt.Fprint(w, p.DebugString())
+	})
+}
@@ -0,0 +1,41 @@
+package pool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChannelPool_String(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+
+	if _, err := p.Get(); err != nil {
+		t.Errorf("Get error: %s", err)
+	}
+
+	s := p.String()
+	for _, want := range []string{"state=open", "open=3", "idle=2", "waiters=0", "maxConn=5"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("String error. Expecting %q in %q", want, s)
+		}
+	}
+	if strings.Contains(s, "\n") {
+		t.Errorf("String error. Expecting single line, got %q", s)
+	}
+
+	_ = p.Close()
+	if s := p.String(); !strings.Contains(s, "state=closed") {
+		t.Errorf("String error. Expecting %q in %q", "state=closed", s)
+	}
+}
+
+func TestChannelPool_DebugString(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	s := p.DebugString()
+	for _, want := range []string{"state:", "open:", "idle:", "waiters:", "created:", "oldestIdle:"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("DebugString error. Expecting %q in %q", want, s)
+		}
+	}
+}
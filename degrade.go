@@ -0,0 +1,65 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DegradeEvent pool进入或离开降级状态
+type DegradeEvent struct {
+	Time time.Time
+
+	Degraded bool // true为进入降级, false为恢复
+
+	Failures int // 连续失败次数, 恢复时为0
+
+	Err error // 最近一次失败的错误, 恢复时为nil
+}
+
+// OnDegrade 连续新建或健康检查失败达到阈值进入降级时调用, 之后第一次成功时以Degraded为false再调用一次;
+// 依赖方可据此提前打开自己的熔断器
+type OnDegrade func(DegradeEvent)
+
+// Degraded 是否处于降级状态, 即连续新建或健康检查失败已达到WithDegradeThreshold的阈值且尚未成功
+func (p *ChannelPool) Degraded() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.degraded
+}
+
+// isDegradeFailure 失败是否计入连续失败, 调用方放弃、本地限制和没有实际新建的失败不算
+func isDegradeFailure(ctx context.Context, err error) bool {
+	return isBackendFailure(ctx, err) && !errors.Is(err, ErrBackendUnavailable)
+}
+
+// degradeResult 记录一次新建或健康检查的结果, 状态变化时返回事件, 需持有p.mu
+func (p *ChannelPool) degradeResult(err error) *DegradeEvent {
+	if err == nil {
+		p.failStreak = 0
+		if !p.degraded {
+			return nil
+		}
+		p.degraded = false
+		return &DegradeEvent{Time: time.Now()}
+	}
+	p.failStreak++
+	if p.degraded || p.failStreak < p.degradeThreshold {
+		return nil
+	}
+	p.degraded = true
+	return &DegradeEvent{Time: time.Now(), Degraded: true, Failures: p.failStreak, Err: err}
+}
+
+// observeDegrade 记录一次新建或健康检查的结果, 状态变化时调用onDegrade
+func (p *ChannelPool) observeDegrade(ctx context.Context, err error) {
+	if p.degradeThreshold <= 0 || (err != nil && !isDegradeFailure(ctx, err)) {
+		return
+	}
+	p.mu.Lock()
+	event := p.degradeResult(err)
+	p.mu.Unlock()
+	if event != nil && p.onDegrade != nil {
+		p.onDegrade(*event)
+	}
+}
@@ -0,0 +1,113 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestChannelPool_Degraded(t *testing.T) {
+	var failing atomic.Bool
+	dialErr := errors.New("connection refused")
+	f := func() (net.Conn, error) {
+		if failing.Load() {
+			return nil, dialErr
+		}
+		return factory()
+	}
+	var (
+		mu     sync.Mutex
+		events []DegradeEvent
+	)
+	onDegrade := func(e DegradeEvent) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+	p, err := NewChannelPool(3, 5, f, WithInitialConns(0), WithDegradeThreshold(3, onDegrade))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	failing.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, err := p.Get(); err == nil {
+			t.Fatalf("Get error. Expecting dial error")
+		}
+	}
+	if p.Degraded() {
+		t.Errorf("Degraded error. Expecting false before threshold")
+	}
+	// 调用方放弃不计入连续失败
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = p.GetWitchContext(ctx)
+	if p.Degraded() {
+		t.Errorf("Degraded error. Expecting canceled Get not counted")
+	}
+
+	if _, err := p.Get(); err == nil {
+		t.Fatalf("Get error. Expecting dial error")
+	}
+	if !p.Degraded() || !p.Stats().Degraded {
+		t.Errorf("Degraded error. Expecting true after %d failures", 3)
+	}
+	// 降级期间的失败不重复通知
+	_, _ = p.Get()
+
+	failing.Store(false)
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if p.Degraded() {
+		t.Errorf("Degraded error. Expecting recovered after success")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 {
+		t.Fatalf("OnDegrade error. Expecting %d events, got %d", 2, len(events))
+	}
+	if !events[0].Degraded || events[0].Failures != 3 || !errors.Is(events[0].Err, dialErr) {
+		t.Errorf("OnDegrade error. Expecting degraded after 3 failures, got %+v", events[0])
+	}
+	if events[1].Degraded || events[1].Err != nil {
+		t.Errorf("OnDegrade error. Expecting recovery, got %+v", events[1])
+	}
+}
+
+func TestChannelPool_DegradedHealthCheck(t *testing.T) {
+	var failing atomic.Bool
+	check := func(ctx context.Context, conn net.Conn) error {
+		if failing.Load() {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}
+	p, err := NewChannelPool(3, 5, factory, WithHealthCheck(check), WithDegradeThreshold(2, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 两个空闲conn检查失败后新建成功, 新建成功即恢复
+	failing.Store(true)
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if p.Degraded() {
+		t.Errorf("Degraded error. Expecting recovered by successful dial")
+	}
+	p.Put(conn)
+
+	if _, err := NewChannelPool(3, 5, factory, WithDegradeThreshold(-1, nil)); err == nil {
+		t.Errorf("NewChannelPool error. Expecting invalid degrade threshold")
+	}
+}
@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// 开发模式下panic的误用种类, 见MisuseError
+const (
+	MisuseForeignPut    = "foreign_put"     // 放回的conn不是pool创建的, 或已被pool关闭
+	MisuseDoublePut     = "double_put"      // 放回已经空闲的conn
+	MisuseUseAfterPut   = "use_after_put"   // 放回后继续使用取出时的PoolConn, 未开启时返回ErrConnReturned
+	MisuseGetAfterClose = "get_after_close" // pool关闭后获取conn
+)
+
+// MisuseError 开启WithDevelopmentMode时发现误用后panic的值, 包含定位问题所需的调用位置
+type MisuseError struct {
+	Misuse string // MisuseForeignPut 等
+
+	Pool string // pool名称, 见WithName
+
+	ConnID uint64 // 相关的conn, 不是pool创建的conn或获取时为0
+
+	Holder string // 取出conn的调用位置
+
+	ReturnedAt string // 放回conn的调用位置
+
+	Caller string // 发生误用的调用位置
+
+	Stack string // 发生误用时的调用栈
+}
+
+func (e *MisuseError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pool misuse: %s", e.Misuse)
+	if e.Pool != "" {
+		fmt.Fprintf(&b, " (pool %q)", e.Pool)
+	}
+	if e.ConnID != 0 {
+		fmt.Fprintf(&b, " conn #%d", e.ConnID)
+	}
+	if e.Holder != "" {
+		fmt.Fprintf(&b, ", checked out at %s", e.Holder)
+	}
+	if e.ReturnedAt != "" {
+		fmt.Fprintf(&b, ", returned at %s", e.ReturnedAt)
+	}
+	if e.Caller != "" {
+		fmt.Fprintf(&b, ", misused at %s", e.Caller)
+	}
+	if e.Stack != "" {
+		fmt.Fprintf(&b, "\n%s", e.Stack)
+	}
+	return b.String()
+}
+
+// misuse 开启WithDevelopmentMode时panic, 不能持有p.mu; m为nil时不记录conn的信息
+func (p *ChannelPool) misuse(kind string, m *connMeta, returnedAt string) {
+	if !p.devMode {
+		return
+	}
+	e := &MisuseError{Misuse: kind, Pool: p.name, ReturnedAt: returnedAt, Caller: misuseCaller()}
+	if m != nil {
+		e.ConnID, e.Holder = m.id, m.holder
+	}
+	buf := make([]byte, 8192)
+	e.Stack = string(buf[:runtime.Stack(buf, false)])
+	panic(e)
+}
+
+// misuseCaller 调用pool的位置, 跳过pool内部的调用
+func misuseCaller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		f, more := frames.Next()
+		if !isPoolFrame(f) {
+			return fmt.Sprintf("%s:%d", filepath.Base(f.File), f.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// isPoolFrame 是否是本包(不含测试)中的调用
+func isPoolFrame(f runtime.Frame) bool {
+	if strings.HasSuffix(f.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(f.Function, "ConnPool.") || strings.HasPrefix(f.Function, "ConnPool/poolcore.")
+}
@@ -0,0 +1,97 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// expectMisuse 执行f并检查panic的误用种类
+func expectMisuse(t *testing.T, kind string, f func()) *MisuseError {
+	t.Helper()
+	var e *MisuseError
+	func() {
+		defer func() {
+			err, _ := recover().(error)
+			if !errors.As(err, &e) {
+				t.Errorf("misuse error. Expecting panic with %s, got %v", kind, err)
+			}
+		}()
+		f()
+	}()
+	if e != nil && e.Misuse != kind {
+		t.Errorf("misuse error. Expecting %s, got %s", kind, e.Misuse)
+	}
+	return e
+}
+
+func TestChannelPool_DevelopmentMode(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory, WithDevelopmentMode(), WithName("dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	id, _ := p.ConnID(conn)
+	_ = p.Put(conn)
+
+	// 放回后继续使用
+	e := expectMisuse(t, MisuseUseAfterPut, func() { _, _ = conn.Write([]byte("x")) })
+	if e != nil {
+		if e.ConnID != id || e.Pool != "dev" {
+			t.Errorf("misuse error. Expecting conn #%d in pool dev, got #%d in %q", id, e.ConnID, e.Pool)
+		}
+		if !strings.HasPrefix(e.Holder, "dev_test.go:") || !strings.HasPrefix(e.ReturnedAt, "dev_test.go:") || !strings.HasPrefix(e.Caller, "dev_test.go:") {
+			t.Errorf("misuse error. Expecting call sites in dev_test.go, got %q %q %q", e.Holder, e.ReturnedAt, e.Caller)
+		}
+	}
+
+	// 重复放回
+	expectMisuse(t, MisuseDoublePut, func() { _ = conn.Close() })
+
+	// 再次取出同一个conn时使用新的PoolConn, 旧的仍不能使用
+	again, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == conn {
+		t.Error("Get error. Expecting a new PoolConn in development mode")
+	}
+	if _, err := again.Write([]byte("x")); err != nil {
+		t.Error(err)
+	}
+	expectMisuse(t, MisuseUseAfterPut, func() { _ = conn.SetDeadline(again.(*PoolConn).Acquisition().Time) })
+	_ = again.Close()
+
+	// 放回其他conn
+	other, err := net.Dial(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMisuse(t, MisuseForeignPut, func() { _ = p.Put(other) })
+
+	// pool关闭后获取
+	_ = p.Close()
+	expectMisuse(t, MisuseGetAfterClose, func() { _, _ = p.Get() })
+}
+
+func TestChannelPool_DevelopmentModeOff(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, _ := p.Get()
+	_ = p.Put(conn)
+	_ = p.Put(conn)
+	_ = p.Close()
+	if _, err := p.Get(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get error. Expecting %v, got %v", ErrClosed, err)
+	}
+}
@@ -0,0 +1,152 @@
+package pool
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DialOption TCPFactory 的可选配置
+type DialOption func(*tcpDialer)
+
+type tcpDialer struct {
+	dialer net.Dialer
+
+	readBuffer int // SO_RCVBUF, <= 0 使用系统默认值
+
+	writeBuffer int // SO_SNDBUF, <= 0 使用系统默认值
+
+	noDelay *bool // TCP_NODELAY, nil 使用Go的默认值(开启)
+
+	cache *resolveCache // 主机名解析缓存, nil 每次新建都由dialer解析
+
+	family AddressFamily // 优先或只连接的地址族, FamilyUnknown 不限制
+
+	requireFamily bool // 只连接family的地址
+
+	stats *FamilyStats // 按地址族的连接统计, nil 不统计
+}
+
+// WithDialTimeout 设置建立连接的超时时间
+func WithDialTimeout(timeout time.Duration) DialOption {
+	return func(d *tcpDialer) {
+		d.dialer.Timeout = timeout
+	}
+}
+
+// WithReadBuffer 设置socket接收缓冲区大小(SO_RCVBUF)
+func WithReadBuffer(bytes int) DialOption {
+	return func(d *tcpDialer) {
+		d.readBuffer = bytes
+	}
+}
+
+// WithWriteBuffer 设置socket发送缓冲区大小(SO_SNDBUF)
+func WithWriteBuffer(bytes int) DialOption {
+	return func(d *tcpDialer) {
+		d.writeBuffer = bytes
+	}
+}
+
+// WithNoDelay 设置是否关闭Nagle算法(TCP_NODELAY)
+func WithNoDelay(noDelay bool) DialOption {
+	return func(d *tcpDialer) {
+		d.noDelay = &noDelay
+	}
+}
+
+// WithResolver 使用resolver解析address中的主机名并缓存ttl, 缓存期间新建conn不再解析, 依次连接解析到的各个地址;
+// resolver为nil时使用net.DefaultResolver. 用于conn频繁重建时减轻DNS的压力
+func WithResolver(resolver *net.Resolver, ttl time.Duration) DialOption {
+	return func(d *tcpDialer) {
+		d.cache = newResolveCache(resolver, ttl)
+	}
+}
+
+// WithAddressFamily 解析到多个地址时先连接family的地址, require为true时只连接family的地址,
+// 没有该地址族的地址时返回ErrAddressFamily; 未设置WithResolver时每次新建都解析, 不缓存
+func WithAddressFamily(family AddressFamily, require bool) DialOption {
+	return func(d *tcpDialer) {
+		d.family = family
+		d.requireFamily = require
+	}
+}
+
+// WithFamilyStats 按地址族把每次连接的结果记录到stats, 多个factory可以共用一个stats
+func WithFamilyStats(stats *FamilyStats) DialOption {
+	return func(d *tcpDialer) {
+		d.stats = stats
+	}
+}
+
+// TCPFactory 同 TCPFactoryContext, 新建不能被取消, 建议使用 TCPFactoryContext
+func TCPFactory(network, address string, opts ...DialOption) Factory {
+	f := TCPFactoryContext(network, address, opts...)
+	return func() (net.Conn, error) {
+		return f(context.Background())
+	}
+}
+
+// TCPFactoryContext 返回建立TCP连接的FactoryContext, 连接建立后按配置设置socket参数, 设置失败时关闭连接并返回error
+func TCPFactoryContext(network, address string, opts ...DialOption) FactoryContext {
+	d := &tcpDialer{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	if d.cache == nil && d.family != FamilyUnknown {
+		d.cache = newResolveCache(d.dialer.Resolver, 0)
+	}
+	return func(ctx context.Context) (net.Conn, error) {
+		conn, err := d.dial(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.tune(conn); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+}
+
+// dial 建立连接, 设置了WithResolver或WithAddressFamily时自行解析主机名并依次连接各个地址
+func (d *tcpDialer) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.cache != nil {
+		return d.cache.dial(ctx, d.dialAddr, network, address, d.family, d.requireFamily)
+	}
+	return d.dialAddr(ctx, network, address)
+}
+
+// dialAddr 连接一个地址并按地址族记录结果
+func (d *tcpDialer) dialAddr(ctx context.Context, network, address string) (net.Conn, error) {
+	start := time.Now()
+	conn, err := d.dialer.DialContext(ctx, network, address)
+	if d.stats != nil {
+		d.stats.observe(dialedFamily(address, conn, err), time.Since(start), err)
+	}
+	return conn, err
+}
+
+// tune 设置TCP conn的socket参数
+func (d *tcpDialer) tune(conn net.Conn) error {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+	if d.readBuffer > 0 {
+		if err := tc.SetReadBuffer(d.readBuffer); err != nil {
+			return err
+		}
+	}
+	if d.writeBuffer > 0 {
+		if err := tc.SetWriteBuffer(d.writeBuffer); err != nil {
+			return err
+		}
+	}
+	if d.noDelay != nil {
+		if err := tc.SetNoDelay(*d.noDelay); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPFactory(t *testing.T) {
+	f := TCPFactory("tcp", "127.0.0.1:7777",
+		WithDialTimeout(time.Second),
+		WithReadBuffer(64<<10),
+		WithWriteBuffer(64<<10),
+		WithNoDelay(false))
+
+	p, err := NewChannelPool(1, 2, f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if _, ok := conn.(*PoolConn).RawConn().(*net.TCPConn); !ok {
+		t.Errorf("TCPFactory error. Expecting *net.TCPConn, got %T", conn.(*PoolConn).RawConn())
+	}
+	if _, err := conn.Write([]byte("hello\n")); err != nil {
+		t.Errorf("Write error: %s", err)
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTCPFactory_DialError(t *testing.T) {
+	// 取一个没有监听的端口
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	f := TCPFactory("tcp", addr, WithDialTimeout(time.Second))
+	if conn, err := f(); err == nil {
+		conn.Close()
+		t.Errorf("TCPFactory error. Expecting dial error")
+	}
+}
+
+func TestTCPFactoryContext(t *testing.T) {
+	f := TCPFactoryContext("tcp", "127.0.0.1:7777", WithDialTimeout(time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if conn, err := f(ctx); err == nil {
+		conn.Close()
+		t.Errorf("TCPFactoryContext error. Expecting canceled dial")
+	}
+
+	conn, err := f(context.Background())
+	if err != nil {
+		t.Fatalf("TCPFactoryContext error: %s", err)
+	}
+	conn.Close()
+}
+
+func TestChannelPool_FactoryContext(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	p, err := NewChannelPoolContext(1, 2, func(ctx context.Context) (net.Conn, error) {
+		entered <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 调用方放弃等待时取消新建
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	if _, err := p.GetWitchContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetWitchContext error. Expecting %v, got %v", context.DeadlineExceeded, err)
+	}
+	<-entered
+
+	// pool关闭时取消新建
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Get()
+		done <- err
+	}()
+	<-entered
+	p.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Get error. Expecting %v, got %v", context.Canceled, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Get error. Expecting dial canceled by Close")
+	}
+	if p.InUse() != 0 {
+		t.Errorf("Close error. Expecting %d, got %d", 0, p.InUse())
+	}
+}
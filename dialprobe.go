@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrBackendUnavailable = errors.New("backend unavailable")
+)
+
+// ProbePolicy 后端不可用期间, 探测新建进行中时其他新建的处理方式
+type ProbePolicy int
+
+const (
+	ProbeWait     ProbePolicy = iota // 等待探测结果, 成功后各自新建, 失败时返回探测的错误
+	ProbeFailFast                    // 不等待, 直接返回最近一次新建的错误
+)
+
+// dialProbe 一次探测新建
+type dialProbe struct {
+	done chan struct{} // 探测结束时close
+
+	err error // 探测的结果, 探测因ctx结束等原因没有结果时为errProbeAborted
+}
+
+// 探测没有得到后端是否可用的结果, 等待者重新探测
+var errProbeAborted = errors.New("dial probe aborted")
+
+// isBackendFailure 新建失败是否说明后端不可用, 调用方放弃和本地的限制不算
+func isBackendFailure(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, ErrPortExhausted) && !errors.Is(err, ErrDialBudgetExceeded) &&
+		!errors.Is(err, ErrClosed)
+}
+
+func backendErr(err error) error {
+	return fmt.Errorf("%w: %w", ErrBackendUnavailable, err)
+}
+
+// enterProbe 最近一次新建失败时, 同一时间只允许一个新建作为探测, 返回值表示本次新建是否为探测
+func (p *ChannelPool) enterProbe(ctx context.Context) (bool, error) {
+	if !p.dialProbe {
+		return false, nil
+	}
+	for {
+		p.mu.Lock()
+		if p.dialErr == nil {
+			p.mu.Unlock()
+			return false, nil
+		}
+		if p.probe == nil {
+			p.probe = &dialProbe{done: make(chan struct{})}
+			p.mu.Unlock()
+			return true, nil
+		}
+		if p.probePolicy == ProbeFailFast {
+			err := p.dialErr
+			p.mu.Unlock()
+			return false, backendErr(err)
+		}
+		probe := p.probe
+		p.mu.Unlock()
+
+		select {
+		case <-probe.done:
+		case <-ctx.Done():
+			return false, timeoutErr(ctx)
+		case <-p.done:
+			return false, ErrClosed
+		}
+		switch probe.err {
+		case nil:
+			return false, nil
+		case errProbeAborted:
+			// 没有结果, 重新探测
+		default:
+			return false, backendErr(probe.err)
+		}
+	}
+}
+
+// exitProbe 记录新建的结果, 本次为探测时唤醒等待结果的新建
+func (p *ChannelPool) exitProbe(ctx context.Context, prober bool, err error) {
+	if !p.dialProbe {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	result := err
+	switch {
+	case err == nil:
+		p.dialErr = nil
+	case isBackendFailure(ctx, err):
+		p.dialErr = err
+	default:
+		result = errProbeAborted
+	}
+	if prober {
+		p.probe.err = result
+		close(p.probe.done)
+		p.probe = nil
+	}
+}
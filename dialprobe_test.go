@@ -0,0 +1,171 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// probeBackend 可控制的后端, gate不为nil时新建阻塞到gate关闭
+type probeBackend struct {
+	mu      sync.Mutex
+	err     error
+	gate    chan struct{}
+	entered chan struct{}
+	dials   int
+}
+
+func (b *probeBackend) factory() (net.Conn, error) {
+	b.mu.Lock()
+	b.dials++
+	gate := b.gate
+	b.mu.Unlock()
+	if gate != nil {
+		b.entered <- struct{}{}
+		<-gate
+	}
+	b.mu.Lock()
+	err := b.err
+	b.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return factory()
+}
+
+func (b *probeBackend) set(err error, gate chan struct{}) {
+	b.mu.Lock()
+	b.err, b.gate = err, gate
+	b.mu.Unlock()
+}
+
+func (b *probeBackend) dialCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dials
+}
+
+func TestChannelPool_DialProbeFailFast(t *testing.T) {
+	down := errors.New("connection refused")
+	b := &probeBackend{err: down, entered: make(chan struct{}, 1)}
+	p, err := NewChannelPool(3, 5, b.factory, WithInitialConns(0), WithDialProbe(ProbeFailFast))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Get(); err != down {
+		t.Fatalf("Get error. Expecting %v, got %v", down, err)
+	}
+
+	// 探测进行中时其他新建直接失败
+	gate := make(chan struct{})
+	b.set(nil, gate)
+	done := make(chan error, 1)
+	go func() {
+		conn, err := p.Get()
+		if err == nil {
+			p.Put(conn)
+		}
+		done <- err
+	}()
+	<-b.entered
+	if _, err := p.Get(); !errors.Is(err, ErrBackendUnavailable) || !errors.Is(err, down) {
+		t.Errorf("Get error. Expecting %v caused by %v, got %v", ErrBackendUnavailable, down, err)
+	}
+	if n := b.dialCount(); n != 2 {
+		t.Errorf("Get error. Expecting %d dials, got %d", 2, n)
+	}
+	close(gate)
+	if err := <-done; err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	// 探测成功后正常新建
+	b.set(nil, nil)
+	conns := make([]net.Conn, 0, 2)
+	for i := 0; i < 2; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		p.Put(conn)
+	}
+}
+
+func TestChannelPool_DialProbeWait(t *testing.T) {
+	down := errors.New("connection refused")
+	b := &probeBackend{err: down, entered: make(chan struct{}, 1)}
+	p, err := NewChannelPool(3, 5, b.factory, WithInitialConns(0), WithDialProbe(ProbeWait))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Get(); err != down {
+		t.Fatalf("Get error. Expecting %v, got %v", down, err)
+	}
+
+	// 探测失败时等待者返回探测的错误
+	still := errors.New("still down")
+	gate := make(chan struct{})
+	b.set(still, gate)
+	probeDone := make(chan error, 1)
+	go func() {
+		_, err := p.Get()
+		probeDone <- err
+	}()
+	<-b.entered
+	waitDone := make(chan error, 1)
+	go func() {
+		_, err := p.Get()
+		waitDone <- err
+	}()
+	time.Sleep(time.Millisecond * 20)
+	close(gate)
+	if err := <-probeDone; err != still {
+		t.Errorf("Get error. Expecting %v, got %v", still, err)
+	}
+	if err := <-waitDone; !errors.Is(err, ErrBackendUnavailable) || !errors.Is(err, still) {
+		t.Errorf("Get error. Expecting %v caused by %v, got %v", ErrBackendUnavailable, still, err)
+	}
+	if n := b.dialCount(); n != 2 {
+		t.Errorf("Get error. Expecting %d dials, got %d", 2, n)
+	}
+
+	// 探测成功后等待者各自新建
+	gate = make(chan struct{})
+	b.set(nil, gate)
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			conn, err := p.Get()
+			if err == nil {
+				defer p.Put(conn)
+			}
+			results <- err
+		}()
+	}
+	<-b.entered
+	time.Sleep(time.Millisecond * 20)
+	b.set(nil, nil)
+	close(gate)
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("Get error: %s", err)
+		}
+	}
+
+	// ctx结束的等待者返回超时
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.GetWitchContext(ctx); err == nil {
+		t.Errorf("GetWitchContext error. Expecting canceled")
+	}
+}
@@ -0,0 +1,96 @@
+package pool
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// DoOption Do 的可选配置
+type DoOption func(*doConfig)
+
+type doConfig struct {
+	attemptTimeout time.Duration // 每次尝试的超时时间, <= 0 不限制
+
+	maxAttempts int // 最多尝试次数, 只有idempotent时大于1才生效
+
+	idempotent bool // fn可以安全地重复执行
+}
+
+// WithAttemptTimeout 设置每次尝试的超时时间, 包括取出conn和执行fn; 执行fn期间设置为conn的deadline
+func WithAttemptTimeout(timeout time.Duration) DoOption {
+	return func(c *doConfig) {
+		c.attemptTimeout = timeout
+	}
+}
+
+// WithMaxAttempts 设置最多尝试次数, fn出错时关闭conn并使用另一个conn重试, 需同时设置WithIdempotent
+func WithMaxAttempts(n int) DoOption {
+	return func(c *doConfig) {
+		c.maxAttempts = n
+	}
+}
+
+// WithIdempotent 标记fn可以安全地重复执行, 未标记时fn出错不重试
+func WithIdempotent() DoOption {
+	return func(c *doConfig) {
+		c.idempotent = true
+	}
+}
+
+// Do 取出conn执行fn, fn返回nil时放回pool, 返回error时认为conn状态未知, 关闭conn;
+// 设置了WithIdempotent和WithMaxAttempts时fn出错后换一个conn重试, 返回最后一次的error.
+// 取出conn失败时不重试
+func (p *ChannelPool) Do(ctx context.Context, fn func(conn net.Conn) error, opts ...DoOption) error {
+	cfg := doConfig{maxAttempts: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if !cfg.idempotent || cfg.maxAttempts < 1 {
+		cfg.maxAttempts = 1
+	}
+
+	ctx = p.withCaller(ctx, 2)
+	var err error
+	for attempt := 0; attempt < cfg.maxAttempts; attempt++ {
+		if attempt > 0 && ctx.Err() != nil {
+			return err
+		}
+		var retry bool
+		if retry, err = p.doAttempt(ctx, fn, cfg.attemptTimeout); !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// doAttempt 执行一次尝试, 返回fn是否出错, fn出错时可以重试
+func (p *ChannelPool) doAttempt(ctx context.Context, fn func(conn net.Conn) error, timeout time.Duration) (bool, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	conn, err := p.GetWitchContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && timeout > 0 {
+		if err := conn.SetDeadline(deadline); err != nil {
+			p.discard(conn, CloseReasonBroken)
+			return true, err
+		}
+	}
+	if err := fn(conn); err != nil {
+		p.discard(conn, CloseReasonBroken)
+		return true, err
+	}
+	if timeout > 0 {
+		if err := conn.SetDeadline(time.Time{}); err != nil {
+			p.discard(conn, CloseReasonBroken)
+			return false, nil
+		}
+	}
+	return false, p.Put(conn)
+}
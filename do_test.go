@@ -0,0 +1,119 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_Do(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	err := p.Do(context.Background(), func(conn net.Conn) error {
+		if p.Len() != maxFree-1 {
+			t.Errorf("Do error. Expecting %d, got %d", maxFree-1, p.Len())
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Do error: %s", err)
+	}
+	if p.Len() != maxFree {
+		t.Errorf("Do error. Expecting %d, got %d", maxFree, p.Len())
+	}
+
+	// 出错的conn被关闭
+	errBroken := errors.New("broken")
+	err = p.Do(context.Background(), func(conn net.Conn) error {
+		return errBroken
+	})
+	if err != errBroken {
+		t.Errorf("Do error. Expecting %v, got %v", errBroken, err)
+	}
+	if p.OpenNum() != maxFree-1 {
+		t.Errorf("Do error. Expecting %d, got %d", maxFree-1, p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 1 {
+		t.Errorf("Do error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestChannelPool_DoRetry(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	errBroken := errors.New("broken")
+	var ids []uint64
+	err := p.Do(context.Background(), func(conn net.Conn) error {
+		id, _ := p.ConnID(conn)
+		ids = append(ids, id)
+		if len(ids) < 3 {
+			return errBroken
+		}
+		return nil
+	}, WithMaxAttempts(3), WithIdempotent())
+	if err != nil {
+		t.Errorf("Do error: %s", err)
+	}
+	if len(ids) != 3 || ids[0] == ids[1] || ids[1] == ids[2] {
+		t.Errorf("Do error. Expecting 3 attempts on different conns, got %v", ids)
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 2 {
+		t.Errorf("Do error. Expecting %d, got %d", 2, h.Count)
+	}
+
+	// 未标记幂等时不重试
+	attempts := 0
+	err = p.Do(context.Background(), func(conn net.Conn) error {
+		attempts++
+		return errBroken
+	}, WithMaxAttempts(3))
+	if err != errBroken || attempts != 1 {
+		t.Errorf("Do error. Expecting %v after %d attempt, got %v after %d", errBroken, 1, err, attempts)
+	}
+}
+
+func TestChannelPool_DoAttemptTimeout(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	// 不发送请求, 读取会一直阻塞到deadline
+	attempts := 0
+	start := time.Now()
+	err := p.Do(context.Background(), func(conn net.Conn) error {
+		attempts++
+		_, err := conn.Read(make([]byte, 1))
+		return err
+	}, WithAttemptTimeout(time.Millisecond*30), WithMaxAttempts(2), WithIdempotent())
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Do error. Expecting timeout, got %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Do error. Expecting %d attempts, got %d", 2, attempts)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*500 {
+		t.Errorf("Do error. Expecting return after attempt timeouts, cost %s", cost)
+	}
+
+	// 成功的尝试放回的conn不保留deadline
+	single, _ := NewChannelPool(1, 1, factory)
+	defer single.Close()
+	err = single.Do(context.Background(), func(conn net.Conn) error {
+		return nil
+	}, WithAttemptTimeout(time.Millisecond*30))
+	if err != nil {
+		t.Errorf("Do error: %s", err)
+	}
+	time.Sleep(time.Millisecond * 40)
+	err = single.Do(context.Background(), func(conn net.Conn) error {
+		_, err := conn.Write([]byte("ping"))
+		return err
+	})
+	if err != nil {
+		t.Errorf("Do error: %s", err)
+	}
+}
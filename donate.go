@@ -0,0 +1,114 @@
+package pool
+
+import (
+	"net"
+)
+
+// Donate 把p中最多n个空闲conn转给to, 省去to新建conn的开销, 返回转出的conn数;
+// p有等待者时不转出, 转入受to的maxFree和maxConn限制. to须连接与p相同的后端, 由调用方保证
+func (p *ChannelPool) Donate(to *ChannelPool, n int) int {
+	return p.donate(to, n, 0)
+}
+
+// donate 同Donate, p至少保留keep个空闲conn
+func (p *ChannelPool) donate(to *ChannelPool, n, keep int) int {
+	if to == p {
+		return 0
+	}
+	donated := 0
+	for donated < n {
+		conn, m, ok := p.popSurplus(keep)
+		if !ok {
+			break
+		}
+		if !to.adopt(conn, m) {
+			p.restoreIdle(conn)
+			break
+		}
+		p.donated(conn)
+		donated++
+	}
+	return donated
+}
+
+// donated 移除已转给其他pool的conn的登记
+func (p *ChannelPool) donated(conn net.Conn) {
+	p.mu.Lock()
+	p.forget(conn, CloseReasonDonated)
+	p.donatedNum++
+	p.mu.Unlock()
+}
+
+// popSurplus 没有等待者且空闲conn多于keep个时取出一个空闲conn, conn仍登记在p中
+func (p *ChannelPool) popSurplus(keep int) (net.Conn, connMeta, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || p.waiterCount() > 0 || p.idle.Len() <= keep {
+		return nil, connMeta{}, false
+	}
+	conn, ok := p.popIdle("")
+	if !ok {
+		return nil, connMeta{}, false
+	}
+	return conn, *p.conns[conn], true
+}
+
+// restoreIdle 转出失败时放回空闲conn
+func (p *ChannelPool) restoreIdle(conn net.Conn) {
+	p.mu.Lock()
+	if !p.closed && int64(p.idle.Len()) < p.maxIdle {
+		p.idle.Push(conn)
+		p.markIdle(conn)
+		p.mu.Unlock()
+		return
+	}
+	c := p.forget(conn, CloseReasonOverflow)
+	p.mu.Unlock()
+	p.closeAsync(c)
+}
+
+// adopt 接收其他pool转来的空闲conn, 保留其创建时间等元数据
+func (p *ChannelPool) adopt(conn net.Conn, from connMeta) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed || int64(p.idle.Len()) >= p.maxIdle {
+		return false
+	}
+	// 取出的conn和正在新建的conn占用的容量单位加上空闲conn不能超过maxConn
+	if !p.unlimited && p.sem != nil && p.sem.Held()+int64(p.idle.Len()) >= p.maxConn {
+		return false
+	}
+
+	p.registerAdopted(conn, from)
+	p.idle.Push(conn)
+	p.markIdle(conn)
+	return true
+}
+
+// adoptBusy 接收其他pool转来的空闲conn, 登记为取出的conn, 调用方已占用容量单位
+func (p *ChannelPool) adoptBusy(conn net.Conn, from connMeta) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+	p.registerAdopted(conn, from)
+	return true
+}
+
+// registerAdopted 登记转入的conn, 保留其创建时间等元数据并计入Budget, 需持有p.mu
+func (p *ChannelPool) registerAdopted(conn net.Conn, from connMeta) *connMeta {
+	m := p.register(conn, from.conn)
+	m.createdAt = from.createdAt
+	m.expiryScale = from.expiryScale
+	m.dialDuration = from.dialDuration
+	m.handshakeDuration = from.handshakeDuration
+	m.cred = from.cred
+	m.tls = from.tls
+	p.budget.add()
+	p.adoptedNum++
+	return m
+}
@@ -0,0 +1,86 @@
+package pool
+
+import (
+	"testing"
+)
+
+func TestChannelPool_Donate(t *testing.T) {
+	from, err := NewChannelPool(3, 3, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer from.Close()
+	to, err := NewChannelPool(2, 3, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer to.Close()
+
+	// 受to的maxFree限制
+	if n := from.Donate(to, 5); n != 2 {
+		t.Errorf("Donate error. Expecting %d, got %d", 2, n)
+	}
+	if from.Len() != 1 || from.OpenNum() != 1 {
+		t.Errorf("Donate error. Expecting %d, got %d idle %d open", 1, from.Len(), from.OpenNum())
+	}
+	if to.Len() != 2 || to.OpenNum() != 2 {
+		t.Errorf("Donate error. Expecting %d, got %d idle %d open", 2, to.Len(), to.OpenNum())
+	}
+
+	// 转入的conn可以正常使用, 不需要新建
+	conn, err := to.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Errorf("Write error: %s", err)
+	}
+	to.Put(conn)
+
+	fs, ts := from.Stats(), to.Stats()
+	if fs.Donated != 2 || ts.Adopted != 2 || ts.Misses != 0 {
+		t.Errorf("Stats error. Expecting %d donated %d adopted %d misses, got %d %d %d",
+			2, 2, 0, fs.Donated, ts.Adopted, ts.Misses)
+	}
+	if h := from.ConnAgeStats()[CloseReasonDonated]; h.Count != 2 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 2, h.Count)
+	}
+}
+
+func TestChannelPool_DonateRecipientFull(t *testing.T) {
+	from, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer from.Close()
+	to, err := NewChannelPool(1, 2, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer to.Close()
+
+	if n := from.Donate(from, 1); n != 0 {
+		t.Errorf("Donate error. Expecting %d, got %d", 0, n)
+	}
+
+	// 转入方取出的conn加空闲conn已达到maxConn
+	c1, err := to.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	c2, err := to.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if n := from.Donate(to, 1); n != 0 || from.Len() != 1 {
+		t.Errorf("Donate error. Expecting %d donated %d kept, got %d %d", 0, 1, n, from.Len())
+	}
+	to.Put(c1)
+	to.Put(c2)
+
+	// 转入方关闭后不再转入
+	to.Close()
+	if n := from.Donate(to, 1); n != 0 || from.Len() != 1 {
+		t.Errorf("Donate error. Expecting %d donated %d kept, got %d %d", 0, 1, n, from.Len())
+	}
+}
@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrDialBudgetExceeded = errors.New("dial budget exceeded")
+)
+
+// TimeoutError 等待conn期间ctx结束, errors.Is 同时匹配 ErrTimeOut 和 ctx.Err(),
+// 可以区分调用方取消(context.Canceled)和等待超时(context.DeadlineExceeded)
+type TimeoutError struct {
+	Cause error // ctx.Err()
+}
+
+func (e *TimeoutError) Error() string {
+	return ErrTimeOut.Error() + ": " + e.Cause.Error()
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *TimeoutError) Is(target error) bool {
+	return target == ErrTimeOut
+}
+
+// FactoryError factory返回了不可用的conn: 返回nil且没有error, 或返回的conn已关闭
+type FactoryError struct {
+	Err error // ErrNilConn 或检查conn时的错误
+}
+
+func (e *FactoryError) Error() string {
+	return "invalid conn from factory: " + e.Err.Error()
+}
+
+func (e *FactoryError) Unwrap() error {
+	return e.Err
+}
+
+// 限制新建的机制, 见DialThrottledError
+const (
+	LimiterRamp       = "ramp"        // 爬坡期间等待新建名额时ctx结束, 见WithRampUp
+	LimiterChurnGuard = "churn_guard" // 新建速率超过WithChurnGuard上限
+	LimiterBudget     = "budget"      // WithBudget的名额用完且没有可转入的空闲conn
+)
+
+// DialThrottledError 新建被pool自身的限速推迟或拒绝, 而不是后端不可用;
+// errors.Is 同时匹配 ErrDialBudgetExceeded 和 Err
+type DialThrottledError struct {
+	Limiter string // LimiterRamp、LimiterChurnGuard 或 LimiterBudget
+
+	Err error // 爬坡时为TimeoutError, 超过新建速率上限时匹配ErrChurnLimit, 名额用完时为ErrBudgetExhausted
+}
+
+func (e *DialThrottledError) Error() string {
+	return ErrDialBudgetExceeded.Error() + " (" + e.Limiter + "): " + e.Err.Error()
+}
+
+func (e *DialThrottledError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DialThrottledError) Is(target error) bool {
+	return target == ErrDialBudgetExceeded
+}
+
+// GetError 开启WithGetErrorContext时Get返回的错误, 附带失败时pool的状态, 方便直接从日志排查;
+// errors.Is 和 errors.As 作用于Err
+type GetError struct {
+	Pool string // WithName设置的pool名称
+
+	InUse   int
+	Idle    int
+	Waiters int
+
+	LastDialErr error // 最近一次新建失败的错误, 没有失败过时为nil
+
+	LastDialErrAt time.Time // 最近一次新建失败的时间
+
+	Err error // Get原本返回的错误
+}
+
+func (e *GetError) Error() string {
+	var b strings.Builder
+	if e.Pool != "" {
+		fmt.Fprintf(&b, "pool %s: ", e.Pool)
+	}
+	fmt.Fprintf(&b, "get: %s (in_use=%d idle=%d waiters=%d", e.Err, e.InUse, e.Idle, e.Waiters)
+	if e.LastDialErr != nil {
+		fmt.Fprintf(&b, " last_dial_err=%q at %s", e.LastDialErr.Error(), e.LastDialErrAt.Format(time.RFC3339))
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func (e *GetError) Unwrap() error {
+	return e.Err
+}
+
+// getErr 开启WithGetErrorContext时用GetError包装Get的错误
+func (p *ChannelPool) getErr(err error) error {
+	if !p.getErrContext {
+		return err
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return &GetError{
+		Pool:          p.name,
+		InUse:         p.inUse(),
+		Idle:          p.idle.Len(),
+		Waiters:       p.waiterCount(),
+		LastDialErr:   p.lastDialErr,
+		LastDialErrAt: p.lastDialErrAt,
+		Err:           err,
+	}
+}
+
+// timeoutErr 根据ctx生成TimeoutError
+func timeoutErr(ctx context.Context) error {
+	cause := ctx.Err()
+	if cause == nil {
+		cause = context.DeadlineExceeded
+	}
+	return &TimeoutError{Cause: cause}
+}
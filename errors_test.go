@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_TimeoutError(t *testing.T) {
+	p, _ := NewChannelPool(1, 1, factory)
+	defer p.Close()
+
+	if _, err := p.Get(); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	// 等待超时
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer cancel()
+	_, err := p.GetWitchContext(ctx)
+	if !errors.Is(err, ErrTimeOut) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get error. Expecting %v wrapping %v, got %v", ErrTimeOut, context.DeadlineExceeded, err)
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("Get error. Expecting not %v, got %v", context.Canceled, err)
+	}
+
+	// 调用方取消
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		cancel()
+	}()
+	_, err = p.GetWitchContext(ctx)
+	if !errors.Is(err, ErrTimeOut) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Get error. Expecting %v wrapping %v, got %v", ErrTimeOut, context.Canceled, err)
+	}
+
+	var te *TimeoutError
+	if !errors.As(err, &te) || te.Cause != context.Canceled {
+		t.Errorf("Get error. Expecting *TimeoutError, got %T", err)
+	}
+}
+
+func TestChannelPool_FactoryError(t *testing.T) {
+	var ret func() (net.Conn, error)
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		return ret()
+	}, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// factory返回nil且没有error
+	ret = func() (net.Conn, error) { return nil, nil }
+	_, err = p.Get()
+	var fe *FactoryError
+	if !errors.As(err, &fe) || !errors.Is(err, ErrNilConn) {
+		t.Errorf("Get error. Expecting FactoryError with %v, got %v", ErrNilConn, err)
+	}
+
+	// factory返回已关闭的conn
+	ret = func() (net.Conn, error) {
+		conn, err := factory()
+		if err == nil {
+			conn.Close()
+		}
+		return conn, err
+	}
+	_, err = p.Get()
+	if !errors.As(err, &fe) || !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Get error. Expecting FactoryError with %v, got %v", net.ErrClosed, err)
+	}
+	if p.InUse() != 0 || p.OpenNum() != 0 {
+		t.Errorf("Get error. Expecting no conn held, got %d inUse %d open", p.InUse(), p.OpenNum())
+	}
+
+	// 之后正常新建
+	ret = factory
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+
+	if _, err := NewChannelPool(1, 2, nil); err == nil {
+		t.Errorf("NewChannelPool error. Expecting invalid factory")
+	}
+}
+
+func TestChannelPool_GetErrorContext(t *testing.T) {
+	var failing atomic.Bool
+	dialErr := errors.New("connection refused")
+	f := func() (net.Conn, error) {
+		if failing.Load() {
+			return nil, dialErr
+		}
+		return factory()
+	}
+	p, err := NewChannelPool(1, 2, f, WithName("users"), WithGetErrorContext())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c1, _ := p.Get()
+	failing.Store(true)
+	_, err = p.Get()
+	var ge *GetError
+	if !errors.As(err, &ge) || !errors.Is(err, dialErr) {
+		t.Fatalf("Get error. Expecting GetError wrapping %v, got %v", dialErr, err)
+	}
+	if ge.Pool != "users" || ge.InUse != 1 || ge.Idle != 0 || ge.LastDialErr != dialErr {
+		t.Errorf("Get error. Expecting pool users 1 in use and last dial error, got %+v", ge)
+	}
+	if s := ge.Error(); !strings.Contains(s, "pool users") || !strings.Contains(s, "in_use=1") || !strings.Contains(s, "last_dial_err") {
+		t.Errorf("GetError error. Expecting pool state in message, got %q", s)
+	}
+
+	// 等待超时时仍附带最近一次新建失败
+	failing.Store(false)
+	c2, _ := p.Get()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.GetWitchContext(ctx)
+	if !errors.As(err, &ge) || !errors.Is(err, ErrTimeOut) || ge.InUse != 2 || ge.LastDialErr != dialErr {
+		t.Errorf("Get error. Expecting timeout GetError with 2 in use, got %v", err)
+	}
+	p.Put(c1)
+	p.Put(c2)
+}
@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"math/rand"
+	"time"
+)
+
+// expiryScale 为新建的conn生成过期时间的缩放系数, 取值 (1-expiryJitter, 1]
+func (p *ChannelPool) expiryScale() float64 {
+	if p.expiryJitter <= 0 {
+		return 1
+	}
+	return 1 - p.expiryJitter*rand.Float64()
+}
+
+// scaled 按conn的缩放系数计算实际的过期时长
+func scaled(d time.Duration, scale float64) time.Duration {
+	return time.Duration(float64(d) * scale)
+}
+
+// expired 判断空闲conn是否已超过最大存活时间或最大空闲时间, 或对端证书即将过期, 或后端处于隔离期, 需持有p.mu
+func (p *ChannelPool) expired(m *connMeta, now time.Time) (CloseReason, bool) {
+	if p.quarantined(m.backend, now) {
+		return CloseReasonQuarantined, true
+	}
+	if p.certExpiring(m, now) {
+		return CloseReasonCertExpiry, true
+	}
+	if p.lifetimeExceeded(m, now) {
+		return CloseReasonMaxLifetime, true
+	}
+	if p.maxIdleTime > 0 && now.Sub(m.idleSince) >= scaled(p.maxIdleTime, m.expiryScale) {
+		return CloseReasonIdleTimeout, true
+	}
+	return 0, false
+}
+
+// lifetimeExceeded 判断conn是否已超过最大存活时间, 需持有p.mu
+func (p *ChannelPool) lifetimeExceeded(m *connMeta, now time.Time) bool {
+	return p.maxLifetime > 0 && now.Sub(m.createdAt) >= scaled(p.maxLifetime, m.expiryScale)
+}
+
+// certExpiring 判断TLS conn的对端证书是否已到淘汰时间, 需持有p.mu
+func (p *ChannelPool) certExpiring(m *connMeta, now time.Time) bool {
+	if m.tls == nil || m.tls.PeerNotAfter.IsZero() {
+		return false
+	}
+	return !now.Before(m.tls.PeerNotAfter.Add(-p.tlsExpiryMargin))
+}
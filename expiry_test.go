@@ -0,0 +1,109 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChannelPool_MaxIdleTime(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory, WithMaxIdleTime(time.Millisecond*30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	time.Sleep(time.Millisecond * 50)
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if id, _ := p.ConnID(conn); id != 2 {
+		t.Errorf("Get error. Expecting conn %d, got %d", 2, id)
+	}
+	if h := p.ConnAgeStats()[CloseReasonIdleTimeout]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+	if p.OpenNum() != 1 {
+		t.Errorf("Get error. Expecting %d, got %d", 1, p.OpenNum())
+	}
+
+	// 刚放回的conn没有过期
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if id, _ := p.ConnID(conn); id != 2 {
+		t.Errorf("Get error. Expecting conn %d, got %d", 2, id)
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChannelPool_MaxLifetime(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory, WithMaxLifetime(time.Millisecond*30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if id, _ := p.ConnID(conn); id != 2 {
+		t.Errorf("Get error. Expecting conn %d, got %d", 2, id)
+	}
+	if h := p.ConnAgeStats()[CloseReasonMaxLifetime]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChannelPool_ExpiryJitter(t *testing.T) {
+	p := &ChannelPool{expiryJitter: 0.2, maxIdleTime: time.Second}
+
+	seen := make(map[float64]bool)
+	for i := 0; i < 100; i++ {
+		scale := p.expiryScale()
+		if scale <= 0.8 || scale > 1 {
+			t.Fatalf("expiryScale error. Expecting (0.8, 1], got %f", scale)
+		}
+		seen[scale] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("expiryScale error. Expecting spread scales, got %d distinct", len(seen))
+	}
+
+	now := time.Now()
+	m := &connMeta{createdAt: now, idleSince: now.Add(-time.Millisecond * 900), expiryScale: 0.85}
+	if reason, ok := p.expired(m, now); !ok || reason != CloseReasonIdleTimeout {
+		t.Errorf("expired error. Expecting %s, got %s %t", CloseReasonIdleTimeout, reason, ok)
+	}
+	m.expiryScale = 0.95
+	if _, ok := p.expired(m, now); ok {
+		t.Errorf("expired error. Expecting not expired")
+	}
+}
+
+func TestNew_InvalidExpiryJitter(t *testing.T) {
+	for _, jitter := range []float64{-0.1, 1} {
+		if _, err := NewChannelPool(1, 2, factory, WithMaxIdleTimeJitter(jitter)); err == nil {
+			t.Errorf("New error. Expecting invalid jitter %f rejected", jitter)
+		}
+	}
+}
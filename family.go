@@ -0,0 +1,149 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrAddressFamily = errors.New("no address of the required family")
+)
+
+// AddressFamily 地址族
+type AddressFamily int
+
+const (
+	FamilyUnknown AddressFamily = iota // 不是IP地址, 如unix socket
+	FamilyIPv4
+	FamilyIPv6
+)
+
+func (f AddressFamily) String() string {
+	switch f {
+	case FamilyIPv4:
+		return "ipv4"
+	case FamilyIPv6:
+		return "ipv6"
+	default:
+		return "unknown"
+	}
+}
+
+// familyOf IP地址的地址族
+func familyOf(ip net.IP) AddressFamily {
+	switch {
+	case ip == nil:
+		return FamilyUnknown
+	case ip.To4() != nil:
+		return FamilyIPv4
+	default:
+		return FamilyIPv6
+	}
+}
+
+// hostFamily host:port或host中host的地址族, host不是IP地址时为FamilyUnknown
+func hostFamily(address string) AddressFamily {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	return familyOf(net.ParseIP(host))
+}
+
+// addrFamily net.Addr的地址族
+func addrFamily(addr net.Addr) AddressFamily {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return familyOf(a.IP)
+	case *net.UDPAddr:
+		return familyOf(a.IP)
+	case *net.IPAddr:
+		return familyOf(a.IP)
+	case nil:
+		return FamilyUnknown
+	default:
+		return hostFamily(a.String())
+	}
+}
+
+// orderAddrs 按地址族排列解析到的地址, require为true时只保留family的地址, 否则family的地址在前; 同一地址族内保持原顺序
+func orderAddrs(addrs []string, family AddressFamily, require bool) []string {
+	if family == FamilyUnknown {
+		return addrs
+	}
+	ordered := make([]string, 0, len(addrs))
+	var rest []string
+	for _, a := range addrs {
+		if hostFamily(a) == family {
+			ordered = append(ordered, a)
+		} else if !require {
+			rest = append(rest, a)
+		}
+	}
+	return append(ordered, rest...)
+}
+
+// FamilyDialStats 一个地址族的新建统计
+type FamilyDialStats struct {
+	Dials int64 // 尝试连接的次数, 一次新建依次连接多个地址时每个地址计一次
+
+	Failures int64 // 连接失败的次数
+
+	Duration time.Duration // 累计连接耗时, 包括失败的
+}
+
+// SuccessRate 连接成功的比例
+func (s FamilyDialStats) SuccessRate() float64 {
+	return ratio(s.Dials-s.Failures, s.Dials)
+}
+
+// AvgDuration 平均每次连接的耗时
+func (s FamilyDialStats) AvgDuration() time.Duration {
+	if s.Dials == 0 {
+		return 0
+	}
+	return s.Duration / time.Duration(s.Dials)
+}
+
+// FamilyStats 按地址族统计TCPFactory的连接结果, 用于发现IPv6等路径故障导致的新建缓慢; 并发安全
+type FamilyStats struct {
+	mu sync.Mutex
+
+	stats [3]FamilyDialStats // 按AddressFamily索引
+}
+
+// Family 返回family的统计, 未知的family返回零值
+func (s *FamilyStats) Family(family AddressFamily) FamilyDialStats {
+	if family < 0 || int(family) >= len(s.stats) {
+		return FamilyDialStats{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats[family]
+}
+
+// observe 记录一次连接
+func (s *FamilyStats) observe(family AddressFamily, d time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := &s.stats[family]
+	st.Dials++
+	st.Duration += d
+	if err != nil {
+		st.Failures++
+	}
+}
+
+// dialedFamily 一次连接的地址族, 优先使用conn或错误中的对端地址
+func dialedFamily(address string, conn net.Conn, err error) AddressFamily {
+	if conn != nil {
+		return addrFamily(conn.RemoteAddr())
+	}
+	var oe *net.OpError
+	if errors.As(err, &oe) && oe.Addr != nil {
+		return addrFamily(oe.Addr)
+	}
+	return hostFamily(address)
+}
@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestOrderAddrs(t *testing.T) {
+	addrs := []string{"::1", "127.0.0.1", "fe80::1", "10.0.0.1"}
+	tests := []struct {
+		family  AddressFamily
+		require bool
+		want    []string
+	}{
+		{FamilyUnknown, false, addrs},
+		{FamilyIPv4, false, []string{"127.0.0.1", "10.0.0.1", "::1", "fe80::1"}},
+		{FamilyIPv6, true, []string{"::1", "fe80::1"}},
+		{FamilyIPv4, true, []string{"127.0.0.1", "10.0.0.1"}},
+	}
+	for _, tt := range tests {
+		if got := orderAddrs(addrs, tt.family, tt.require); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("orderAddrs error. Expecting %v, got %v", tt.want, got)
+		}
+	}
+}
+
+func TestTCPFactory_AddressFamily(t *testing.T) {
+	_, port, _ := net.SplitHostPort(address)
+	stats := &FamilyStats{}
+
+	f := TCPFactoryContext(network, net.JoinHostPort("localhost", port), WithAddressFamily(FamilyIPv4, false), WithFamilyStats(stats))
+	conn, err := f(context.Background())
+	if err != nil {
+		t.Fatalf("dial error: %s", err)
+	}
+	conn.Close()
+
+	f = TCPFactoryContext(network, address, WithAddressFamily(FamilyIPv6, true), WithFamilyStats(stats))
+	if _, err := f(context.Background()); !errors.Is(err, ErrAddressFamily) {
+		t.Errorf("dial error. Expecting %v, got %v", ErrAddressFamily, err)
+	}
+
+	// 本地没有监听IPv6
+	f = TCPFactoryContext(network, net.JoinHostPort("::1", port), WithFamilyStats(stats))
+	if _, err := f(context.Background()); err == nil {
+		t.Fatalf("dial error. Expecting error on ::1")
+	}
+
+	if s := stats.Family(FamilyIPv4); s.Dials != 1 || s.Failures != 0 || s.SuccessRate() != 1 {
+		t.Errorf("FamilyStats error. Expecting 1 successful ipv4 dial, got %+v", s)
+	}
+	if s := stats.Family(FamilyIPv6); s.Dials != 1 || s.Failures != 1 || s.SuccessRate() != 0 {
+		t.Errorf("FamilyStats error. Expecting 1 failed ipv6 dial, got %+v", s)
+	}
+	// 未知的地址族返回零值
+	for _, f := range []AddressFamily{-1, FamilyIPv6 + 1} {
+		if s := stats.Family(f); s != (FamilyDialStats{}) {
+			t.Errorf("FamilyStats error. Expecting zero value for family %d, got %+v", f, s)
+		}
+	}
+}
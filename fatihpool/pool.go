@@ -0,0 +1,104 @@
+// Package pool 兼容 github.com/fatih/pool 的API, 底层使用 ConnPool 实现.
+//
+// 迁移时只需把 import "github.com/fatih/pool" 替换为 import "ConnPool/fatihpool".
+package pool
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+
+	connpool "ConnPool"
+)
+
+var (
+	// ErrClosed pool已关闭
+	ErrClosed = connpool.ErrClosed
+)
+
+// Pool 同 fatih/pool.Pool
+type Pool interface {
+	// Get 获得conn, pool中没有空闲conn时新建, conn的Close会把conn放回pool
+	Get() (net.Conn, error)
+
+	// Close 关闭pool及其空闲conn
+	Close()
+
+	// Len 空闲conn数
+	Len() int
+}
+
+// Factory net.Conn 生产者
+type Factory func() (net.Conn, error)
+
+type channelPool struct {
+	p *connpool.ChannelPool
+}
+
+// NewChannelPool 创建pool并建立initialCap个conn, 最多保留maxCap个空闲conn, conn总数不限制
+func NewChannelPool(initialCap, maxCap int, factory Factory) (Pool, error) {
+	if initialCap < 0 || maxCap <= 0 || initialCap > maxCap {
+		return nil, errors.New("invalid capacity settings")
+	}
+
+	p, err := connpool.NewChannelPool(int64(maxCap), 0, connpool.Factory(factory),
+		connpool.WithUnlimitedConns(),
+		connpool.WithInitialConns(initialCap))
+	if err != nil {
+		return nil, err
+	}
+	return &channelPool{p: p}, nil
+}
+
+func (c *channelPool) Get() (net.Conn, error) {
+	conn, err := c.p.Get()
+	if err != nil {
+		return nil, err
+	}
+	return &PoolConn{Conn: conn, c: c}, nil
+}
+
+func (c *channelPool) Close() {
+	_ = c.p.Close()
+}
+
+func (c *channelPool) Len() int {
+	return c.p.Len()
+}
+
+// PoolConn 同 fatih/pool.PoolConn, Close时放回pool
+type PoolConn struct {
+	net.Conn
+	mu       sync.RWMutex
+	c        *channelPool
+	unusable bool
+}
+
+// Close 把conn放回pool, 标记为不可用时关闭conn
+func (p *PoolConn) Close() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.unusable {
+		return p.c.p.Discard(p.Conn)
+	}
+	return p.c.p.Put(p.Conn)
+}
+
+// MarkUnusable 标记conn不可用, 之后的Close会关闭conn而不是放回pool
+func (p *PoolConn) MarkUnusable() {
+	p.mu.Lock()
+	p.unusable = true
+	p.mu.Unlock()
+}
+
+// ReadFrom 交给ConnPool的conn处理, 保留splice/sendfile
+func (p *PoolConn) ReadFrom(r io.Reader) (int64, error) {
+	return p.Conn.(io.ReaderFrom).ReadFrom(r)
+}
+
+// WriteTo 交给ConnPool的conn处理, 保留splice
+func (p *PoolConn) WriteTo(w io.Writer) (int64, error) {
+	return p.Conn.(io.WriterTo).WriteTo(w)
+}
@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func newListener(t *testing.T) (net.Listener, Factory) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 256)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						conn.Close()
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return l, func() (net.Conn, error) { return net.Dial("tcp", l.Addr().String()) }
+}
+
+func TestNew(t *testing.T) {
+	l, factory := newListener(t)
+	defer l.Close()
+
+	p, err := NewChannelPool(2, 5, factory)
+	if err != nil {
+		t.Fatalf("New error: %s", err)
+	}
+	defer p.Close()
+
+	if p.Len() != 2 {
+		t.Errorf("New error. Expecting %d, got %d", 2, p.Len())
+	}
+
+	for _, c := range [][2]int{{-1, 5}, {0, 0}, {6, 5}} {
+		if _, err := NewChannelPool(c[0], c[1], factory); err == nil {
+			t.Errorf("New error. Expecting invalid capacity for %v", c)
+		}
+	}
+}
+
+func TestPool_GetClose(t *testing.T) {
+	l, factory := newListener(t)
+	defer l.Close()
+
+	p, _ := NewChannelPool(0, 2, factory)
+
+	// 空闲为空时新建, 且不限制总数
+	conns := make([]net.Conn, 0, 4)
+	for i := 0; i < 4; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		if _, ok := conn.(*PoolConn); !ok {
+			t.Errorf("Get error. Expecting *PoolConn, got %T", conn)
+		}
+		conns = append(conns, conn)
+	}
+
+	// Close 放回pool, 超出maxCap的被关闭
+	for _, conn := range conns {
+		if err := conn.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+	if p.Len() != 2 {
+		t.Errorf("Close error. Expecting %d, got %d", 2, p.Len())
+	}
+
+	// 不可用的conn不放回
+	conn, _ := p.Get()
+	conn.(*PoolConn).MarkUnusable()
+	_ = conn.Close()
+	if p.Len() != 1 {
+		t.Errorf("MarkUnusable error. Expecting %d, got %d", 1, p.Len())
+	}
+
+	p.Close()
+	if _, err := p.Get(); err != ErrClosed {
+		t.Errorf("Get error. Expecting %v, got %v", ErrClosed, err)
+	}
+}
+
+func TestPoolConn_ReadFrom(t *testing.T) {
+	l, factory := newListener(t)
+	defer l.Close()
+
+	p, _ := NewChannelPool(0, 1, factory)
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	defer conn.Close()
+
+	// PoolConn 保留ConnPool conn的ReadFrom/WriteTo
+	if _, ok := conn.(io.ReaderFrom); !ok {
+		t.Errorf("Get error. Expecting io.ReaderFrom, got %T", conn)
+	}
+	if _, ok := conn.(io.WriterTo); !ok {
+		t.Errorf("Get error. Expecting io.WriterTo, got %T", conn)
+	}
+	n, err := io.Copy(conn, io.LimitReader(zeroReader{}, 1000))
+	if err != nil || n != 1000 {
+		t.Errorf("ReadFrom error. Expecting %d, got %d %v", 1000, n, err)
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
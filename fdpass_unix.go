@@ -0,0 +1,137 @@
+//go:build unix
+
+package pool
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrNotPassable = errors.New("conn cannot be passed to another process")
+)
+
+type filer interface {
+	File() (*os.File, error)
+}
+
+// fdPassable 包装过的conn(WithWrapConn、WithTLSClient)带有进程内状态, 不能只传递文件描述符
+func (p *ChannelPool) fdPassable() bool {
+	return p.wrapConn == nil && p.tlsConfig == nil
+}
+
+// ExportIdle 实验性: 把最多n个空闲conn的文件描述符通过uc(SCM_RIGHTS)发送给接管的进程, 返回发送的conn数;
+// 发送成功的conn从pool移除并关闭本进程持有的描述符, socket由接收方继续使用. 有等待者时不发送;
+// factory返回的conn须实现File()(如*net.TCPConn), 设置了WithWrapConn或WithTLSClient时返回ErrNotPassable
+func (p *ChannelPool) ExportIdle(uc *net.UnixConn, n int) (int, error) {
+	if !p.fdPassable() {
+		return 0, ErrNotPassable
+	}
+	exported := 0
+	for exported < n {
+		conn, m, ok := p.popSurplus(0)
+		if !ok {
+			break
+		}
+		if err := sendConn(uc, conn, m.createdAt); err != nil {
+			p.restoreIdle(conn)
+			return exported, err
+		}
+		p.mu.Lock()
+		c := p.forget(conn, CloseReasonDonated)
+		p.donatedNum++
+		p.mu.Unlock()
+		p.closeAsync(c)
+		exported++
+	}
+	return exported, nil
+}
+
+// sendConn 发送conn的文件描述符, 附带创建时间
+func sendConn(uc *net.UnixConn, conn net.Conn, createdAt time.Time) error {
+	fc, ok := conn.(filer)
+	if !ok {
+		return ErrNotPassable
+	}
+	f, err := fc.File()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(createdAt.UnixNano()))
+	_, _, err = uc.WriteMsgUnix(b[:], syscall.UnixRights(int(f.Fd())), nil)
+	return err
+}
+
+// ImportConns 实验性: 从uc接收ExportIdle发送的conn作为空闲conn加入pool, 保留其创建时间, 直到对端关闭uc或已接收n个,
+// 返回加入的conn数; 超过maxFree或maxConn而无法加入的conn被关闭. 设置了WithWrapConn或WithTLSClient时返回ErrNotPassable
+func (p *ChannelPool) ImportConns(uc *net.UnixConn, n int) (int, error) {
+	if !p.fdPassable() {
+		return 0, ErrNotPassable
+	}
+	imported := 0
+	for received := 0; received < n; received++ {
+		conn, createdAt, err := recvConn(uc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, err
+		}
+		if !p.adopt(conn, connMeta{conn: conn, createdAt: createdAt, expiryScale: p.expiryScale()}) {
+			_ = conn.Close()
+			continue
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// recvConn 接收sendConn发送的conn, 对端关闭时返回io.EOF
+func recvConn(uc *net.UnixConn) (net.Conn, time.Time, error) {
+	var b [8]byte
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := uc.ReadMsgUnix(b[:], oob)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if n == 0 && oobn == 0 {
+		return nil, time.Time{}, io.EOF
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(msgs) != 1 {
+		return nil, time.Time{}, errors.New("unexpected control message")
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(fds) != 1 {
+		for _, fd := range fds {
+			_ = syscall.Close(fd)
+		}
+		return nil, time.Time{}, errors.New("unexpected number of file descriptors")
+	}
+
+	f := os.NewFile(uintptr(fds[0]), "pooled-conn")
+	conn, err := net.FileConn(f)
+	_ = f.Close()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	createdAt := time.Now()
+	if n == len(b) {
+		createdAt = time.Unix(0, int64(binary.BigEndian.Uint64(b[:])))
+	}
+	return conn, createdAt, nil
+}
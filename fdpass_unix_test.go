@@ -0,0 +1,110 @@
+//go:build unix
+
+package pool
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func unixPair(t *testing.T) (*net.UnixConn, *net.UnixConn) {
+	addr := &net.UnixAddr{Name: filepath.Join(t.TempDir(), "handoff.sock"), Net: "unix"}
+	l, err := net.ListenUnix("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	client, err := net.DialUnix("unix", nil, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := l.AcceptUnix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server
+}
+
+func TestChannelPool_ExportImport(t *testing.T) {
+	old, err := NewChannelPool(3, 5, factory, WithInitialConns(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer old.Close()
+	var created []time.Time
+	old.RangeIdle(func(info ConnInfo) bool {
+		created = append(created, info.CreatedAt)
+		return true
+	})
+
+	successor, err := NewChannelPool(3, 5, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer successor.Close()
+
+	send, recv := unixPair(t)
+	defer recv.Close()
+
+	done := make(chan int)
+	go func() {
+		n, err := successor.ImportConns(recv, 10)
+		if err != nil {
+			t.Errorf("ImportConns error: %s", err)
+		}
+		done <- n
+	}()
+
+	n, err := old.ExportIdle(send, 10)
+	if err != nil || n != 2 {
+		t.Fatalf("ExportIdle error. Expecting 2, got %d %v", n, err)
+	}
+	send.Close()
+	if n := <-done; n != 2 {
+		t.Fatalf("ImportConns error. Expecting %d, got %d", 2, n)
+	}
+
+	if old.OpenNum() != 0 || old.Stats().Donated != 2 {
+		t.Errorf("ExportIdle error. Expecting 0 open 2 donated, got %d %d", old.OpenNum(), old.Stats().Donated)
+	}
+	if successor.Len() != 2 || successor.Stats().Adopted != 2 {
+		t.Errorf("ImportConns error. Expecting 2 idle 2 adopted, got %d %d", successor.Len(), successor.Stats().Adopted)
+	}
+	i := 0
+	successor.RangeIdle(func(info ConnInfo) bool {
+		if !info.CreatedAt.Equal(created[i]) || info.Backend != address {
+			t.Errorf("ImportConns error. Expecting created %s backend %s, got %s %s", created[i], address, info.CreatedAt, info.Backend)
+		}
+		i++
+		return true
+	})
+
+	// 接收的socket仍可使用
+	conn, err := successor.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if _, err := conn.Write([]byte("handoff")); err != nil {
+		t.Errorf("Write error: %s", err)
+	}
+	successor.Put(conn)
+}
+
+func TestChannelPool_ExportNotPassable(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithWrapConn(func(c net.Conn) net.Conn { return c }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	send, recv := unixPair(t)
+	defer send.Close()
+	defer recv.Close()
+	if _, err := p.ExportIdle(send, 1); err != ErrNotPassable {
+		t.Errorf("ExportIdle error. Expecting %v, got %v", ErrNotPassable, err)
+	}
+	if p.Len() != 3 {
+		t.Errorf("ExportIdle error. Expecting %d idle, got %d", 3, p.Len())
+	}
+}
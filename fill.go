@@ -0,0 +1,94 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// 初始conn新建失败后后台补建的退避时间
+const (
+	fillBackoffMin = 100 * time.Millisecond
+	fillBackoffMax = 10 * time.Second
+)
+
+// FillEvent 后台补建初始conn的进度
+type FillEvent struct {
+	Time time.Time
+
+	Open int // 当前已创建未关闭的conn数
+
+	Target int // 初始conn数, 见WithInitialConns
+
+	Attempt int // 后台补建的次数, 0 为创建pool时的新建
+
+	Err error // 本次新建的错误, nil 表示成功
+
+	Done bool // 已达到Target, 后台补建结束
+}
+
+// OnFill 初始conn新建失败后每次补建时调用
+type OnFill func(e FillEvent)
+
+// refill 创建pool时初始conn未全部建立, 在后台按退避时间补建直到conn数达到target或pool关闭
+func (p *ChannelPool) refill(target int) {
+	backoff := fillBackoffMin
+	done := false // 是否已报告达到target
+	for attempt := 1; ; attempt++ {
+		p.mu.RLock()
+		closed, open := p.closed, int(p.acct.Open)
+		p.mu.RUnlock()
+		switch {
+		case closed:
+			return
+		case open >= target:
+			// 其他调用新建的conn也计入
+			if !done {
+				p.fillProgress(attempt-1, target, nil)
+			}
+			return
+		}
+
+		// 容量被取出的conn占满时不新建, 等待后重试
+		if p.tryAcquire() {
+			conn, err := p.dial(context.Background())
+			if err == nil {
+				err = p.Put(conn)
+				backoff = fillBackoffMin
+				done = p.fillProgress(attempt, target, err)
+				continue
+			}
+			p.release()
+			done = p.fillProgress(attempt, target, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-p.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if backoff *= 2; backoff > fillBackoffMax {
+			backoff = fillBackoffMax
+		}
+	}
+}
+
+// fillProgress 调用OnFill, 返回是否已达到target
+func (p *ChannelPool) fillProgress(attempt, target int, err error) bool {
+	p.mu.RLock()
+	open := int(p.acct.Open)
+	p.mu.RUnlock()
+	if p.onFill == nil {
+		return open >= target
+	}
+	p.onFill(FillEvent{
+		Time:    time.Now(),
+		Open:    open,
+		Target:  target,
+		Attempt: attempt,
+		Err:     err,
+		Done:    open >= target,
+	})
+	return open >= target
+}
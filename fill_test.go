@@ -0,0 +1,59 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_BestEffortFill(t *testing.T) {
+	dialErr := errors.New("backend down")
+	var mu sync.Mutex
+	failures := 2
+	flaky := func() (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			return nil, dialErr
+		}
+		return factory()
+	}
+
+	events := make(chan FillEvent, 10)
+	p, err := NewChannelPool(3, 5, flaky, WithInitialConns(2), WithBestEffortFill(func(e FillEvent) {
+		events <- e
+	}))
+	if err != nil {
+		t.Fatalf("NewChannelPool error: %s", err)
+	}
+	defer p.Close()
+
+	var got []FillEvent
+	timeout := time.After(time.Second * 2)
+	for len(got) == 0 || !got[len(got)-1].Done {
+		select {
+		case e := <-events:
+			got = append(got, e)
+		case <-timeout:
+			t.Fatalf("BestEffortFill error. Expecting Done event, got %+v", got)
+		}
+	}
+	if len(got) != 4 || got[0].Attempt != 0 || got[0].Err != dialErr || got[1].Err != dialErr || got[2].Err != nil {
+		t.Errorf("BestEffortFill error. Expecting 2 failures then 2 dials, got %+v", got)
+	}
+	if e := got[3]; e.Open != 2 || e.Target != 2 {
+		t.Errorf("BestEffortFill error. Expecting 2/2, got %d/%d", e.Open, e.Target)
+	}
+	if p.Len() != 2 {
+		t.Errorf("BestEffortFill error. Expecting %d idle, got %d", 2, p.Len())
+	}
+
+	// 没有设置时初始conn新建失败返回错误
+	failures = 1
+	if _, err := NewChannelPool(3, 5, flaky); err == nil {
+		t.Errorf("NewChannelPool error. Expecting fill error")
+	}
+}
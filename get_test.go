@@ -0,0 +1,230 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"ConnPool/poolcore"
+)
+
+// checkGetResult 检查 (conn == nil) == (err != nil)
+func checkGetResult(t *testing.T, conn net.Conn, err error) {
+	t.Helper()
+	if (conn == nil) != (err != nil) {
+		t.Errorf("Get error. Expecting (conn == nil) == (err != nil), got conn=%v err=%v", conn, err)
+	}
+}
+
+func TestChannelPool_GetInvariant(t *testing.T) {
+	errDial := errors.New("dial failed")
+	errCheck := errors.New("check failed")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		factory Factory
+		setup   func(p *ChannelPool)
+		ctx     func() (context.Context, context.CancelFunc)
+		want    error
+	}{
+		{
+			name: "idle",
+		},
+		{
+			name:  "dial",
+			setup: func(p *ChannelPool) { _, _ = p.Get() },
+		},
+		{
+			name: "dial error",
+			factory: func() func() (net.Conn, error) {
+				n := 0
+				return func() (net.Conn, error) {
+					if n++; n > 1 {
+						return nil, errDial
+					}
+					return factory()
+				}
+			}(),
+			setup: func(p *ChannelPool) { _, _ = p.Get() },
+			want:  errDial,
+		},
+		{
+			name: "factory returns nil conn",
+			factory: func() func() (net.Conn, error) {
+				n := 0
+				return func() (net.Conn, error) {
+					if n++; n > 1 {
+						return nil, nil
+					}
+					return factory()
+				}
+			}(),
+			setup: func(p *ChannelPool) { _, _ = p.Get() },
+			want:  ErrNilConn,
+		},
+		{
+			name: "canceled context",
+			ctx:  func() (context.Context, context.CancelFunc) { return canceled, func() {} },
+			want: ErrTimeOut,
+		},
+		{
+			name:  "wait deadline",
+			setup: func(p *ChannelPool) { _, _ = p.Get(); _, _ = p.Get() },
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), time.Millisecond*20)
+			},
+			want: ErrTimeOut,
+		},
+		{
+			name:  "closed",
+			setup: func(p *ChannelPool) { _ = p.Close() },
+			want:  ErrClosed,
+		},
+		{
+			name: "health check fails then dial fails",
+			factory: func() func() (net.Conn, error) {
+				n := 0
+				return func() (net.Conn, error) {
+					if n++; n > 1 {
+						return nil, errDial
+					}
+					return factory()
+				}
+			}(),
+			opts: []Option{WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+				return errCheck
+			})},
+			want: errDial,
+		},
+		{
+			name: "interceptor returns nil conn",
+			opts: []Option{WithGetInterceptor(func(ctx context.Context, next GetFunc) (net.Conn, error) {
+				return nil, nil
+			})},
+			want: ErrNilConn,
+		},
+		{
+			name: "interceptor returns conn with error",
+			opts: []Option{WithGetInterceptor(func(ctx context.Context, next GetFunc) (net.Conn, error) {
+				conn, _ := next(ctx)
+				return conn, errCheck
+			})},
+			want: errCheck,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := tt.factory
+			if f == nil {
+				f = factory
+			}
+			p, err := NewChannelPool(1, 2, f, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer p.Close()
+
+			if tt.setup != nil {
+				tt.setup(p)
+			}
+			ctx, cancel := context.Background(), func() {}
+			if tt.ctx != nil {
+				ctx, cancel = tt.ctx()
+			}
+			defer cancel()
+
+			conn, err := p.GetWitchContext(ctx)
+			checkGetResult(t, conn, err)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Get error. Expecting %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestChannelPool_GetRaceClose(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		p, _ := NewChannelPool(2, 4, factory)
+
+		var wg sync.WaitGroup
+		for j := 0; j < 16; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 10; k++ {
+					ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+					conn, err := p.GetWitchContext(ctx)
+					cancel()
+					checkGetResult(t, conn, err)
+					if err != nil {
+						continue
+					}
+					_ = p.Put(conn)
+				}
+			}()
+		}
+		time.Sleep(time.Millisecond * time.Duration(i))
+		_ = p.Close()
+		wg.Wait()
+
+		if p.OpenNum() != 0 {
+			t.Errorf("Close error. Expecting %d, got %d", 0, p.OpenNum())
+		}
+	}
+}
+
+func TestChannelPool_WeightedSemaphore(t *testing.T) {
+	// 两个pool共用3个单位, p1每个conn占2个
+	shared := poolcore.NewSemaphore(3)
+	p1, err := NewChannelPool(1, 0, factory, WithUnlimitedConns(), WithInitialConns(0), WithWeightedSemaphore(shared, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p1.Close()
+	p2, err := NewChannelPool(1, 0, factory, WithUnlimitedConns(), WithInitialConns(0), WithWeightedSemaphore(shared, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+
+	c1, err := p1.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	c2, err := p2.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	if _, err := p2.GetWitchContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get error. Expecting %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// p1放回后空闲conn不占用单位, p2可以取出
+	p1.Put(c1)
+	c3, err := p2.GetWitchContext(context.Background())
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if shared.Held() != 2 {
+		t.Errorf("Held error. Expecting %d, got %d", 2, shared.Held())
+	}
+	p2.Put(c2)
+	p2.Put(c3)
+	if shared.Held() != 0 {
+		t.Errorf("Held error. Expecting %d, got %d", 0, shared.Held())
+	}
+
+	if _, err := NewChannelPool(1, 1, factory, WithWeightedSemaphore(shared, 0)); err == nil {
+		t.Errorf("NewChannelPool error. Expecting invalid weighted semaphore")
+	}
+}
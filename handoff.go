@@ -0,0 +1,68 @@
+package pool
+
+import (
+	"net"
+)
+
+// handOff 有可以使用conn的等待者时把放回的conn留给等待者, 不放入空闲列表, 需持有p.mu;
+// 之后归还的容量单位唤醒队首的等待者, 等待者先取交接的conn, 未等待的调用方不能取走.
+// 等待者都要求conn没有协商的ALPN协议时按普通放回处理
+func (p *ChannelPool) handOff(conn net.Conn) bool {
+	m, ok := p.conns[conn]
+	if !ok || p.waiterCount() == 0 {
+		return false
+	}
+	// 交接的conn不多于可以使用它的等待者, 多出的按普通放回处理
+	waiters := p.waitersFor(m)
+	if waiters == 0 || p.handoff.Len() >= waiters || p.handoff.Len() >= p.waiterCount() {
+		return false
+	}
+	p.handoff.Push(conn)
+	m.handoff = true
+	p.handoffNum++
+	return true
+}
+
+// waitersFor 正在等待且可以使用conn的调用数, 需持有p.mu
+func (p *ChannelPool) waitersFor(m *connMeta) int {
+	n := 0
+	for proto, count := range p.waitProtos {
+		if proto == "" || m.speaks(proto) {
+			n += count
+		}
+	}
+	return n
+}
+
+// popHandoff 等待者取出交接给等待者的conn, proto同popIdle, 需持有p.mu
+func (p *ChannelPool) popHandoff(proto string) (net.Conn, bool) {
+	return p.popFrom(&p.handoff, proto)
+}
+
+// settleHandoff 把未被取走且没有等待者可以使用的交接conn转为空闲conn, 空闲已满时交给后台关闭, 需持有p.mu;
+// 等待者超时、放弃或要求其他ALPN协议时交接的conn可能没有等待者来取
+func (p *ChannelPool) settleHandoff() {
+	if p.handoff.Len() == 0 {
+		return
+	}
+	waiting := p.waiterCount() > 0
+	for _, conn := range p.handoff.Drain() {
+		m, ok := p.conns[conn]
+		if ok && waiting && p.waitersFor(m) > 0 {
+			p.handoff.Push(conn)
+			continue
+		}
+		if ok {
+			m.handoff = false
+		}
+		if !p.closed && int64(p.idle.Len()) < p.maxIdle {
+			p.idle.Push(conn)
+			continue
+		}
+		c := p.forget(conn, CloseReasonOverflow)
+		p.overflowNum++
+		if !p.enqueueClose(c) {
+			p.goBackground(func() { _ = p.closeConn(c) })
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_PutHandoff(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	a, _ := p.Get()
+	b, _ := p.Get()
+	idB, _ := p.ConnID(b)
+
+	got := make(chan uint64, 1)
+	go func() {
+		conn, err := p.Get()
+		if err != nil {
+			t.Error(err)
+			got <- 0
+			return
+		}
+		id, _ := p.ConnID(conn)
+		got <- id
+		_ = p.Put(conn)
+	}()
+	for p.Stats().Waiters == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := p.Put(b); err != nil {
+		t.Fatal(err)
+	}
+	if id := <-got; id != idB {
+		t.Errorf("Get error. Expecting conn #%d handed off, got #%d", idB, id)
+	}
+	_ = p.Put(a)
+
+	s := p.Stats()
+	if s.PutHandoffs != 1 {
+		t.Errorf("Stats error. Expecting %d handoffs, got %d", 1, s.PutHandoffs)
+	}
+	if s.Created != 2 || s.Overflows != 1 {
+		t.Errorf("Stats error. Expecting 2 created 1 overflow, got %d %d", s.Created, s.Overflows)
+	}
+}
+
+func TestChannelPool_HandoffSettle(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, _ := p.Get()
+	done := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err := p.GetWitchContext(ctx)
+		done <- err
+	}()
+	for p.Stats().Waiters == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 交接后容量未归还, 等待者超时, 交接的conn转为空闲conn
+	p.mu.Lock()
+	handed := p.handOff(rawConn(conn))
+	p.markIdle(rawConn(conn))
+	p.mu.Unlock()
+	if !handed {
+		t.Fatal("handOff error. Expecting conn handed off")
+	}
+	if err := <-done; err == nil {
+		t.Error("Get error. Expecting time out")
+	}
+	p.release()
+
+	if p.Len() != 1 {
+		t.Errorf("Len error. Expecting %d, got %d", 1, p.Len())
+	}
+	c, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := p.ConnID(c); id != 1 {
+		t.Errorf("Get error. Expecting conn #%d, got #%d", 1, id)
+	}
+}
+
+func TestChannelPool_HandoffProtocol(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	a, _ := p.Get()
+	b, _ := p.Get()
+	idA, _ := p.ConnID(a)
+	p.mu.Lock()
+	p.conns[rawConn(a)].tls = &TLSInfo{NegotiatedProtocol: "http/1.1"}
+	p.mu.Unlock()
+
+	type result struct {
+		id  uint64
+		err error
+	}
+	wait := func(proto string, waiters int) chan result {
+		got := make(chan result, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			conn, err := p.GetProtocol(ctx, proto)
+			if err != nil {
+				got <- result{err: err}
+				return
+			}
+			id, _ := p.ConnID(conn)
+			got <- result{id: id}
+			_ = p.Put(conn)
+		}()
+		for p.Stats().Waiters != waiters {
+			time.Sleep(time.Millisecond)
+		}
+		return got
+	}
+
+	// 队首等待h2, 之后的等待者要求http/1.1: 交接的conn留给后者, 不被前者丢在交接列表
+	h2 := wait("h2", 1)
+	h1 := wait("http/1.1", 2)
+	if err := p.Put(a); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-h2; !errors.Is(r.err, ErrProtocolMismatch) {
+		t.Errorf("GetProtocol error. Expecting %v, got %v", ErrProtocolMismatch, r.err)
+	}
+	if r := <-h1; r.err != nil || r.id != idA {
+		t.Errorf("GetProtocol error. Expecting conn #%d handed off, got #%d %v", idA, r.id, r.err)
+	}
+
+	// 没有等待者可以使用的conn直接放入空闲列表
+	a, _ = p.Get()
+	h2 = wait("h2", 1)
+	if err := p.Put(a); err != nil {
+		t.Fatal(err)
+	}
+	p.mu.RLock()
+	handoff := p.handoff.Len()
+	p.mu.RUnlock()
+	if handoff != 0 {
+		t.Errorf("Put error. Expecting unmatched conn not handed off, got %d", handoff)
+	}
+	if r := <-h2; !errors.Is(r.err, ErrProtocolMismatch) {
+		t.Errorf("GetProtocol error. Expecting %v, got %v", ErrProtocolMismatch, r.err)
+	}
+	_ = p.Put(b)
+	if s := p.Stats(); s.PutHandoffs != 1 {
+		t.Errorf("Stats error. Expecting %d handoffs, got %d", 1, s.PutHandoffs)
+	}
+	p.mu.RLock()
+	handoff = p.handoff.Len()
+	p.mu.RUnlock()
+	if handoff != 0 {
+		t.Errorf("settleHandoff error. Expecting no handoffs left, got %d", handoff)
+	}
+}
@@ -0,0 +1,61 @@
+package pool
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// HandshakeStage WithTLSClient进行TLS握手的时机
+type HandshakeStage int
+
+const (
+	HandshakeOnDial     HandshakeStage = iota // 新建conn时握手, 在OnCreate之前, 耗时计入新建
+	HandshakeOnCheckout                       // 新建时只建立TCP连接, 第一次取出时在调用方的ctx下握手, 耗时计入本次取出
+)
+
+func (s HandshakeStage) String() string {
+	switch s {
+	case HandshakeOnDial:
+		return "dial"
+	case HandshakeOnCheckout:
+		return "checkout"
+	default:
+		return "unknown"
+	}
+}
+
+// clientTLS 用WithTLSClient的配置包装factory创建的conn, 未设置时返回nil
+func (p *ChannelPool) clientTLS(raw net.Conn) *tls.Conn {
+	if p.tlsConfig == nil {
+		return nil
+	}
+	return tls.Client(raw, p.tlsConfig)
+}
+
+// handshakeOnCheckout 对第一次取出的conn完成延迟的TLS握手, 返回握手耗时; 已握手或不需要握手时返回0
+func (p *ChannelPool) handshakeOnCheckout(ctx context.Context, raw net.Conn) (time.Duration, error) {
+	p.mu.RLock()
+	m, ok := p.conns[raw]
+	pending := ok && m.pendingHandshake
+	p.mu.RUnlock()
+	if !pending {
+		return 0, nil
+	}
+
+	// conn已被本次调用方独占, 握手期间不会有其他调用者访问m.tlsConn
+	start := time.Now()
+	err := m.tlsConn.HandshakeContext(ctx)
+	d := time.Since(start)
+	if err != nil {
+		return d, err
+	}
+	p.mu.Lock()
+	m.pendingHandshake = false
+	m.handshakeDuration += d
+	m.tls = tlsInfoOf(m.tlsConn)
+	p.handshakeLatency.observe(d)
+	p.mu.Unlock()
+	return d, nil
+}
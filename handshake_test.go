@@ -0,0 +1,99 @@
+package pool
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_TLSClientOnCheckout(t *testing.T) {
+	srv, config := newTLSBackend(t)
+	defer srv.Close()
+
+	dial := func() (net.Conn, error) { return net.Dial("tcp", srv.Listener.Addr().String()) }
+	p, err := NewChannelPool(1, 2, dial, WithTLSClient(config, HandshakeOnCheckout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 预热只建立TCP连接
+	if stats := p.Stats(); stats.Open != 1 || len(stats.TLS) != 0 || stats.Handshake.Count != 0 {
+		t.Errorf("Stats error. Expecting 1 open conn without handshake, got %d %v %d", stats.Open, stats.TLS, stats.Handshake.Count)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	conn, err := p.GetWitchContext(ctx)
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc := conn.(*PoolConn)
+	if pc.Acquisition().HandshakeDuration <= 0 {
+		t.Errorf("Acquisition error. Expecting handshake duration on first checkout")
+	}
+	if _, ok := pc.TLS(); !ok {
+		t.Errorf("TLS error. Expecting info after handshake")
+	}
+	p.Put(pc)
+
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if d := conn.(*PoolConn).Acquisition().HandshakeDuration; d != 0 {
+		t.Errorf("Acquisition error. Expecting %d, got %s", 0, d)
+	}
+	p.Put(conn)
+	if stats := p.Stats(); stats.Handshake.Count != 1 {
+		t.Errorf("Stats error. Expecting %d, got %d", 1, stats.Handshake.Count)
+	}
+}
+
+func TestChannelPool_TLSClientHandshakeFailed(t *testing.T) {
+	srv, config := newTLSBackend(t)
+	defer srv.Close()
+	config.ServerName = "wrong.example"
+
+	dial := func() (net.Conn, error) { return net.Dial("tcp", srv.Listener.Addr().String()) }
+	p, err := NewChannelPool(1, 2, dial, WithTLSClient(config, HandshakeOnCheckout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Get(); err == nil {
+		t.Fatalf("Get error. Expecting handshake error")
+	}
+	if p.InUse() != 0 || p.OpenNum() != 0 {
+		t.Errorf("Get error. Expecting 0 in use 0 open, got %d %d", p.InUse(), p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonHandshakeFailed]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestChannelPool_TLSClientOnDial(t *testing.T) {
+	srv, config := newTLSBackend(t)
+	defer srv.Close()
+
+	dial := func() (net.Conn, error) { return net.Dial("tcp", srv.Listener.Addr().String()) }
+	p, err := NewChannelPool(1, 2, dial, WithTLSClient(config, HandshakeOnDial))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if stats := p.Stats(); len(stats.TLS) != 1 || stats.Handshake.Count != 1 {
+		t.Errorf("Stats error. Expecting handshake on dial, got %v %d", stats.TLS, stats.Handshake.Count)
+	}
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if d := conn.(*PoolConn).Acquisition().HandshakeDuration; d != 0 {
+		t.Errorf("Acquisition error. Expecting %d, got %s", 0, d)
+	}
+	p.Put(conn)
+}
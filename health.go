@@ -0,0 +1,255 @@
+package pool
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// HealthCheck 检查conn是否可用, 返回error表示conn不可用, ctx派生自调用方的ctx;
+// conn为WrapConn包装后的conn
+type HealthCheck func(ctx context.Context, conn net.Conn) error
+
+type checkResult struct {
+	conn net.Conn
+	err  error
+}
+
+// checksIdle 取出空闲conn时是否需要检查
+func (p *ChannelPool) checksIdle() bool {
+	return p.healthCheck != nil || p.keepAlive > 0 || p.auth != nil || p.liveness != nil
+}
+
+// check 检查底层conn, 设置了WithLivenessProbe或对TCP conn开启keepalive时先探测对端是否存活,
+// 凭据即将过期时重新认证, 再以独立的超时时间执行健康检查
+func (p *ChannelPool) check(ctx context.Context, conn net.Conn) error {
+	if _, ok := conn.(*net.TCPConn); p.liveness != nil || (ok && p.keepAlive > 0) {
+		if err := p.livenessProbe()(conn); err != nil {
+			return err
+		}
+	}
+	if p.auth != nil {
+		if err := p.refreshAuth(ctx, conn); err != nil {
+			return err
+		}
+	}
+	if p.healthCheck == nil {
+		return nil
+	}
+	if p.healthCheckTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.healthCheckTimeout)
+		defer cancel()
+	}
+	return p.healthCheck(ctx, p.userConn(conn))
+}
+
+// hedge 返回并行检查的延迟和最大并行数, 未配置时按检查超时时间推算
+func (p *ChannelPool) hedge() (time.Duration, int) {
+	if p.hedgeDelay > 0 {
+		if p.hedgeParallel < 1 {
+			return p.hedgeDelay, 1
+		}
+		return p.hedgeDelay, p.hedgeParallel
+	}
+	if p.healthCheckTimeout > 0 {
+		return p.healthCheckTimeout / 2, 2
+	}
+	return 0, 1
+}
+
+// validate 对取出的空闲conn做健康检查, 第一个conn检查较慢时并行检查其他空闲conn,
+// 返回最先通过检查的conn; 全部失败时使用释放出的名额新建conn
+func (p *ChannelPool) validate(ctx context.Context, first net.Conn) (net.Conn, error) {
+
+	delay, parallel := p.hedge()
+
+	results := make(chan checkResult, parallel)
+	inflight := 0
+	launch := func(conn net.Conn) {
+		inflight++
+		p.goBackground(func() {
+			results <- checkResult{conn: conn, err: p.check(ctx, conn)}
+		})
+	}
+	launch(first)
+
+	var hedgeC <-chan time.Time
+	if delay > 0 && parallel > 1 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		hedgeC = timer.C
+	}
+
+	// slot 表示持有一个失败conn留下的容量单位, 用于最后新建conn
+	slot := false
+	for inflight > 0 {
+		select {
+		case <-ctx.Done():
+			p.goBackground(func() { p.drainChecks(results, inflight, slot) })
+			return nil, timeoutErr(ctx)
+
+		case <-hedgeC:
+			hedgeC = nil
+			for inflight < parallel {
+				conn, ok := p.tryIdle(protocolOf(ctx))
+				if !ok {
+					break
+				}
+				launch(conn)
+			}
+
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				p.checkPassed(r.conn)
+				if slot {
+					p.release()
+				}
+				if inflight > 0 {
+					p.goBackground(func() { p.drainChecks(results, inflight, false) })
+				}
+				return r.conn, nil
+			}
+
+			p.mu.Lock()
+			p.traceConn(r.conn, ConnEventHealthFail, r.err.Error())
+			p.healthResult(r.conn, r.err)
+			c := p.forget(r.conn, checkFailReason(r.err))
+			p.mu.Unlock()
+			p.closeAsync(c)
+			p.observeDegrade(ctx, r.err)
+
+			// 用失败conn的容量单位换一个空闲conn继续检查
+			if conn, ok := p.takeIdle(protocolOf(ctx)); ok {
+				launch(conn)
+				continue
+			}
+			if slot {
+				p.release()
+			}
+			slot = true
+		}
+	}
+
+	// 没有可用的空闲conn, 使用留下的容量单位新建
+	conn, err := p.dial(ctx)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// checkPassed 记录conn通过健康检查, 解除其后端的隔离记录并重置连续失败
+func (p *ChannelPool) checkPassed(conn net.Conn) {
+	if p.healthCheck != nil || p.quarantine != nil {
+		p.mu.Lock()
+		if m, ok := p.conns[conn]; ok && p.healthCheck != nil {
+			m.validated = true
+		}
+		p.healthResult(conn, nil)
+		p.mu.Unlock()
+	}
+	p.observeDegrade(context.Background(), nil)
+}
+
+// drainChecks 处理调用者已经不再等待的检查结果, 通过的放回pool, 失败的关闭
+func (p *ChannelPool) drainChecks(results chan checkResult, inflight int, slot bool) {
+	if slot {
+		p.release()
+	}
+	for ; inflight > 0; inflight-- {
+		r := <-results
+		if r.err == nil {
+			p.checkPassed(r.conn)
+			_ = p.Put(r.conn)
+			continue
+		}
+		p.mu.Lock()
+		p.traceConn(r.conn, ConnEventHealthFail, r.err.Error())
+		p.healthResult(r.conn, r.err)
+		p.mu.Unlock()
+		p.discard(r.conn, checkFailReason(r.err))
+		p.observeDegrade(context.Background(), r.err)
+	}
+}
+
+// validateOnPut 开启WithHealthCheckOnPut时结束本次取出, 在后台检查放回的conn, 通过后放回pool, 失败时关闭;
+// 检查期间conn仍占用容量单位. 不需要检查时返回false, 由Put直接放回
+func (p *ChannelPool) validateOnPut(conn net.Conn) bool {
+	if !p.checksIdle() {
+		return false
+	}
+	p.mu.Lock()
+	m, ok := p.conns[conn]
+	// 半关闭或被标记不可用的conn在Put时关闭, 不需要检查; 重复放回由Put处理
+	if !ok || p.closed || m.idle || m.validating || m.halfClosed || m.unusable {
+		p.mu.Unlock()
+		return false
+	}
+	m.validating = true
+	p.recordCheckin(m)
+	p.poison(m)
+	p.mu.Unlock()
+
+	p.goBackground(func() {
+		if p.checkPut(conn) {
+			_ = p.put(conn, nil, true)
+		}
+	})
+	return true
+}
+
+// validatingCount 正在后台执行放回检查的conn数, 需持有p.mu
+func (p *ChannelPool) validatingCount() int {
+	if !p.checkOnPut {
+		return 0
+	}
+	n := 0
+	for _, m := range p.conns {
+		if m.validating {
+			n++
+		}
+	}
+	return n
+}
+
+// checkPut 检查放回的conn, 检查失败时关闭conn并返回false
+func (p *ChannelPool) checkPut(conn net.Conn) bool {
+	err := p.check(context.Background(), conn)
+	if err == nil {
+		p.checkPassed(conn)
+		return true
+	}
+	p.mu.Lock()
+	p.traceConn(conn, ConnEventHealthFail, err.Error())
+	p.healthResult(conn, err)
+	p.mu.Unlock()
+	p.discard(conn, checkFailReason(err))
+	p.observeDegrade(context.Background(), err)
+	return false
+}
+
+// tryIdle 不等待地获得一个容量单位并取出一个空闲conn, proto同popIdle
+func (p *ChannelPool) tryIdle(proto string) (net.Conn, bool) {
+	if !p.tryAcquire() {
+		return nil, false
+	}
+	conn, ok := p.takeIdle(proto)
+	if !ok {
+		p.release()
+	}
+	return conn, ok
+}
+
+// takeIdle 使用已持有的容量单位取出一个空闲conn, proto同popIdle
+func (p *ChannelPool) takeIdle(proto string) (net.Conn, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, false
+	}
+	return p.popIdle(proto)
+}
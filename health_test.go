@@ -0,0 +1,287 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_HealthCheck(t *testing.T) {
+	var checked int32
+	p, err := NewChannelPool(int64(maxFree), int64(maxConn), factory,
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			atomic.AddInt32(&checked, 1)
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Get(); err != nil {
+		t.Errorf("Get error: %s", err)
+	}
+	if atomic.LoadInt32(&checked) != 1 {
+		t.Errorf("HealthCheck error. Expecting %d, got %d",
+			1, atomic.LoadInt32(&checked))
+	}
+	if p.OpenNum() != maxFree {
+		t.Errorf("HealthCheck error. Expecting %d, got %d",
+			maxFree, p.OpenNum())
+	}
+}
+
+func TestChannelPool_HealthCheckFail(t *testing.T) {
+	var dials int32
+	p, err := NewChannelPool(int64(maxFree), int64(maxConn),
+		func() (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return factory()
+		},
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			return errors.New("broken")
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 所有空闲conn检查失败后新建conn
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if conn == nil {
+		t.Fatalf("Get error. Expecting conn, got nil")
+	}
+	if atomic.LoadInt32(&dials) != int32(maxFree+1) {
+		t.Errorf("HealthCheck error. Expecting %d, got %d",
+			maxFree+1, atomic.LoadInt32(&dials))
+	}
+	if p.Len() != 0 {
+		t.Errorf("HealthCheck error. Expecting %d, got %d",
+			0, p.Len())
+	}
+	if p.OpenNum() != 1 {
+		t.Errorf("HealthCheck error. Expecting %d, got %d",
+			1, p.OpenNum())
+	}
+}
+
+func TestChannelPool_HealthCheckHedge(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		slow net.Conn
+	)
+	p, err := NewChannelPool(int64(maxFree), int64(maxConn), factory,
+		WithHealthCheckTimeout(time.Millisecond*500),
+		WithHealthCheckHedge(time.Millisecond*20, 2),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			mu.Lock()
+			if slow == nil {
+				slow = conn
+			}
+			hung := slow == conn
+			mu.Unlock()
+
+			// 第一个conn的检查一直卡住
+			if hung {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	start := time.Now()
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*300 {
+		t.Errorf("Get error. Expecting hedged check, cost %s", cost)
+	}
+	if conn.(*PoolConn).RawConn() == slow {
+		t.Errorf("Get error. Expecting healthy conn, got the hung one")
+	}
+
+	// 卡住的conn检查超时后被关闭
+	time.Sleep(time.Millisecond * 700)
+	if p.OpenNum() != maxFree-1 {
+		t.Errorf("HealthCheck error. Expecting %d, got %d",
+			maxFree-1, p.OpenNum())
+	}
+}
+
+func TestChannelPool_HealthCheckCallerDeadline(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory,
+		WithHealthCheckTimeout(time.Second),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := p.GetWitchContext(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("Get error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*300 {
+		t.Errorf("Get error. Expecting return at caller deadline, cost %s", cost)
+	}
+}
+
+// waitValidated 等待放回检查全部结束
+func waitValidated(t *testing.T, p *ChannelPool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 2)
+	for p.Stats().Validating != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := p.Stats().Validating; n != 0 {
+		t.Fatalf("Validating error. Expecting %d, got %d", 0, n)
+	}
+}
+
+func TestChannelPool_HealthCheckOnPut(t *testing.T) {
+	var broken int32
+	p, err := NewChannelPool(1, 2, factory, WithInitialConns(0), WithHealthCheckOnPut(),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			if atomic.LoadInt32(&broken) == 1 {
+				return errors.New("broken")
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	waitValidated(t, p)
+	if p.Len() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d", 1, p.Len())
+	}
+
+	// 放回时检查失败的conn被关闭
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	atomic.StoreInt32(&broken, 1)
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	waitValidated(t, p)
+	if p.Len() != 0 || p.OpenNum() != 0 {
+		t.Errorf("Put error. Expecting no conns, got %d idle %d open", p.Len(), p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonHealthCheck]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestChannelPool_HealthCheckOnPutPeerClosed(t *testing.T) {
+	var peers []net.Conn
+	var mu sync.Mutex
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		client, server := net.Pipe()
+		mu.Lock()
+		peers = append(peers, server)
+		mu.Unlock()
+		return client, nil
+	}, WithInitialConns(0), WithHealthCheckOnPut(), WithLivenessProbe(ReadLiveness))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	// 使用期间对端关闭, 放回时不再进入pool
+	mu.Lock()
+	_ = peers[0].Close()
+	mu.Unlock()
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	waitValidated(t, p)
+	if p.Len() != 0 || p.OpenNum() != 0 {
+		t.Errorf("Put error. Expecting no conns, got %d idle %d open", p.Len(), p.OpenNum())
+	}
+}
+
+func TestChannelPool_HealthCheckOnPutAsync(t *testing.T) {
+	block := make(chan struct{})
+	var blocking int32
+	p, err := NewChannelPool(1, 1, factory, WithInitialConns(0), WithHealthCheckOnPut(),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			if atomic.LoadInt32(&blocking) == 1 {
+				<-block
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	atomic.StoreInt32(&blocking, 1)
+
+	// 检查阻塞时Put立即返回
+	start := time.Now()
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	if cost := time.Since(start); cost > time.Millisecond*50 {
+		t.Errorf("Put error. Expecting return without waiting for the check, cost %s", cost)
+	}
+	if s := p.Stats(); s.Validating != 1 || s.Idle != 0 || s.InUse != 1 {
+		t.Errorf("Stats error. Expecting 1 validating 0 idle 1 in use, got %d %d %d", s.Validating, s.Idle, s.InUse)
+	}
+	// 本次取出已结束
+	if err := conn.Close(); !errors.Is(err, ErrConnReturned) {
+		t.Errorf("Close error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if err := p.Put(conn); err != nil || p.Stats().Validating != 1 {
+		t.Errorf("Put error. Expecting double put ignored, got %v", err)
+	}
+
+	// 检查期间conn不能被取出, 通过后放回pool
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+	if _, err := p.GetWitchContext(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("Get error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	cancel()
+	atomic.StoreInt32(&blocking, 0)
+	close(block)
+	waitValidated(t, p)
+	if s := p.Stats(); s.Idle != 1 || s.Open != 1 {
+		t.Errorf("Stats error. Expecting 1 idle 1 open, got %d %d", s.Idle, s.Open)
+	}
+}
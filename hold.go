@@ -0,0 +1,129 @@
+package pool
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// HoldRecord 一次取出的持有情况
+type HoldRecord struct {
+	ConnID uint64
+
+	Holder string // 取出方 file:line
+
+	Start time.Time // 取出时间
+
+	Duration time.Duration // 持有时间, 未放回时为已持有的时间
+
+	Stack string // 取出时的调用栈, 需开启stacks
+}
+
+// HoldProfile 采样到的取出的持有时间
+type HoldProfile struct {
+	Holds LatencySummary // 已放回的取出的持有时间
+
+	Longest []HoldRecord // 已放回的持有时间最长的取出, 按持有时间降序
+
+	Current []HoldRecord // 未放回的取出, 按已持有时间降序
+}
+
+// holdProfiler 持有时间采样配置和结果
+type holdProfiler struct {
+	rate float64 // 采样比例
+
+	topK int // 保留最长的取出数
+
+	stacks bool // 是否记录调用栈
+
+	holds latencyWindow
+
+	longest []HoldRecord
+}
+
+// startHold 按采样比例开始记录一次取出, 需持有p.mu
+func (p *ChannelPool) startHold(m *connMeta, holder string) {
+	h := p.holdProfiler
+	if h == nil || m == nil || rand.Float64() >= h.rate {
+		return
+	}
+	rec := &HoldRecord{ConnID: m.id, Holder: holder, Start: time.Now()}
+	if h.stacks {
+		buf := make([]byte, 4096)
+		rec.Stack = string(buf[:runtime.Stack(buf, false)])
+	}
+	m.hold = rec
+}
+
+// endHold 结束记录conn的本次取出, 需持有p.mu
+func (p *ChannelPool) endHold(m *connMeta) {
+	h := p.holdProfiler
+	if h == nil || m.hold == nil {
+		return
+	}
+	rec := *m.hold
+	m.hold = nil
+	rec.Duration = time.Since(rec.Start)
+	h.holds.observe(rec.Duration)
+
+	// 插入降序的最长列表
+	i := sort.Search(len(h.longest), func(i int) bool { return h.longest[i].Duration < rec.Duration })
+	if i >= h.topK {
+		return
+	}
+	if len(h.longest) < h.topK {
+		h.longest = append(h.longest, HoldRecord{})
+	}
+	copy(h.longest[i+1:], h.longest[i:])
+	h.longest[i] = rec
+}
+
+// HoldProfile 返回采样到的持有时间, 需通过WithHoldProfile开启
+func (p *ChannelPool) HoldProfile() HoldProfile {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	h := p.holdProfiler
+	if h == nil {
+		return HoldProfile{}
+	}
+	now := time.Now()
+	var current []HoldRecord
+	for _, m := range p.conns {
+		if m.hold != nil {
+			rec := *m.hold
+			rec.Duration = now.Sub(rec.Start)
+			current = append(current, rec)
+		}
+	}
+	sort.Slice(current, func(i, j int) bool { return current[i].Duration > current[j].Duration })
+	if len(current) > h.topK {
+		current = current[:h.topK]
+	}
+	return HoldProfile{
+		Holds:   h.holds.summary(),
+		Longest: append([]HoldRecord(nil), h.longest...),
+		Current: current,
+	}
+}
+
+// writeHoldProfile 输出持有时间, 用于DebugHandler
+func writeHoldProfile(w io.Writer, hp HoldProfile) {
+	s := hp.Holds
+	fmt.Fprintf(w, "holds: count=%d p50=%s p90=%s p99=%s max=%s\n", s.Count, s.P50, s.P90, s.P99, s.Max)
+	write := func(title string, recs []HoldRecord) {
+		fmt.Fprintf(w, "%s:\n", title)
+		for _, r := range recs {
+			fmt.Fprintf(w, "  conn %d held %s by %s since %s\n",
+				r.ConnID, r.Duration.Round(time.Microsecond), r.Holder, r.Start.Format(time.RFC3339Nano))
+			if r.Stack != "" {
+				fmt.Fprintf(w, "%s\n", r.Stack)
+			}
+		}
+	}
+	write("current", hp.Current)
+	write("longest", hp.Longest)
+}
@@ -0,0 +1,80 @@
+package pool
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_HoldProfile(t *testing.T) {
+	p, err := NewChannelPool(3, 3, factory, WithHoldProfile(1, 2, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for _, hold := range []time.Duration{10, 30, 20} {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		time.Sleep(hold * time.Millisecond)
+		p.Put(conn)
+	}
+	held, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	hp := p.HoldProfile()
+	if hp.Holds.Count != 3 {
+		t.Errorf("HoldProfile error. Expecting %d, got %d", 3, hp.Holds.Count)
+	}
+	if len(hp.Longest) != 2 {
+		t.Fatalf("HoldProfile error. Expecting %d, got %d", 2, len(hp.Longest))
+	}
+	if hp.Longest[0].Duration < 30*time.Millisecond || hp.Longest[1].Duration < 20*time.Millisecond ||
+		hp.Longest[1].Duration >= hp.Longest[0].Duration {
+		t.Errorf("HoldProfile error. Expecting descending 30ms, 20ms, got %s, %s", hp.Longest[0].Duration, hp.Longest[1].Duration)
+	}
+	if !strings.HasPrefix(hp.Longest[0].Holder, "hold_test.go:") {
+		t.Errorf("HoldProfile error. Expecting holder, got %q", hp.Longest[0].Holder)
+	}
+	if !strings.Contains(hp.Longest[0].Stack, "TestChannelPool_HoldProfile") {
+		t.Errorf("HoldProfile error. Expecting stack, got %q", hp.Longest[0].Stack)
+	}
+	if len(hp.Current) != 1 {
+		t.Errorf("HoldProfile error. Expecting %d current, got %d", 1, len(hp.Current))
+	}
+
+	rec := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/?holds", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, "holds: count=3") || !strings.Contains(body, "current:") {
+		t.Errorf("DebugHandler error. Expecting hold profile, got %s", body)
+	}
+
+	// 丢弃的conn同样结束记录
+	p.Discard(held)
+	if hp := p.HoldProfile(); hp.Holds.Count != 4 || len(hp.Current) != 0 {
+		t.Errorf("HoldProfile error. Expecting %d holds %d current, got %d %d", 4, 0, hp.Holds.Count, len(hp.Current))
+	}
+}
+
+func TestChannelPool_HoldProfileSampling(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory, WithHoldProfile(0, 2, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if hp := p.HoldProfile(); hp.Holds.Count != 0 {
+		t.Errorf("HoldProfile error. Expecting %d, got %d", 0, hp.Holds.Count)
+	}
+}
@@ -0,0 +1,29 @@
+package pool
+
+import (
+	"context"
+	"net"
+)
+
+// OnCreate 新建conn后调用, ctx为触发新建的调用方的ctx, 返回error时conn被关闭且本次获取失败
+type OnCreate func(ctx context.Context, conn net.Conn) error
+
+// OnFull Put因空闲已满关闭conn时调用, overflows为累计次数; 频繁调用说明maxFree过小, pool在不断新建和关闭conn
+type OnFull func(overflows int64)
+
+// GetFunc 获取conn的函数
+type GetFunc func(ctx context.Context) (net.Conn, error)
+
+// GetInterceptor 包装每一次获取conn, 可读取调用方ctx中的值或替换传给next的ctx
+type GetInterceptor func(ctx context.Context, next GetFunc) (net.Conn, error)
+
+// chainInterceptors 把多个拦截器串成一个, 先注册的在最外层
+func chainInterceptors(interceptors []GetInterceptor, get GetFunc) GetFunc {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], get
+		get = func(ctx context.Context) (net.Conn, error) {
+			return interceptor(ctx, next)
+		}
+	}
+	return get
+}
@@ -0,0 +1,90 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+type traceKey struct{}
+
+func TestChannelPool_HookContext(t *testing.T) {
+	var checked, created, order []string
+	p, err := NewChannelPool(1, 2, factory,
+		WithGetInterceptor(func(ctx context.Context, next GetFunc) (net.Conn, error) {
+			order = append(order, "outer")
+			return next(context.WithValue(ctx, traceKey{}, "trace-1"))
+		}),
+		WithGetInterceptor(func(ctx context.Context, next GetFunc) (net.Conn, error) {
+			order = append(order, "inner:"+ctx.Value(traceKey{}).(string))
+			return next(ctx)
+		}),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			if v, ok := ctx.Value(traceKey{}).(string); ok {
+				checked = append(checked, v)
+			}
+			return nil
+		}),
+		WithOnCreate(func(ctx context.Context, conn net.Conn) error {
+			v, _ := ctx.Value(traceKey{}).(string)
+			created = append(created, v)
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 初始化时没有调用方ctx
+	if len(created) != 1 || created[0] != "" {
+		t.Errorf("OnCreate error. Expecting [\"\"], got %q", created)
+	}
+
+	// 取空闲conn, 触发健康检查
+	if _, err := p.Get(); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	// 新建conn, 触发OnCreate
+	if _, err := p.Get(); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	if len(order) != 4 || order[0] != "outer" || order[1] != "inner:trace-1" {
+		t.Errorf("Interceptor error. Expecting outer first, got %q", order)
+	}
+	if len(checked) != 1 || checked[0] != "trace-1" {
+		t.Errorf("HealthCheck error. Expecting [trace-1], got %q", checked)
+	}
+	if len(created) != 2 || created[1] != "trace-1" {
+		t.Errorf("OnCreate error. Expecting trace-1, got %q", created)
+	}
+}
+
+func TestChannelPool_OnCreateError(t *testing.T) {
+	errInit := errors.New("init failed")
+	fail := false
+	p, err := NewChannelPool(1, 2, factory,
+		WithOnCreate(func(ctx context.Context, conn net.Conn) error {
+			if fail {
+				return errInit
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Get(); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	fail = true
+	if _, err := p.Get(); err != errInit {
+		t.Errorf("Get error. Expecting %v, got %v", errInit, err)
+	}
+	if p.OpenNum() != 1 {
+		t.Errorf("OnCreate error. Expecting %d, got %d", 1, p.OpenNum())
+	}
+}
@@ -0,0 +1,90 @@
+package pool
+
+import (
+	"net"
+	"sort"
+	"time"
+)
+
+// ConnInfo RangeInUse、RangeIdle 遍历时conn的状态, 是采集时的拷贝
+type ConnInfo struct {
+	ID uint64
+
+	CreatedAt time.Time
+
+	Age time.Duration // 采集时已创建的时长
+
+	Uses int64 // 被取出的次数
+
+	Backend string // 对端地址
+
+	Holder string // 取出方, 见WithHolder; 空闲conn为空
+
+	CheckedOutAt time.Time // 最近一次取出的时间
+
+	Operation string // 最近一次取出的操作名, 见WithOperation
+
+	IdleSince time.Time // 最近一次放回的时间, 只对空闲conn有意义
+
+	Pinned bool // 通过GetPinned取出
+}
+
+// RangeInUse 按ID顺序遍历当前被取出的conn, f返回false时停止; 先在锁内复制状态再在锁外调用f,
+// 遍历不阻塞Get/Put, f看到的是调用RangeInUse时的状态
+func (p *ChannelPool) RangeInUse(f func(info ConnInfo) bool) {
+	now := time.Now()
+	p.mu.RLock()
+	infos := make([]ConnInfo, 0, p.inUse())
+	for _, m := range p.conns {
+		if m.idle {
+			continue
+		}
+		infos = append(infos, m.info(now))
+	}
+	p.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	rangeInfos(infos, f)
+}
+
+// RangeIdle 按放回顺序(最早放回的在前, 也是下一个被取出的)遍历空闲conn, f返回false时停止;
+// 同RangeInUse在锁外调用f, 只用于查看, 遍历期间conn可能已被取出; 需要关闭某个conn时使用CloseConn
+func (p *ChannelPool) RangeIdle(f func(info ConnInfo) bool) {
+	now := time.Now()
+	p.mu.RLock()
+	infos := make([]ConnInfo, 0, p.idle.Len())
+	p.idle.Range(func(conn net.Conn) bool {
+		if m, ok := p.conns[conn]; ok {
+			infos = append(infos, m.info(now))
+		}
+		return true
+	})
+	p.mu.RUnlock()
+
+	rangeInfos(infos, f)
+}
+
+func rangeInfos(infos []ConnInfo, f func(info ConnInfo) bool) {
+	for _, info := range infos {
+		if !f(info) {
+			return
+		}
+	}
+}
+
+// info 采集conn的状态, 需持有p.mu
+func (m *connMeta) info(now time.Time) ConnInfo {
+	info := ConnInfo{
+		ID:           m.id,
+		CreatedAt:    m.createdAt,
+		Age:          now.Sub(m.createdAt),
+		Uses:         m.uses,
+		Holder:       m.holder,
+		CheckedOutAt: m.acquired.Time,
+		Operation:    m.acquired.Operation,
+		IdleSince:    m.idleSince,
+		Pinned:       m.pinned,
+		Backend:      m.backend,
+	}
+	return info
+}
@@ -0,0 +1,106 @@
+package pool
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChannelPool_RangeInUse(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c1, err := p.GetWitchContext(WithHolder(context.Background(), "req-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var infos []ConnInfo
+	p.RangeInUse(func(info ConnInfo) bool {
+		infos = append(infos, info)
+		return true
+	})
+	if len(infos) != 2 || infos[0].ID >= infos[1].ID {
+		t.Fatalf("RangeInUse error. Expecting 2 conns sorted by ID, got %+v", infos)
+	}
+	id1, _ := p.ConnID(c1)
+	for _, info := range infos {
+		if info.ID == id1 && info.Holder != "req-1" {
+			t.Errorf("RangeInUse error. Expecting holder %q, got %q", "req-1", info.Holder)
+		}
+		if info.CheckedOutAt.IsZero() || info.Age <= 0 {
+			t.Errorf("RangeInUse error. Expecting checkout time and age, got %+v", info)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/?inuse", nil))
+	if body := rec.Body.String(); strings.Count(body, "conn #") != 2 || !strings.Contains(body, `holder="req-1"`) {
+		t.Errorf("DebugHandler error. Expecting 2 conns in use, got %s", body)
+	}
+
+	n := 0
+	p.RangeInUse(func(ConnInfo) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("RangeInUse error. Expecting to stop after %d, got %d", 1, n)
+	}
+
+	// 放回后不再遍历, 取出方被清除
+	p.Put(c1)
+	p.Put(c2)
+	p.RangeInUse(func(info ConnInfo) bool {
+		t.Errorf("RangeInUse error. Expecting no conns in use, got %+v", info)
+		return true
+	})
+}
+
+func TestChannelPool_RangeIdle(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c1, _ := p.Get()
+	c2, _ := p.Get()
+	p.Put(c2)
+	p.Put(c1)
+	c1, _ = p.Get() // 取出最早放回的c2
+	p.Put(c1)
+
+	var infos []ConnInfo
+	p.RangeIdle(func(info ConnInfo) bool {
+		infos = append(infos, info)
+		return true
+	})
+	// 按放回顺序
+	id1, _ := p.ConnID(c1)
+	if len(infos) != 2 || infos[1].ID != id1 {
+		t.Fatalf("RangeIdle error. Expecting 2 conns with #%d last, got %+v", id1, infos)
+	}
+	if infos[0].Uses != 1 || infos[1].Uses != 2 {
+		t.Errorf("RangeIdle error. Expecting uses 1/2, got %d/%d", infos[0].Uses, infos[1].Uses)
+	}
+	for _, info := range infos {
+		if info.Backend != address || info.Holder != "" || info.IdleSince.IsZero() {
+			t.Errorf("RangeIdle error. Expecting idle conn to %s, got %+v", address, info)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/?idle", nil))
+	if body := rec.Body.String(); strings.Count(body, "conn #") != 2 || !strings.Contains(body, "uses=2") {
+		t.Errorf("DebugHandler error. Expecting 2 idle conns, got %s", body)
+	}
+}
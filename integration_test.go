@@ -0,0 +1,462 @@
+//go:build integration
+
+package pool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// echoBackend 持续回显收到数据的后端
+func echoBackend(t *testing.T) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return ln
+}
+
+// flakyProxy 转发到后端, 可注入延迟、带宽限制和连接重置
+type flakyProxy struct {
+	ln     net.Listener
+	addr   string
+	target string
+
+	mu          sync.Mutex
+	latency     time.Duration // 每次转发前的延迟
+	bytesPerSec int           // 带宽限制, <= 0 不限制
+	conns       map[net.Conn]struct{}
+}
+
+func newFlakyProxy(t *testing.T, target string) *flakyProxy {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &flakyProxy{ln: ln, addr: ln.Addr().String(), target: target, conns: make(map[net.Conn]struct{})}
+	go p.serve(ln)
+	return p
+}
+
+func (p *flakyProxy) serve(ln net.Listener) {
+	for {
+		client, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		backend, err := net.Dial("tcp", p.target)
+		if err != nil {
+			client.Close()
+			continue
+		}
+		p.mu.Lock()
+		p.conns[client] = struct{}{}
+		p.conns[backend] = struct{}{}
+		p.mu.Unlock()
+		go p.pipe(client, backend)
+		go p.pipe(backend, client)
+	}
+}
+
+// pipe 按当前的延迟和带宽转发数据
+func (p *flakyProxy) pipe(dst, src net.Conn) {
+	defer func() {
+		p.mu.Lock()
+		delete(p.conns, dst)
+		delete(p.conns, src)
+		p.mu.Unlock()
+		dst.Close()
+		src.Close()
+	}()
+
+	buf := make([]byte, 1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			p.mu.Lock()
+			latency, bps := p.latency, p.bytesPerSec
+			p.mu.Unlock()
+			delay := latency
+			if bps > 0 {
+				delay += time.Duration(n) * time.Second / time.Duration(bps)
+			}
+			time.Sleep(delay)
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// waitConns 等待转发的连接数达到n, 包括到后端的连接
+func (p *flakyProxy) waitConns(t *testing.T, n int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		got := len(p.conns)
+		p.mu.Unlock()
+		if got >= n {
+			return
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+	t.Fatalf("proxy error. Expecting %d conns", n)
+}
+
+func (p *flakyProxy) Addr() string {
+	return p.addr
+}
+
+func (p *flakyProxy) SetLatency(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = d
+}
+
+func (p *flakyProxy) SetBandwidth(bytesPerSec int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bytesPerSec = bytesPerSec
+}
+
+// ResetAll 重置所有正在转发的连接
+func (p *flakyProxy) ResetAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for c := range p.conns {
+		if tc, ok := c.(*net.TCPConn); ok {
+			tc.SetLinger(0)
+		}
+		c.Close()
+	}
+}
+
+// Down 停止监听并重置所有连接, 之后的新建被拒绝
+func (p *flakyProxy) Down() {
+	p.mu.Lock()
+	ln := p.ln
+	p.mu.Unlock()
+	ln.Close()
+	p.ResetAll()
+}
+
+// Up 在原地址重新监听
+func (p *flakyProxy) Up(t *testing.T) {
+	ln, err := net.Listen("tcp", p.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.ln = ln
+	p.mu.Unlock()
+	go p.serve(ln)
+}
+
+func (p *flakyProxy) Close() {
+	p.Down()
+}
+
+// echoCheck 发送一行并等待回显, 用作健康检查
+func echoCheck(ctx context.Context, conn net.Conn) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	if _, err := conn.Write([]byte("ping\n")); err != nil {
+		return err
+	}
+	buf := make([]byte, 5)
+	_, err := io.ReadFull(conn, buf)
+	return err
+}
+
+func newProxyPool(t *testing.T, proxy *flakyProxy, maxFree, maxConn int64, opts ...Option) *ChannelPool {
+	p, err := NewChannelPool(maxFree, maxConn, TCPFactory("tcp", proxy.Addr(), WithDialTimeout(time.Second)), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestIntegration_ResetDetectedByHealthCheck(t *testing.T) {
+	backend := echoBackend(t)
+	defer backend.Close()
+	proxy := newFlakyProxy(t, backend.Addr().String())
+	defer proxy.Close()
+
+	p := newProxyPool(t, proxy, 3, 3,
+		WithHealthCheck(echoCheck),
+		WithHealthCheckTimeout(time.Millisecond*200))
+	defer p.Close()
+
+	proxy.waitConns(t, 6)
+	proxy.ResetAll()
+	time.Sleep(time.Millisecond * 50)
+
+	err := p.Do(context.Background(), func(conn net.Conn) error {
+		return echoCheck(context.Background(), conn)
+	})
+	if err != nil {
+		t.Fatalf("Do error: %s", err)
+	}
+	if h := p.ConnAgeStats()[CloseReasonHealthCheck]; h.Count != 3 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 3, h.Count)
+	}
+	if p.OpenNum() != 1 {
+		t.Errorf("Do error. Expecting %d, got %d", 1, p.OpenNum())
+	}
+}
+
+func TestIntegration_ResetDetectedByKeepAlive(t *testing.T) {
+	backend := echoBackend(t)
+	defer backend.Close()
+	proxy := newFlakyProxy(t, backend.Addr().String())
+	defer proxy.Close()
+
+	p := newProxyPool(t, proxy, 2, 2, WithTCPKeepAlive(time.Second))
+	defer p.Close()
+
+	proxy.waitConns(t, 4)
+	proxy.ResetAll()
+	time.Sleep(time.Millisecond * 50)
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if id, _ := p.ConnID(conn); id != 3 {
+		t.Errorf("Get error. Expecting new conn %d, got %d", 3, id)
+	}
+	if err := echoCheck(context.Background(), conn); err != nil {
+		t.Errorf("echo error: %s", err)
+	}
+	p.Put(conn)
+}
+
+func TestIntegration_LatencyDiscardsTimedOutConn(t *testing.T) {
+	backend := echoBackend(t)
+	defer backend.Close()
+	proxy := newFlakyProxy(t, backend.Addr().String())
+	defer proxy.Close()
+
+	p := newProxyPool(t, proxy, 1, 1)
+	defer p.Close()
+
+	proxy.SetLatency(time.Millisecond * 200)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err := p.Do(ctx, func(conn net.Conn) error {
+		return echoCheck(ctx, conn)
+	})
+	if err == nil {
+		t.Fatalf("Do error. Expecting timeout")
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+	if p.InUse() != 0 {
+		t.Errorf("Do error. Expecting %d, got %d", 0, p.InUse())
+	}
+
+	// 延迟恢复后新建conn正常工作
+	proxy.SetLatency(0)
+	err = p.Do(context.Background(), func(conn net.Conn) error {
+		return echoCheck(context.Background(), conn)
+	})
+	if err != nil {
+		t.Errorf("Do error: %s", err)
+	}
+}
+
+func TestIntegration_BandwidthQueuesCallers(t *testing.T) {
+	backend := echoBackend(t)
+	defer backend.Close()
+	proxy := newFlakyProxy(t, backend.Addr().String())
+	defer proxy.Close()
+
+	p := newProxyPool(t, proxy, 2, 2)
+	defer p.Close()
+
+	proxy.SetBandwidth(32 << 10)
+	payload := make([]byte, 4<<10)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := p.Do(context.Background(), func(conn net.Conn) error {
+				if _, err := conn.Write(payload); err != nil {
+					return err
+				}
+				_, err := io.ReadFull(bufio.NewReader(conn), make([]byte, len(payload)))
+				return err
+			})
+			if err != nil {
+				t.Errorf("Do error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 每个请求传输约125ms, 两个conn处理四个请求
+	if cost := time.Since(start); cost < time.Millisecond*250 {
+		t.Errorf("Do error. Expecting bandwidth limited, cost %s", cost)
+	}
+	s := p.Stats()
+	if s.Waits == 0 {
+		t.Errorf("Stats error. Expecting callers to wait")
+	}
+	if s.Open != 2 || s.InUse != 0 {
+		t.Errorf("Stats error. Expecting %d open %d inUse, got %d %d", 2, 0, s.Open, s.InUse)
+	}
+}
+
+func TestIntegration_RetryOnReset(t *testing.T) {
+	backend := echoBackend(t)
+	defer backend.Close()
+	proxy := newFlakyProxy(t, backend.Addr().String())
+	defer proxy.Close()
+
+	p := newProxyPool(t, proxy, 3, 3)
+	defer p.Close()
+
+	proxy.waitConns(t, 6)
+	proxy.ResetAll()
+	time.Sleep(time.Millisecond * 50)
+
+	// 未标记WithIdempotent时不重试
+	echo := func(conn net.Conn) error {
+		return echoCheck(context.Background(), conn)
+	}
+	if err := p.Do(context.Background(), echo, WithMaxAttempts(5)); err == nil {
+		t.Fatal("Do error. Expecting reset conn to fail without retry")
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+
+	// 重试时关闭被重置的conn, 换用新建的conn后成功
+	attempts := 0
+	err := p.Do(context.Background(), func(conn net.Conn) error {
+		attempts++
+		return echo(conn)
+	}, WithIdempotent(), WithMaxAttempts(5))
+	if err != nil {
+		t.Fatalf("Do error: %s", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Do error. Expecting %d attempts, got %d", 3, attempts)
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 3 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 3, h.Count)
+	}
+
+	// 尝试次数用完时返回最后一次的错误
+	proxy.waitConns(t, 2)
+	proxy.ResetAll()
+	time.Sleep(time.Millisecond * 50)
+	if err := p.Do(context.Background(), echo, WithIdempotent(), WithMaxAttempts(1)); err == nil {
+		t.Error("Do error. Expecting error after attempts exhausted")
+	}
+}
+
+func TestIntegration_CircuitBreaking(t *testing.T) {
+	backend := echoBackend(t)
+	defer backend.Close()
+	proxy := newFlakyProxy(t, backend.Addr().String())
+	defer proxy.Close()
+
+	var dials int32
+	factory := func(ctx context.Context) (net.Conn, error) {
+		atomic.AddInt32(&dials, 1)
+		// 放慢新建, 让并发的新建在探测期间到达
+		time.Sleep(time.Millisecond * 100)
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", proxy.Addr())
+	}
+	var mu sync.Mutex
+	var events []DegradeEvent
+	p, err := NewChannelPoolContext(1, 4, factory, WithInitialConns(0),
+		WithDialProbe(ProbeFailFast),
+		WithDegradeThreshold(2, func(e DegradeEvent) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 后端不可用, 连续失败达到阈值后进入降级
+	proxy.Down()
+	for i := 0; i < 2; i++ {
+		if _, err := p.Get(); err == nil {
+			t.Fatal("Get error. Expecting dial to fail")
+		}
+	}
+	if !p.Degraded() {
+		t.Error("Degraded error. Expecting degraded after 2 failures")
+	}
+
+	// 同一时间只有一个探测新建, 其他的直接失败
+	atomic.StoreInt32(&dials, 0)
+	var wg sync.WaitGroup
+	var unavailable int32
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Get(); errors.Is(err, ErrBackendUnavailable) {
+				atomic.AddInt32(&unavailable, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&dials); n != 1 {
+		t.Errorf("DialProbe error. Expecting %d dial, got %d", 1, n)
+	}
+	if n := atomic.LoadInt32(&unavailable); n != 3 {
+		t.Errorf("DialProbe error. Expecting %d fail fast, got %d", 3, n)
+	}
+
+	// 后端恢复后探测成功, 退出降级
+	proxy.Up(t)
+	err = p.Do(context.Background(), func(conn net.Conn) error {
+		return echoCheck(context.Background(), conn)
+	})
+	if err != nil {
+		t.Fatalf("Do error: %s", err)
+	}
+	if p.Degraded() {
+		t.Error("Degraded error. Expecting recovered")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || !events[0].Degraded || events[0].Failures != 2 || events[1].Degraded {
+		t.Errorf("OnDegrade error. Expecting degrade then recover, got %+v", events)
+	}
+}
@@ -0,0 +1,49 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+var (
+	ErrUnexpectedRead = errors.New("unexpected data on idle connection")
+)
+
+// 探测空闲conn时最多等待的时间
+const probeTimeout = time.Millisecond
+
+// setKeepAlive 对新建的TCP conn开启keepalive
+func (p *ChannelPool) setKeepAlive(conn net.Conn) error {
+	tc, ok := conn.(*net.TCPConn)
+	if !ok || p.keepAlive <= 0 {
+		return nil
+	}
+	if err := tc.SetKeepAlive(true); err != nil {
+		return err
+	}
+	return tc.SetKeepAlivePeriod(p.keepAlive)
+}
+
+// probeAlive 短暂读取空闲的TCP conn, 发现keepalive报告的错误或对端已关闭;
+// 空闲conn上不应有未读数据, 读到数据同样视为不可用
+func probeAlive(conn net.Conn) error {
+	if err := conn.SetReadDeadline(time.Now().Add(probeTimeout)); err != nil {
+		return err
+	}
+	var b [1]byte
+	_, err := conn.Read(b[:])
+	if derr := conn.SetReadDeadline(time.Time{}); derr != nil {
+		return derr
+	}
+
+	var ne net.Error
+	switch {
+	case err == nil:
+		return ErrUnexpectedRead
+	case errors.As(err, &ne) && ne.Timeout():
+		return nil
+	default:
+		return err
+	}
+}
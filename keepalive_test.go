@@ -0,0 +1,122 @@
+package pool
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// closingServer 接受连接并在closeAll时从服务端关闭所有连接
+type closingServer struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newClosingServer(t *testing.T) *closingServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &closingServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+		}
+	}()
+	return s
+}
+
+func (s *closingServer) dial() (net.Conn, error) {
+	return net.Dial("tcp", s.ln.Addr().String())
+}
+
+func (s *closingServer) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.conns {
+		c.Close()
+	}
+	s.conns = nil
+}
+
+func (s *closingServer) Close() {
+	s.ln.Close()
+	s.closeAll()
+}
+
+func TestChannelPool_TCPKeepAlive(t *testing.T) {
+	s := newClosingServer(t)
+	defer s.Close()
+
+	p, err := NewChannelPool(1, 2, s.dial, WithTCPKeepAlive(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 对端正常时复用空闲conn
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	first := conn.(*PoolConn).RawConn()
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if conn.(*PoolConn).RawConn() != first {
+		t.Errorf("Get error. Expecting the idle conn reused")
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+
+	// 对端关闭后空闲conn在取出时被发现并替换
+	s.closeAll()
+	time.Sleep(time.Millisecond * 50)
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if conn.(*PoolConn).RawConn() == first {
+		t.Errorf("Get error. Expecting the dead conn replaced")
+	}
+	if h := p.ConnAgeStats()[CloseReasonHealthCheck]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProbeAlive(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	if err := probeAlive(client); err != nil {
+		t.Errorf("probeAlive error. Expecting nil, got %v", err)
+	}
+
+	go server.Write([]byte("x"))
+	time.Sleep(time.Millisecond * 10)
+	if err := probeAlive(client); err != ErrUnexpectedRead {
+		t.Errorf("probeAlive error. Expecting %v, got %v", ErrUnexpectedRead, err)
+	}
+
+	server.Close()
+	if err := probeAlive(client); err == nil {
+		t.Errorf("probeAlive error. Expecting error after peer closed")
+	}
+}
@@ -0,0 +1,65 @@
+package pool
+
+import (
+	"sort"
+	"time"
+)
+
+// 计算耗时分位数时保留的最近样本数
+const latencySamples = 1024
+
+// LatencySummary 耗时分布, 分位数基于最近 latencySamples 个样本
+type LatencySummary struct {
+	Count int64 // 累计样本数
+
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// latencyWindow 保留最近的耗时样本
+type latencyWindow struct {
+	samples []time.Duration
+
+	next int // 下一个样本写入的位置
+
+	count int64 // 累计样本数
+}
+
+func (w *latencyWindow) observe(d time.Duration) {
+	w.count++
+	if len(w.samples) < latencySamples {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencySamples
+}
+
+func (w *latencyWindow) summary() LatencySummary {
+	s := LatencySummary{Count: w.count}
+	if len(w.samples) == 0 {
+		return s
+	}
+	sorted := append([]time.Duration(nil), w.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(q float64) time.Duration {
+		return sorted[int(q*float64(len(sorted)-1))]
+	}
+	s.P50 = at(0.5)
+	s.P90 = at(0.9)
+	s.P99 = at(0.99)
+	s.Max = sorted[len(sorted)-1]
+	return s
+}
+
+// DialDuration 调用factory建立该conn的耗时
+func (c *PoolConn) DialDuration() time.Duration {
+	return c.dialDuration
+}
+
+// HandshakeDuration 新建后OnCreate(如TLS握手、鉴权)的耗时, 未设置OnCreate时为0
+func (c *PoolConn) HandshakeDuration() time.Duration {
+	return c.handshakeDuration
+}
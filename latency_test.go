@@ -0,0 +1,74 @@
+package pool
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_DialLatency(t *testing.T) {
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		time.Sleep(time.Millisecond * 20)
+		return factory()
+	},
+		WithOnCreate(func(ctx context.Context, conn net.Conn) error {
+			time.Sleep(time.Millisecond * 10)
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc := conn.(*PoolConn)
+	if d := pc.DialDuration(); d < time.Millisecond*20 {
+		t.Errorf("DialDuration error. Expecting >= 20ms, got %s", d)
+	}
+	if d := pc.HandshakeDuration(); d < time.Millisecond*10 {
+		t.Errorf("HandshakeDuration error. Expecting >= 10ms, got %s", d)
+	}
+	if err := p.Put(pc); err != nil {
+		t.Error(err)
+	}
+
+	s := p.Stats()
+	if s.Dial.Count != 1 || s.Handshake.Count != 1 {
+		t.Errorf("Stats error. Expecting %d samples, got %d/%d", 1, s.Dial.Count, s.Handshake.Count)
+	}
+	if s.Dial.P50 != pc.DialDuration() || s.Dial.Max != pc.DialDuration() {
+		t.Errorf("Stats error. Expecting %s, got %s", pc.DialDuration(), s.Dial.P50)
+	}
+}
+
+func TestLatencyWindow(t *testing.T) {
+	var w latencyWindow
+	if s := w.summary(); s.Count != 0 || s.Max != 0 {
+		t.Errorf("summary error. Expecting empty, got %+v", s)
+	}
+
+	// 超过窗口大小后只保留最近的样本
+	for i := 0; i < latencySamples; i++ {
+		w.observe(time.Hour)
+	}
+	for i := 1; i <= latencySamples; i++ {
+		w.observe(time.Duration(i) * time.Millisecond)
+	}
+	s := w.summary()
+	if s.Count != 2*latencySamples {
+		t.Errorf("summary error. Expecting %d, got %d", 2*latencySamples, s.Count)
+	}
+	if s.Max != latencySamples*time.Millisecond {
+		t.Errorf("summary error. Expecting %s, got %s", latencySamples*time.Millisecond, s.Max)
+	}
+	if s.P50 != 512*time.Millisecond {
+		t.Errorf("summary error. Expecting %s, got %s", 512*time.Millisecond, s.P50)
+	}
+	if s.P99 != 1013*time.Millisecond {
+		t.Errorf("summary error. Expecting %s, got %s", 1013*time.Millisecond, s.P99)
+	}
+}
@@ -0,0 +1,175 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// goroutineStacks 当前所有goroutine的栈, 按goroutine头部(如"goroutine 12 [running]:")的id索引
+func goroutineStacks() map[string]string {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+	stacks := make(map[string]string)
+	for _, g := range bytes.Split(buf, []byte("\n\n")) {
+		header, _, _ := strings.Cut(string(g), "\n")
+		id, _, _ := strings.Cut(strings.TrimPrefix(header, "goroutine "), " ")
+		stacks[id] = string(g)
+	}
+	return stacks
+}
+
+// leakCheck 记录当前的goroutine, 返回的函数等待之后新建的pool相关goroutine全部退出, 超时报告泄漏
+func leakCheck(t *testing.T) func() {
+	before := goroutineStacks()
+	return func() {
+		t.Helper()
+		var leaked []string
+		deadline := time.Now().Add(time.Second * 2)
+		for {
+			leaked = leaked[:0]
+			for id, stack := range goroutineStacks() {
+				if _, ok := before[id]; ok || strings.Contains(stack, "testing.tRunner") {
+					continue
+				}
+				// 只关注pool及测试中调用pool的goroutine, 不包括测试用的server
+				if strings.Contains(stack, "ConnPool.(*") {
+					leaked = append(leaked, stack)
+				}
+			}
+			if len(leaked) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		for _, stack := range leaked {
+			t.Errorf("leaked goroutine:\n%s", stack)
+		}
+	}
+}
+
+func TestChannelPool_CloseNoLeakWaiters(t *testing.T) {
+	defer leakCheck(t)()
+
+	p, err := NewChannelPool(1, 2, factory, WithMaxPinned(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	held, _ := p.Get()
+	pinned, _ := p.GetPinned(context.Background())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Get(); err != ErrClosed {
+				t.Errorf("Get error. Expecting %v, got %v", ErrClosed, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := p.GetPinned(context.Background()); err != ErrClosed {
+				t.Errorf("GetPinned error. Expecting %v, got %v", ErrClosed, err)
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond * 20)
+	p.Close()
+	wg.Wait()
+	p.Put(held)
+	p.Put(pinned)
+}
+
+func TestChannelPool_CloseNoLeakDials(t *testing.T) {
+	defer leakCheck(t)()
+
+	// 第一个新建阻塞, 爬坡限制下其余新建等待名额
+	p, err := NewChannelPoolContext(3, 3, func(ctx context.Context) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, WithInitialConns(0), WithRampUp(LinearRamp(1, 0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Get(); err == nil {
+				t.Errorf("Get error. Expecting error after Close")
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := p.WaitReady(context.Background(), 1); err == nil {
+			t.Errorf("WaitReady error. Expecting error after Close")
+		}
+	}()
+	time.Sleep(time.Millisecond * 20)
+	p.Close()
+	wg.Wait()
+}
+
+func TestChannelPool_CloseNoLeakBackground(t *testing.T) {
+	defer leakCheck(t)()
+
+	var mu sync.Mutex
+	fail := false
+	p, err := NewChannelPool(2, 3, factory,
+		WithWatchdog(time.Millisecond*20, func(StallEvent) {}),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			time.Sleep(time.Millisecond * 5)
+			mu.Lock()
+			defer mu.Unlock()
+			if fail {
+				return errors.New("unhealthy")
+			}
+			return nil
+		}),
+		WithHealthCheckHedge(time.Millisecond, 2),
+		WithMaxIdleTime(time.Millisecond*10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	mu.Lock()
+	fail = true
+	mu.Unlock()
+	if conn, err := p.Get(); err == nil {
+		p.Put(conn)
+	}
+	time.Sleep(time.Millisecond * 20)
+	if conn, err := p.Get(); err == nil {
+		p.Put(conn)
+	}
+
+	p.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.WaitStopped(ctx); err != nil {
+		t.Errorf("WaitStopped error: %s", err)
+	}
+}
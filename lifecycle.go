@@ -0,0 +1,214 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// 等待conn放回或关闭时的检查间隔
+const lifecycleRecheck = 10 * time.Millisecond
+
+// WaitReady 新建conn失败后的重试间隔
+const readyRetry = 100 * time.Millisecond
+
+// GetContext 同 GetWitchContext
+func (p *ChannelPool) GetContext(ctx context.Context) (net.Conn, error) {
+	return p.GetWitchContext(p.withCaller(ctx, 2))
+}
+
+// Warmup 建立conn直到至少有n个空闲conn, n超过空闲conn上限(见WithMaxIdle)时按上限计算
+func (p *ChannelPool) Warmup(ctx context.Context, n int) error {
+	if n > int(p.maxIdle) {
+		n = int(p.maxIdle)
+	}
+	for {
+		p.mu.RLock()
+		closed, idle := p.closed, p.idle.Len()
+		p.mu.RUnlock()
+		switch {
+		case closed:
+			return ErrClosed
+		case idle >= n:
+			return nil
+		}
+
+		if err := p.acquire(ctx); err != nil {
+			return err
+		}
+		conn, err := p.dial(ctx)
+		if err != nil {
+			p.release()
+			return err
+		}
+		if err := p.Put(conn); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitReady 建立conn直到pool中至少有minConns个conn(包括取出的), 新建失败时重试,
+// 用于启动时确认后端可用; 设置了WithHealthCheck时只计算通过检查的conn, 未检查过的空闲conn和新建的conn先检查再计入.
+// minConns超过空闲conn上限(见WithMaxIdle)时返回错误, ctx结束时返回的错误包含最后一次新建或检查的错误
+func (p *ChannelPool) WaitReady(ctx context.Context, minConns int) error {
+	if minConns > int(p.maxIdle) {
+		return fmt.Errorf("min conns %d exceeds max idle conns %d", minConns, p.maxIdle)
+	}
+	var lastErr error
+	for {
+		p.mu.RLock()
+		closed, ready := p.closed, p.readyNum()
+		p.mu.RUnlock()
+		switch {
+		case closed:
+			return ErrClosed
+		case ready >= minConns:
+			return nil
+		}
+
+		if err := p.acquire(ctx); err != nil {
+			return readyErr(err, lastErr)
+		}
+		conn, err := p.readyConn(ctx)
+		if err == nil {
+			if err := p.Put(conn); err != nil {
+				return err
+			}
+			continue
+		}
+		lastErr = err
+		select {
+		case <-ctx.Done():
+			return readyErr(timeoutErr(ctx), lastErr)
+		case <-p.done:
+			return ErrClosed
+		case <-time.After(readyRetry):
+		}
+	}
+}
+
+// readyNum WaitReady计入的conn数, 设置了WithHealthCheck时只计算通过检查的conn, 需持有p.mu
+func (p *ChannelPool) readyNum() int {
+	if p.healthCheck == nil {
+		return int(p.acct.Open)
+	}
+	n := 0
+	for _, m := range p.conns {
+		if m.validated {
+			n++
+		}
+	}
+	return n
+}
+
+// readyConn 使用已获得的容量单位取得一个可以被WaitReady计入的conn: 优先检查未检查过的空闲conn, 没有时新建;
+// 失败时释放容量单位, 检查失败的conn被关闭
+func (p *ChannelPool) readyConn(ctx context.Context) (net.Conn, error) {
+	var conn net.Conn
+	if p.healthCheck != nil {
+		p.mu.Lock()
+		conn, _ = p.idle.Pop(func(c net.Conn) bool {
+			m, ok := p.conns[c]
+			return ok && !m.validated
+		})
+		if conn != nil {
+			p.markBusy(conn)
+		}
+		p.mu.Unlock()
+	}
+	if conn == nil {
+		var err error
+		if conn, err = p.dial(ctx); err != nil {
+			p.release()
+			return nil, err
+		}
+	}
+	if p.healthCheck != nil {
+		if err := p.check(ctx, conn); err != nil {
+			p.mu.Lock()
+			p.traceConn(conn, ConnEventHealthFail, err.Error())
+			p.healthResult(conn, err)
+			p.mu.Unlock()
+			p.discard(conn, checkFailReason(err))
+			return nil, err
+		}
+		p.checkPassed(conn)
+	}
+	return conn, nil
+}
+
+func readyErr(err, lastErr error) error {
+	if lastErr == nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", err, lastErr)
+}
+
+// Drain 淘汰当前所有conn: 空闲的立即关闭, 取出的放回时关闭, 之后获取的是新建的conn;
+// 等待被淘汰的conn全部关闭, ctx结束时返回超时错误
+func (p *ChannelPool) Drain(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+	conns, gen := p.retireAll()
+	p.mu.Unlock()
+
+	for _, c := range conns {
+		_ = p.closeConn(c)
+	}
+	return p.waitRetired(ctx, gen)
+}
+
+// retireAll 淘汰当前所有conn, 返回应关闭的空闲conn和新的代数, 取出的conn放回时关闭, 需持有p.mu
+func (p *ChannelPool) retireAll() ([]net.Conn, uint64) {
+	p.gen++
+	conns := append(p.idle.Drain(), p.handoff.Drain()...)
+	for i, c := range conns {
+		conns[i] = p.forget(c, CloseReasonDrained)
+	}
+	return conns, p.gen
+}
+
+// waitRetired 等待代数小于gen的conn全部关闭
+func (p *ChannelPool) waitRetired(ctx context.Context, gen uint64) error {
+	return p.waitUntil(ctx, func() bool {
+		for _, m := range p.conns {
+			if m.gen < gen {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// CloseContext 关闭pool并等待取出的conn放回, ctx结束时返回超时错误
+func (p *ChannelPool) CloseContext(ctx context.Context) error {
+	if err := p.Close(); err != nil {
+		return err
+	}
+	return p.waitUntil(ctx, func() bool {
+		return p.acct.Open == 0
+	})
+}
+
+// waitUntil 等待cond成立, cond在持有p.mu时调用
+func (p *ChannelPool) waitUntil(ctx context.Context, cond func() bool) error {
+	ticker := time.NewTicker(lifecycleRecheck)
+	defer ticker.Stop()
+	for {
+		p.mu.RLock()
+		ok := cond()
+		p.mu.RUnlock()
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return timeoutErr(ctx)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var _ PoolV2 = (*ChannelPool)(nil)
+
+func TestChannelPool_Warmup(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if err := p.Warmup(context.Background(), 2); err != nil {
+		t.Fatalf("Warmup error: %s", err)
+	}
+	if p.Len() != 2 {
+		t.Errorf("Warmup error. Expecting %d, got %d", 2, p.Len())
+	}
+
+	// 超过maxFree时按maxFree计算
+	if err := p.Warmup(context.Background(), 10); err != nil {
+		t.Fatalf("Warmup error: %s", err)
+	}
+	if p.Len() != 3 || p.OpenNum() != 3 {
+		t.Errorf("Warmup error. Expecting %d, got %d idle %d open", 3, p.Len(), p.OpenNum())
+	}
+	if p.InUse() != 0 {
+		t.Errorf("Warmup error. Expecting %d, got %d", 0, p.InUse())
+	}
+}
+
+func TestChannelPool_Drain(t *testing.T) {
+	p, err := NewChannelPool(2, 3, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	held, err := p.GetContext(context.Background())
+	if err != nil {
+		t.Fatalf("GetContext error: %s", err)
+	}
+	oldID, _ := p.ConnID(held)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Drain(context.Background())
+	}()
+
+	// 等待取出的conn放回
+	time.Sleep(time.Millisecond * 30)
+	select {
+	case err := <-done:
+		t.Fatalf("Drain error. Expecting wait for held conn, got %v", err)
+	default:
+	}
+	if p.Len() != 0 {
+		t.Errorf("Drain error. Expecting %d, got %d", 0, p.Len())
+	}
+
+	// Drain期间获取的是新建的conn
+	conn, err := p.GetContext(context.Background())
+	if err != nil {
+		t.Fatalf("GetContext error: %s", err)
+	}
+	if id, _ := p.ConnID(conn); id <= oldID+1 {
+		t.Errorf("GetContext error. Expecting new conn, got %d", id)
+	}
+	p.Put(conn)
+
+	p.Put(held)
+	if err := <-done; err != nil {
+		t.Errorf("Drain error: %s", err)
+	}
+	if h := p.ConnAgeStats()[CloseReasonDrained]; h.Count != 2 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 2, h.Count)
+	}
+	if p.OpenNum() != 1 || p.Len() != 1 {
+		t.Errorf("Drain error. Expecting %d, got %d open %d idle", 1, p.OpenNum(), p.Len())
+	}
+}
+
+func TestChannelPool_DrainTimeout(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	if err := p.Drain(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("Drain error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	p.Put(conn)
+}
+
+func TestChannelPool_CloseContext(t *testing.T) {
+	p, err := NewChannelPool(2, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	if err := p.CloseContext(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("CloseContext error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	if p.OpenNum() != 1 {
+		t.Errorf("CloseContext error. Expecting %d, got %d", 1, p.OpenNum())
+	}
+	p.Put(conn)
+	if p.OpenNum() != 0 {
+		t.Errorf("Put error. Expecting %d, got %d", 0, p.OpenNum())
+	}
+	if err := p.CloseContext(context.Background()); err != ErrClosed {
+		t.Errorf("CloseContext error. Expecting %v, got %v", ErrClosed, err)
+	}
+}
+
+func TestChannelPool_WaitReady(t *testing.T) {
+	var mu sync.Mutex
+	failures := 2
+	p, err := NewChannelPool(3, 5, func() (net.Conn, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			return nil, errors.New("backend not ready")
+		}
+		return factory()
+	}, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Get(); err == nil {
+		t.Fatalf("Get error. Expecting backend not ready")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	if err := p.WaitReady(ctx, 2); err != nil {
+		t.Fatalf("WaitReady error: %s", err)
+	}
+	if p.OpenNum() != 2 || p.Len() != 2 {
+		t.Errorf("WaitReady error. Expecting %d, got %d open %d idle", 2, p.OpenNum(), p.Len())
+	}
+}
+
+func TestChannelPool_WaitReadyTimeout(t *testing.T) {
+	dialErr := errors.New("connection refused")
+	p, err := NewChannelPool(3, 5, func() (net.Conn, error) {
+		return nil, dialErr
+	}, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err = p.WaitReady(ctx, 1)
+	var te *TimeoutError
+	if !errors.As(err, &te) || !errors.Is(err, dialErr) {
+		t.Errorf("WaitReady error. Expecting timeout caused by %v, got %v", dialErr, err)
+	}
+
+	p.Close()
+	if err := p.WaitReady(context.Background(), 1); err != ErrClosed {
+		t.Errorf("WaitReady error. Expecting %v, got %v", ErrClosed, err)
+	}
+}
+
+func TestChannelPool_WaitReadyExceedsMaxIdle(t *testing.T) {
+	p, err := NewChannelPool(2, 5, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if err := p.WaitReady(context.Background(), 3); err == nil {
+		t.Errorf("WaitReady error. Expecting error for min conns above max idle")
+	}
+	if p.OpenNum() != 0 {
+		t.Errorf("WaitReady error. Expecting %d, got %d", 0, p.OpenNum())
+	}
+}
+
+func TestChannelPool_WaitReadyHealthCheck(t *testing.T) {
+	var checks int32
+	checkErr := errors.New("backend not ready")
+	p, err := NewChannelPool(2, 5, factory,
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			// 前3次检查失败, 包括初始的2个空闲conn
+			if atomic.AddInt32(&checks, 1) <= 3 {
+				return checkErr
+			}
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	if err := p.WaitReady(ctx, 2); err != nil {
+		t.Fatalf("WaitReady error: %s", err)
+	}
+	if n := atomic.LoadInt32(&checks); n != 5 {
+		t.Errorf("WaitReady error. Expecting %d checks, got %d", 5, n)
+	}
+	if p.OpenNum() != 2 || p.Len() != 2 {
+		t.Errorf("WaitReady error. Expecting %d, got %d open %d idle", 2, p.OpenNum(), p.Len())
+	}
+
+	// 检查一直失败时不计入, ctx结束时返回最后一次检查的错误
+	p2, err := NewChannelPool(1, 2, factory, WithInitialConns(0),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			return checkErr
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel2()
+	err = p2.WaitReady(ctx2, 1)
+	var te *TimeoutError
+	if !errors.As(err, &te) || !errors.Is(err, checkErr) {
+		t.Errorf("WaitReady error. Expecting timeout caused by %v, got %v", checkErr, err)
+	}
+	if p2.OpenNum() != 0 {
+		t.Errorf("WaitReady error. Expecting %d, got %d", 0, p2.OpenNum())
+	}
+}
@@ -0,0 +1,95 @@
+package pool
+
+import (
+	"net"
+	"time"
+)
+
+// 后台清理过期空闲conn的检查间隔下限
+const minReapInterval = 100 * time.Millisecond
+
+// SetConnMaxLifetime 设置conn的最大存活时间, 同database/sql的SetConnMaxLifetime, d <= 0 不限制;
+// 超过的conn在放回时关闭, 空闲的conn由后台定期关闭, 用于定期重建conn(如后端轮换证书后)
+func (p *ChannelPool) SetConnMaxLifetime(d time.Duration) {
+	p.mu.Lock()
+	p.maxLifetime = d
+	start := d > 0 && !p.reaping && !p.closed
+	if start {
+		p.reaping = true
+	}
+	p.mu.Unlock()
+
+	if start {
+		p.goBackground(p.reaper)
+	}
+	// 按新的存活时间立即检查一次
+	select {
+	case p.reapWake <- struct{}{}:
+	default:
+	}
+}
+
+// reapInterval 后台检查的间隔, 需持有p.mu
+func (p *ChannelPool) reapInterval() time.Duration {
+	interval := p.maxLifetime / 4
+	if interval < minReapInterval {
+		interval = minReapInterval
+	}
+	return interval
+}
+
+// reaper 定期关闭过期的空闲conn, 最大存活时间被设为不限制或pool关闭时退出
+func (p *ChannelPool) reaper() {
+	for {
+		p.mu.Lock()
+		if p.maxLifetime <= 0 || p.closed {
+			p.reaping = false
+			p.mu.Unlock()
+			return
+		}
+		interval := p.reapInterval()
+		p.mu.Unlock()
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-p.done:
+			timer.Stop()
+			return
+		case <-p.reapWake:
+			timer.Stop()
+		case <-timer.C:
+		}
+		p.reapIdle(time.Now())
+	}
+}
+
+// reapIdle 关闭已过期的空闲conn, 开启WithWarmIdle时由后台补足, 返回关闭的conn数
+func (p *ChannelPool) reapIdle(now time.Time) int {
+	p.mu.Lock()
+	expired := func(conn net.Conn) bool {
+		m, ok := p.conns[conn]
+		if !ok {
+			return false
+		}
+		_, ok = p.expired(m, now)
+		return ok
+	}
+	var conns []net.Conn
+	for {
+		conn, ok := p.idle.Pop(expired)
+		if !ok {
+			break
+		}
+		reason, _ := p.expired(p.conns[conn], now)
+		conns = append(conns, p.forget(conn, reason))
+	}
+	if len(conns) > 0 {
+		p.wakeWarm()
+	}
+	p.mu.Unlock()
+
+	for _, c := range conns {
+		p.closeAsync(c)
+	}
+	return len(conns)
+}
@@ -0,0 +1,103 @@
+package pool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChannelPool_MaxLifetimePut(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory, WithInitialConns(0), WithMaxLifetime(time.Millisecond*30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	time.Sleep(time.Millisecond * 50)
+
+	// 超过最大存活时间的conn放回时关闭
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 0 || p.OpenNum() != 0 {
+		t.Errorf("Put error. Expecting no conns, got %d idle %d open", p.Len(), p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonMaxLifetime]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestChannelPool_LifetimeReaper(t *testing.T) {
+	p, err := NewChannelPool(2, 3, factory, WithMaxLifetime(time.Millisecond*50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 空闲conn不被取出也会由后台关闭
+	deadline := time.Now().Add(time.Second * 2)
+	for p.OpenNum() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if p.OpenNum() != 0 {
+		t.Errorf("reaper error. Expecting %d, got %d", 0, p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonMaxLifetime]; h.Count != 2 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 2, h.Count)
+	}
+}
+
+func TestChannelPool_SetConnMaxLifetime(t *testing.T) {
+	p, err := NewChannelPool(2, 3, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	time.Sleep(time.Millisecond * 30)
+	if p.OpenNum() != 2 {
+		t.Errorf("OpenNum error. Expecting %d, got %d", 2, p.OpenNum())
+	}
+
+	// 运行中设置后立即关闭已超过的空闲conn
+	p.SetConnMaxLifetime(time.Millisecond * 20)
+	deadline := time.Now().Add(time.Second)
+	for p.OpenNum() != 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 5)
+	}
+	if p.OpenNum() != 0 {
+		t.Errorf("SetConnMaxLifetime error. Expecting %d, got %d", 0, p.OpenNum())
+	}
+
+	// 取消限制后reaper退出, 新的conn不再过期
+	p.SetConnMaxLifetime(0)
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	time.Sleep(time.Millisecond * 40)
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d", 1, p.Len())
+	}
+	deadline = time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		p.mu.RLock()
+		reaping := p.reaping
+		p.mu.RUnlock()
+		if !reaping {
+			break
+		}
+		time.Sleep(time.Millisecond * 5)
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.reaping {
+		t.Error("reaper error. Expecting reaper stopped")
+	}
+}
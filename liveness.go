@@ -0,0 +1,26 @@
+package pool
+
+import "net"
+
+// LivenessProbe 判断空闲conn的对端是否仍然存活: 返回nil表示对端存活且暂时没有数据,
+// ErrUnexpectedRead表示conn上有未读数据, 其他error(如io.EOF)表示对端已关闭或conn出错
+type LivenessProbe func(conn net.Conn) error
+
+// PeekLiveness unix平台上用MSG_PEEK非阻塞地查看socket, 不消耗数据, 读到0字节视为对端已关闭;
+// 其他平台或conn不是socket时同ReadLiveness
+func PeekLiveness(conn net.Conn) error {
+	return peekUnread(conn)
+}
+
+// ReadLiveness 设置很短的读超时读取1字节, 超时视为存活; 读到的数据会被消耗, 只适合对端不会主动发送数据的协议
+func ReadLiveness(conn net.Conn) error {
+	return probeAlive(conn)
+}
+
+// livenessProbe 返回使用的存活探测, 默认为PeekLiveness
+func (p *ChannelPool) livenessProbe() LivenessProbe {
+	if p.liveness != nil {
+		return p.liveness
+	}
+	return PeekLiveness
+}
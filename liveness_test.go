@@ -0,0 +1,92 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_LivenessProbe(t *testing.T) {
+	var probes int32
+	errDead := errors.New("peer gone")
+	var dead atomic.Bool
+	probe := func(conn net.Conn) error {
+		atomic.AddInt32(&probes, 1)
+		if dead.Load() {
+			return errDead
+		}
+		return PeekLiveness(conn)
+	}
+	p, err := NewChannelPool(1, 2, factory, WithLivenessProbe(probe))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if n := atomic.LoadInt32(&probes); n != 1 {
+		t.Errorf("LivenessProbe error. Expecting %d probes, got %d", 1, n)
+	}
+
+	// 探测失败的空闲conn被关闭, 改为新建
+	dead.Store(true)
+	id, _ := p.ConnID(conn)
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if newID, _ := p.ConnID(conn); newID == id {
+		t.Errorf("LivenessProbe error. Expecting new conn after failed probe")
+	}
+	p.Put(conn)
+	if h := p.ConnAgeStats()[CloseReasonHealthCheck]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestPeekLiveness_KeepsData(t *testing.T) {
+	client, server := tcpPair(t)
+	defer client.Close()
+	defer server.Close()
+
+	server.Write([]byte("x"))
+	time.Sleep(20 * time.Millisecond)
+	if err := PeekLiveness(client); err != ErrUnexpectedRead {
+		t.Errorf("PeekLiveness error. Expecting %v, got %v", ErrUnexpectedRead, err)
+	}
+	// PeekLiveness不消耗数据, ReadLiveness消耗
+	if err := ReadLiveness(client); err != ErrUnexpectedRead {
+		t.Errorf("ReadLiveness error. Expecting %v, got %v", ErrUnexpectedRead, err)
+	}
+	if err := ReadLiveness(client); err != nil {
+		t.Errorf("ReadLiveness error. Expecting nil after data consumed, got %v", err)
+	}
+}
+
+// tcpPair 返回一对已连接的TCP conn
+func tcpPair(t *testing.T) (net.Conn, net.Conn) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, <-accepted
+}
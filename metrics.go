@@ -0,0 +1,216 @@
+package pool
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// WriteMetrics可附加的标签
+const (
+	LabelPool    = "pool"    // WithName设置的pool名称, 未设置时不附加
+	LabelBackend = "backend" // conn的对端地址, 只附加在按conn统计的指标上
+)
+
+// 超出MetricsConfig.MaxBackends的后端合并为该标签值
+const otherBackend = "other"
+
+// MetricsConfig WriteMetrics输出的指标和标签, 用于控制多后端时的标签基数
+type MetricsConfig struct {
+	Labels []string // 附加的标签, 取值LabelPool、LabelBackend; 为nil时只附加LabelPool
+
+	ConstLabels map[string]string // 附加在所有指标上的固定标签, 如租户
+
+	Allow []string // 输出的指标名, 为空时输出全部
+
+	MaxBackends int // 附加LabelBackend时最多输出conn数最多的几个后端, 其余合并为"other", <= 0 不限制
+}
+
+// validate 检查标签名
+func (c MetricsConfig) validate() error {
+	for _, l := range c.Labels {
+		if l != LabelPool && l != LabelBackend {
+			return fmt.Errorf("invalid metrics label %q", l)
+		}
+	}
+	for k := range c.ConstLabels {
+		if k == "" || k == LabelPool || k == LabelBackend {
+			return fmt.Errorf("invalid metrics const label %q", k)
+		}
+	}
+	return nil
+}
+
+func (c MetricsConfig) has(label string) bool {
+	if c.Labels == nil {
+		return label == LabelPool
+	}
+	for _, l := range c.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func (c MetricsConfig) allowed(name string) bool {
+	if len(c.Allow) == 0 {
+		return true
+	}
+	for _, n := range c.Allow {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// backendCount 一个后端的conn数
+type backendCount struct {
+	backend string
+
+	open, idle int
+}
+
+// backendCounts 按后端统计conn数, 按conn数从多到少排序, 超过max的合并为otherBackend, 需持有p.mu
+func (p *ChannelPool) backendCounts(max int) []backendCount {
+	index := make(map[string]int)
+	var counts []backendCount
+	for _, m := range p.conns {
+		i, ok := index[m.backend]
+		if !ok {
+			i = len(counts)
+			index[m.backend] = i
+			counts = append(counts, backendCount{backend: m.backend})
+		}
+		counts[i].open++
+		if m.idle {
+			counts[i].idle++
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].open != counts[j].open {
+			return counts[i].open > counts[j].open
+		}
+		return counts[i].backend < counts[j].backend
+	})
+	if max <= 0 || len(counts) <= max {
+		return counts
+	}
+	other := backendCount{backend: otherBackend}
+	for _, c := range counts[max:] {
+		other.open += c.open
+		other.idle += c.idle
+	}
+	return append(counts[:max:max], other)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsWriter 按Prometheus文本格式输出指标
+type metricsWriter struct {
+	w *bufio.Writer
+
+	config MetricsConfig
+
+	base string // 所有指标共有的标签, 已按名称排序并转义
+}
+
+func newMetricsWriter(w io.Writer, config MetricsConfig, name string) *metricsWriter {
+	var labels []string
+	if config.has(LabelPool) && name != "" {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, LabelPool, labelEscaper.Replace(name)))
+	}
+	keys := make([]string, 0, len(config.ConstLabels))
+	for k := range config.ConstLabels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		labels = append(labels, fmt.Sprintf(`%s="%s"`, k, labelEscaper.Replace(config.ConstLabels[k])))
+	}
+	return &metricsWriter{w: bufio.NewWriter(w), config: config, base: strings.Join(labels, ",")}
+}
+
+func (mw *metricsWriter) header(name, typ, help string) bool {
+	if !mw.config.allowed(name) {
+		return false
+	}
+	fmt.Fprintf(mw.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	return true
+}
+
+func (mw *metricsWriter) sample(name, extra string, value float64) {
+	labels := mw.base
+	if extra != "" {
+		if labels != "" {
+			labels += ","
+		}
+		labels += extra
+	}
+	if labels != "" {
+		fmt.Fprintf(mw.w, "%s{%s} %g\n", name, labels, value)
+		return
+	}
+	fmt.Fprintf(mw.w, "%s %g\n", name, value)
+}
+
+func (mw *metricsWriter) metric(name, typ, help string, value float64) {
+	if mw.header(name, typ, help) {
+		mw.sample(name, "", value)
+	}
+}
+
+// WriteMetrics 按Prometheus文本格式输出pool的指标, 附加的标签和输出的指标由WithMetricsConfig控制
+func (p *ChannelPool) WriteMetrics(w io.Writer) error {
+	config := p.metricsConfig
+	p.mu.RLock()
+	s := p.stats()
+	var backends []backendCount
+	if config.has(LabelBackend) {
+		backends = p.backendCounts(config.MaxBackends)
+	}
+	p.mu.RUnlock()
+
+	mw := newMetricsWriter(w, config, p.name)
+	if backends == nil {
+		mw.metric("connpool_open_conns", "gauge", "Open connections.", float64(s.Open))
+		mw.metric("connpool_idle_conns", "gauge", "Idle connections.", float64(s.Idle))
+	} else {
+		if mw.header("connpool_open_conns", "gauge", "Open connections.") {
+			for _, b := range backends {
+				mw.sample("connpool_open_conns", fmt.Sprintf(`%s="%s"`, LabelBackend, labelEscaper.Replace(b.backend)), float64(b.open))
+			}
+		}
+		if mw.header("connpool_idle_conns", "gauge", "Idle connections.") {
+			for _, b := range backends {
+				mw.sample("connpool_idle_conns", fmt.Sprintf(`%s="%s"`, LabelBackend, labelEscaper.Replace(b.backend)), float64(b.idle))
+			}
+		}
+	}
+	mw.metric("connpool_in_use_conns", "gauge", "Checked out connections.", float64(s.InUse))
+	mw.metric("connpool_waiters", "gauge", "Callers waiting for a connection.", float64(s.Waiters))
+	mw.metric("connpool_created_total", "counter", "Connections created.", float64(s.Created))
+	mw.metric("connpool_closed_total", "counter", "Connections closed.", float64(s.Closed))
+	mw.metric("connpool_hits_total", "counter", "Gets served by an idle connection.", float64(s.Hits))
+	mw.metric("connpool_misses_total", "counter", "Gets that created a connection.", float64(s.Misses))
+	mw.metric("connpool_timeouts_total", "counter", "Gets that timed out waiting.", float64(s.Timeouts))
+	mw.metric("connpool_wait_seconds_total", "counter", "Time spent waiting for a connection.", s.WaitDuration.Seconds())
+	return mw.w.Flush()
+}
+
+// MetricsHandler 输出WriteMetrics的http.Handler, 可直接作为Prometheus的抓取地址
+func (p *ChannelPool) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = p.WriteMetrics(w)
+	})
+}
+
+// Name 返回WithName设置的pool名称
+func (p *ChannelPool) Name() string {
+	return p.name
+}
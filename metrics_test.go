@@ -0,0 +1,91 @@
+package pool
+
+import (
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChannelPool_WriteMetrics(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(2), WithName("users"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, _ := p.Get()
+	defer p.Put(conn)
+
+	var b strings.Builder
+	if err := p.WriteMetrics(&b); err != nil {
+		t.Fatalf("WriteMetrics error: %s", err)
+	}
+	for _, want := range []string{
+		"# TYPE connpool_open_conns gauge\n",
+		`connpool_open_conns{pool="users"} 2` + "\n",
+		`connpool_idle_conns{pool="users"} 1` + "\n",
+		`connpool_in_use_conns{pool="users"} 1` + "\n",
+		`connpool_hits_total{pool="users"} 1` + "\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("WriteMetrics error. Expecting %q, got %q", want, b.String())
+		}
+	}
+	if strings.Contains(b.String(), LabelBackend) {
+		t.Errorf("WriteMetrics error. Expecting no backend label by default")
+	}
+}
+
+func TestChannelPool_MetricsConfig(t *testing.T) {
+	config := MetricsConfig{
+		Labels:      []string{LabelBackend},
+		ConstLabels: map[string]string{"tenant": `a"b`},
+		Allow:       []string{"connpool_open_conns", "connpool_waiters"},
+	}
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(2), WithName("users"), WithMetricsConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	rec := httptest.NewRecorder()
+	p.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	want := `connpool_open_conns{tenant="a\"b",backend="` + address + `"} 2` + "\n"
+	if !strings.Contains(body, want) {
+		t.Errorf("MetricsHandler error. Expecting %q, got %q", want, body)
+	}
+	if !strings.Contains(body, `connpool_waiters{tenant="a\"b"} 0`) {
+		t.Errorf("MetricsHandler error. Expecting waiters, got %q", body)
+	}
+	if strings.Contains(body, "connpool_idle_conns") || strings.Contains(body, `pool="users"`) {
+		t.Errorf("MetricsHandler error. Expecting only allowed metrics and labels, got %q", body)
+	}
+
+	for _, c := range []MetricsConfig{
+		{Labels: []string{"tenant"}},
+		{ConstLabels: map[string]string{LabelPool: "x"}},
+	} {
+		if _, err := NewChannelPool(3, 5, factory, WithMetricsConfig(c)); err == nil {
+			t.Errorf("NewChannelPool error. Expecting invalid metrics config %+v", c)
+		}
+	}
+}
+
+func TestChannelPool_BackendCounts(t *testing.T) {
+	p := &ChannelPool{conns: map[net.Conn]*connMeta{}}
+	for i, backend := range []string{"a", "a", "a", "b", "b", "c", "d"} {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		defer c2.Close()
+		p.conns[c1] = &connMeta{backend: backend, idle: i%2 == 0}
+	}
+	counts := p.backendCounts(2)
+	if len(counts) != 3 || counts[0].backend != "a" || counts[1].backend != "b" || counts[2].backend != otherBackend {
+		t.Fatalf("backendCounts error. Expecting a b other, got %+v", counts)
+	}
+	if counts[0].open != 3 || counts[2].open != 2 {
+		t.Errorf("backendCounts error. Expecting 3 and 2 open, got %d %d", counts[0].open, counts[2].open)
+	}
+}
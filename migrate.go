@@ -0,0 +1,120 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"time"
+)
+
+var (
+	ErrMigrating = errors.New("migration in progress")
+)
+
+// MigratePolicy MigrateTo淘汰旧conn的节奏
+type MigratePolicy struct {
+	Percent float64 // 每个Interval淘汰的旧conn占迁移开始时旧conn数的百分比, 至少淘汰1个, <= 0 为10
+
+	Interval time.Duration // 每批淘汰的间隔, <= 0 为1秒
+}
+
+// migrateStep 淘汰最多quota个旧conn: 先关闭空闲的, 再标记取出的在放回时关闭, 返回尚未关闭的旧conn数
+func (p *ChannelPool) migrateStep(quota int) int {
+	p.mu.Lock()
+	old := func(conn net.Conn) bool {
+		m, ok := p.conns[conn]
+		return ok && m.migrating && !m.migrated
+	}
+	var conns []net.Conn
+	for ; quota > 0; quota-- {
+		conn, ok := p.idle.Pop(old)
+		if !ok {
+			break
+		}
+		conns = append(conns, p.forget(conn, CloseReasonMigrated))
+	}
+	if quota > 0 {
+		for _, m := range p.conns {
+			if quota == 0 {
+				break
+			}
+			if m.migrating && !m.migrated && !m.idle && !m.handoff {
+				m.migrated = true
+				quota--
+			}
+		}
+	}
+	if len(conns) > 0 {
+		p.wakeWarm()
+	}
+	pending := p.migratePending
+	p.mu.Unlock()
+
+	for _, c := range conns {
+		p.closeAsync(c)
+	}
+	return pending
+}
+
+// MigrateTo 把pool切换到新的后端: 之后新建的conn使用factory, 当前的conn按policy的节奏分批淘汰,
+// 空闲的立即关闭, 取出的放回时关闭, 避免同时重建所有conn; 迁移进度见Stats的Migrating、MigrationTotal和MigrationPending.
+// 旧conn全部关闭后返回; ctx结束时停止淘汰并返回超时错误, factory不恢复, 剩余的旧conn继续使用直到被关闭.
+// 已有迁移在进行时返回ErrMigrating
+func (p *ChannelPool) MigrateTo(ctx context.Context, factory FactoryContext, policy MigratePolicy) error {
+	if factory == nil {
+		return errors.New("invalid factory")
+	}
+	if policy.Percent <= 0 {
+		policy.Percent = 10
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = time.Second
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+	if p.migrating {
+		p.mu.Unlock()
+		return ErrMigrating
+	}
+	p.factory = factory
+	p.migrating = true
+	// 上次迁移中止后剩余的旧conn同样需要淘汰
+	total := len(p.conns)
+	for _, m := range p.conns {
+		m.migrating = true
+	}
+	p.migrateTotal = total
+	p.migratePending = total
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		p.migrating = false
+		p.mu.Unlock()
+	}()
+
+	quota := int(math.Ceil(float64(total) * policy.Percent / 100))
+	if quota < 1 {
+		quota = 1
+	}
+	for {
+		if p.migrateStep(quota) == 0 {
+			return nil
+		}
+		timer := time.NewTimer(policy.Interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return timeoutErr(ctx)
+		case <-p.done:
+			timer.Stop()
+			return ErrClosed
+		case <-timer.C:
+		}
+	}
+}
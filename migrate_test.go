@@ -0,0 +1,85 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_MigrateTo(t *testing.T) {
+	target := newStandbyServer(t)
+	p, err := NewChannelPool(3, 5, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 取出的旧conn在放回时淘汰
+	held, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(time.Millisecond * 60)
+		_ = p.Put(held)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+	if err := p.MigrateTo(ctx, target.factory, MigratePolicy{Percent: 50, Interval: time.Millisecond * 20}); err != nil {
+		t.Fatalf("MigrateTo error: %s", err)
+	}
+	if s := p.Stats(); s.Migrating || s.MigrationTotal != 3 || s.MigrationPending != 0 || s.Open != 0 {
+		t.Errorf("MigrateTo error. Expecting 3 migrated, got migrating %t total %d pending %d open %d",
+			s.Migrating, s.MigrationTotal, s.MigrationPending, s.Open)
+	}
+	if h := p.ConnAgeStats()[CloseReasonMigrated]; h.Count != 3 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 3, h.Count)
+	}
+
+	// 之后新建的conn连接新的后端
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := p.Backend(conn); b != target.l.Addr().String() {
+		t.Errorf("MigrateTo error. Expecting backend %s, got %s", target.l.Addr(), b)
+	}
+	_ = p.Put(conn)
+}
+
+func TestChannelPool_MigrateToPace(t *testing.T) {
+	target := newStandbyServer(t)
+	p, err := NewChannelPool(4, 4, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- p.MigrateTo(ctx, target.factory, MigratePolicy{Percent: 25, Interval: time.Millisecond * 200})
+	}()
+
+	// 每批只淘汰1个
+	time.Sleep(time.Millisecond * 50)
+	if s := p.Stats(); !s.Migrating || s.MigrationTotal != 4 || s.MigrationPending != 3 {
+		t.Errorf("MigrateTo error. Expecting 3 of 4 pending, got migrating %t total %d pending %d",
+			s.Migrating, s.MigrationTotal, s.MigrationPending)
+	}
+	if err := p.MigrateTo(ctx, target.factory, MigratePolicy{}); !errors.Is(err, ErrMigrating) {
+		t.Errorf("MigrateTo error. Expecting %v, got %v", ErrMigrating, err)
+	}
+
+	// 中止后剩余的旧conn继续使用
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("MigrateTo error. Expecting %v, got %v", context.Canceled, err)
+	}
+	if s := p.Stats(); s.Migrating || s.MigrationPending != 3 || s.Idle != 3 {
+		t.Errorf("MigrateTo error. Expecting 3 old idle conns, got migrating %t pending %d idle %d",
+			s.Migrating, s.MigrationPending, s.Idle)
+	}
+}
@@ -0,0 +1,80 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// 按操作统计时最多记录的操作数, 包括合并超出部分的otherOperation
+const maxOperations = 64
+
+const otherOperation = "other"
+
+type operationKey struct{}
+
+// WithOperation 在ctx中记录本次获取conn的操作名, 如"get_user", 用于OperationStats按操作统计等待,
+// 并记录在AcquireInfo中; OnCreate、HealthCheck、OnBackend等收到调用方ctx的回调可用OperationFrom读取
+func WithOperation(ctx context.Context, op string) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFrom 返回WithOperation记录的操作名, 未记录时为空
+func OperationFrom(ctx context.Context) string {
+	op, _ := ctx.Value(operationKey{}).(string)
+	return op
+}
+
+// OperationStats 一个操作获取conn的统计, 累计值从pool创建开始计算
+type OperationStats struct {
+	Gets int64 // 成功获取conn的次数
+
+	Waits int64 // 需要等待conn放回的次数
+
+	WaitDuration time.Duration // 累计等待时间
+
+	Timeouts int64 // 等待超时次数
+}
+
+// AvgWait 平均每次等待的时间
+func (s OperationStats) AvgWait() time.Duration {
+	if s.Waits == 0 {
+		return 0
+	}
+	return s.WaitDuration / time.Duration(s.Waits)
+}
+
+// operationStats 返回ctx中操作的统计, 没有记录操作时返回nil, 需持有p.mu
+func (p *ChannelPool) operationStats(ctx context.Context) *OperationStats {
+	op := OperationFrom(ctx)
+	if op == "" {
+		return nil
+	}
+	if p.opStats == nil {
+		p.opStats = make(map[string]*OperationStats)
+	}
+	s, ok := p.opStats[op]
+	if ok {
+		return s
+	}
+	if len(p.opStats) >= maxOperations-1 {
+		op = otherOperation
+		if s, ok = p.opStats[op]; ok {
+			return s
+		}
+	}
+	s = &OperationStats{}
+	p.opStats[op] = s
+	return s
+}
+
+// OperationStats 按WithOperation记录的操作名返回获取conn的统计, 至多64项, 之后新的操作合并为"other"
+func (p *ChannelPool) OperationStats() map[string]OperationStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats := make(map[string]OperationStats, len(p.opStats))
+	for op, s := range p.opStats {
+		stats[op] = *s
+	}
+	return stats
+}
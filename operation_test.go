@@ -0,0 +1,78 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_OperationStats(t *testing.T) {
+	var created []string
+	p, err := NewChannelPool(1, 1, factory, WithInitialConns(0), WithOnCreate(func(ctx context.Context, conn net.Conn) error {
+		created = append(created, OperationFrom(ctx))
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.GetWitchContext(WithOperation(context.Background(), "get_user"))
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if op := conn.(*PoolConn).Acquisition().Operation; op != "get_user" {
+		t.Errorf("Acquisition error. Expecting %q, got %q", "get_user", op)
+	}
+	if len(created) != 1 || created[0] != "get_user" {
+		t.Errorf("OperationFrom error. Expecting OnCreate to see get_user, got %v", created)
+	}
+
+	ctx, cancel := context.WithTimeout(WithOperation(context.Background(), "list_orders"), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.GetWitchContext(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("Get error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	p.Put(conn)
+
+	// 没有操作名的获取不计入
+	conn, _ = p.Get()
+	p.Put(conn)
+
+	stats := p.OperationStats()
+	if s := stats["get_user"]; s.Gets != 1 || s.Waits != 0 {
+		t.Errorf("OperationStats error. Expecting 1 get 0 waits, got %+v", s)
+	}
+	if s := stats["list_orders"]; s.Gets != 0 || s.Waits != 1 || s.Timeouts != 1 || s.AvgWait() < 20*time.Millisecond {
+		t.Errorf("OperationStats error. Expecting 1 timed out wait, got %+v", s)
+	}
+	if len(stats) != 2 {
+		t.Errorf("OperationStats error. Expecting %d operations, got %d", 2, len(stats))
+	}
+}
+
+func TestChannelPool_OperationStatsLimit(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	for i := 0; i < maxOperations+10; i++ {
+		conn, err := p.GetWitchContext(WithOperation(context.Background(), fmt.Sprintf("op-%d", i)))
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		p.Put(conn)
+	}
+	stats := p.OperationStats()
+	if len(stats) != maxOperations {
+		t.Errorf("OperationStats error. Expecting %d operations, got %d", maxOperations, len(stats))
+	}
+	if s := stats[otherOperation]; s.Gets != 11 {
+		t.Errorf("OperationStats error. Expecting %d gets in other, got %d", 11, s.Gets)
+	}
+}
@@ -0,0 +1,448 @@
+package pool
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"time"
+
+	"ConnPool/poolcore"
+)
+
+// Option NewChannelPool 的可选配置
+type Option func(*ChannelPool)
+
+// WithHealthCheck 设置空闲conn被取出时的健康检查, 检查失败的conn会被关闭
+func WithHealthCheck(check HealthCheck) Option {
+	return func(p *ChannelPool) {
+		p.healthCheck = check
+	}
+}
+
+// WithHealthCheckOnPut 放回conn时也执行取出时的检查(存活探测、凭据刷新和WithHealthCheck), 检查失败的conn被关闭而不放回pool;
+// 检查在后台执行, Put不等待, 检查期间conn不能被取出且仍占用容量单位, 数量见Stats的Validating
+func WithHealthCheckOnPut() Option {
+	return func(p *ChannelPool) {
+		p.checkOnPut = true
+	}
+}
+
+// WithHealthCheckTimeout 设置单次健康检查的超时时间, <= 0 不限制
+func WithHealthCheckTimeout(timeout time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.healthCheckTimeout = timeout
+	}
+}
+
+// WithHealthCheckHedge 健康检查超过delay仍未完成时, 并行检查另一个空闲conn, 最多同时检查maxParallel个
+func WithHealthCheckHedge(delay time.Duration, maxParallel int) Option {
+	return func(p *ChannelPool) {
+		p.hedgeDelay = delay
+		p.hedgeParallel = maxParallel
+	}
+}
+
+// WithCloseTimeout 设置pool关闭底层conn的超时时间, 超时后Close在后台继续执行, <= 0 不限制
+func WithCloseTimeout(timeout time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.closeTimeout = timeout
+	}
+}
+
+// WithCloseQueueSize 设置后台关闭队列长度, 队列已满时在新的goroutine中关闭, 不阻塞调用方
+func WithCloseQueueSize(size int) Option {
+	return func(p *ChannelPool) {
+		p.closeQueueSize = size
+	}
+}
+
+// WithOnCreate 设置新建conn后的回调, 可用于握手、鉴权等初始化
+func WithOnCreate(onCreate OnCreate) Option {
+	return func(p *ChannelPool) {
+		p.onCreate = onCreate
+	}
+}
+
+// WithWrapConn 设置新建conn的包装函数, 如TLS或RPC编解码, 在OnCreate之前调用;
+// PoolConn.RawConn 仍返回factory创建的底层conn
+func WithWrapConn(wrap func(net.Conn) net.Conn) Option {
+	return func(p *ChannelPool) {
+		p.wrapConn = wrap
+	}
+}
+
+// WithTCPKeepAlive 对新建的*net.TCPConn开启keepalive, period为探测间隔;
+// 空闲conn取出时经健康检查流程确认keepalive未发现对端失效
+func WithTCPKeepAlive(period time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.keepAlive = period
+	}
+}
+
+// WithPortExhaustionBackoff 设置本地端口耗尽(EADDRNOTAVAIL)后暂停新建的时长,
+// 从min开始每次连续耗尽翻倍直到max, 新建成功后重置; 默认100ms到5s
+func WithPortExhaustionBackoff(min, max time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.portBackoffMin = min
+		p.portBackoffMax = max
+	}
+}
+
+// WithOnPortExhausted 设置本地端口耗尽时的回调, 可用于告警
+func WithOnPortExhausted(onPortExhausted OnPortExhausted) Option {
+	return func(p *ChannelPool) {
+		p.onPortExhausted = onPortExhausted
+	}
+}
+
+// WithDialProbe 新建conn失败后(后端故障或正在恢复), 同一时间只允许一个新建作为探测,
+// 其他新建按policy等待探测结果或直接失败, 返回的error匹配ErrBackendUnavailable; 探测成功后恢复正常新建
+func WithDialProbe(policy ProbePolicy) Option {
+	return func(p *ChannelPool) {
+		p.dialProbe = true
+		p.probePolicy = policy
+	}
+}
+
+// WithCopyIdleTimeout 设置CopyTo/CopyFrom每次读写的超时时间, 每次读写前刷新conn的deadline
+func WithCopyIdleTimeout(timeout time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.copyIdleTimeout = timeout
+	}
+}
+
+// WithOnFull 设置Put因空闲已满关闭conn时的回调
+func WithOnFull(onFull OnFull) Option {
+	return func(p *ChannelPool) {
+		p.onFull = onFull
+	}
+}
+
+// WithChurnGuard window内新建超过limit个conn时调用onChurn, refuse为true时在window内拒绝继续新建并返回ErrChurnLimit
+func WithChurnGuard(limit int, window time.Duration, refuse bool, onChurn OnChurn) Option {
+	return func(p *ChannelPool) {
+		p.churnLimit = limit
+		p.churnWindow = window
+		p.churnRefuse = refuse
+		p.onChurn = onChurn
+	}
+}
+
+// WithMaxIdleTime 设置空闲conn的最大空闲时间, 超过的conn在取出时被关闭
+func WithMaxIdleTime(d time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.maxIdleTime = d
+	}
+}
+
+// WithMaxLifetime 设置conn的最大存活时间, 超过的conn在取出或放回时被关闭, 空闲的由后台定期关闭; 运行中可用SetConnMaxLifetime修改
+func WithMaxLifetime(d time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.maxLifetime = d
+	}
+}
+
+// WithMaxIdleTimeJitter 让每个conn的最大空闲时间和最大存活时间随机缩短至多jitter比例(0 <= jitter < 1),
+// 避免同时创建的conn在同一时刻过期并重新建立
+func WithMaxIdleTimeJitter(jitter float64) Option {
+	return func(p *ChannelPool) {
+		p.expiryJitter = jitter
+	}
+}
+
+// WithTLSExpiryMargin TLS conn在对端证书过期前margin淘汰, 默认在证书过期时淘汰
+func WithTLSExpiryMargin(margin time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.tlsExpiryMargin = margin
+	}
+}
+
+// WithRampUp 设置爬坡曲线, pool中conn全部关闭后重新建立或调用RampUp后,
+// 同时新建的conn数按curve逐步放开, 避免大量新建压垮正在恢复的后端
+func WithRampUp(curve RampCurve) Option {
+	return func(p *ChannelPool) {
+		p.rampCurve = curve
+	}
+}
+
+// WithWatchdog 开启等待检测, 有调用在等待conn且超过stall没有conn被放回或关闭时调用onStall,
+// 用于发现持有conn的死锁或泄漏
+func WithWatchdog(stall time.Duration, onStall OnStall) Option {
+	return func(p *ChannelPool) {
+		p.stallTimeout = stall
+		p.onStall = onStall
+	}
+}
+
+// WithBufferSize 设置GetBuffered的读写缓冲区大小, 默认4096
+func WithBufferSize(size int) Option {
+	return func(p *ChannelPool) {
+		p.bufferSize = size
+	}
+}
+
+// WithUnreadCheck 放回时检查conn上是否留有未读数据, 有数据的conn被关闭而不是复用,
+// 避免上一个使用方没有读完的响应被下一个使用方读到
+func WithUnreadCheck() Option {
+	return func(p *ChannelPool) {
+		p.unreadCheck = true
+	}
+}
+
+// WithGetInterceptor 添加获取conn的拦截器, 可多次调用, 先添加的在最外层
+func WithGetInterceptor(interceptor GetInterceptor) Option {
+	return func(p *ChannelPool) {
+		p.interceptors = append(p.interceptors, interceptor)
+	}
+}
+
+// WithConnTrace 为每个conn记录最近size个生命周期事件, 通过ConnTrace或DebugHandler查看
+func WithConnTrace(size int) Option {
+	return func(p *ChannelPool) {
+		p.traceSize = size
+	}
+}
+
+// WithHoldProfile 按rate比例采样取出conn的持有时间, 保留持有最久的topK个取出,
+// stacks为true时记录取出时的调用栈; 通过HoldProfile或DebugHandler查看
+func WithHoldProfile(rate float64, topK int, stacks bool) Option {
+	return func(p *ChannelPool) {
+		p.holdProfiler = &holdProfiler{rate: rate, topK: topK, stacks: stacks}
+	}
+}
+
+// WithAuth 设置conn的认证, 新建conn时调用auth获取凭据, 空闲conn取出时凭据将在refreshBefore内过期则重新认证,
+// 认证失败的conn被关闭
+func WithAuth(auth Authenticate, refreshBefore time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.auth = auth
+		p.authRefreshBefore = refreshBefore
+	}
+}
+
+// WithMaxPinned 限制通过GetPinned长期持有的conn数, 保证至少 maxConn-n 个conn留给普通的请求/响应,
+// n 须小于 maxConn
+func WithMaxPinned(n int) Option {
+	return func(p *ChannelPool) {
+		p.maxPinned = int64(n)
+	}
+}
+
+// WithReadinessProbe 设置Healthy在取出的conn上执行的检查, 如发送一次ping
+func WithReadinessProbe(probe HealthCheck) Option {
+	return func(p *ChannelPool) {
+		p.readinessProbe = probe
+	}
+}
+
+// WithInitialConns 设置创建pool时建立的conn数量, 取值范围 [0, 空闲conn上限], 默认为WithWarmIdle的数量, 未设置时为空闲conn上限
+func WithInitialConns(n int) Option {
+	return func(p *ChannelPool) {
+		p.initialConns = int64(n)
+		p.initialSet = true
+	}
+}
+
+// WithMaxIdle 设置Put时保留的空闲conn上限, 空闲已满时关闭放回的conn; 不能超过maxConn, 为0时使用maxFree.
+// 与WithWarmIdle一起使用时maxFree只作为默认值, 两者分别控制保留多少和保持多少空闲conn
+func WithMaxIdle(n int) Option {
+	return func(p *ChannelPool) {
+		p.maxIdle = int64(n)
+	}
+}
+
+// WithWarmIdle 设置保持的空闲conn数, 取值范围 [0, 空闲conn上限]; 空闲conn被取出后少于n时在后台新建补足,
+// 容量已满或有等待者时不新建; 同时作为创建pool时建立的conn数的默认值
+func WithWarmIdle(n int) Option {
+	return func(p *ChannelPool) {
+		p.warmIdle = n
+	}
+}
+
+// WithMaxFree 覆盖创建pool时的maxFree, 用于New和CloneWith
+func WithMaxFree(n int64) Option {
+	return func(p *ChannelPool) {
+		p.maxFree = n
+	}
+}
+
+// WithMaxConn 覆盖创建pool时的maxConn, 用于New和CloneWith
+func WithMaxConn(n int64) Option {
+	return func(p *ChannelPool) {
+		p.maxConn = n
+		p.autoMaxConn = false
+	}
+}
+
+// WithBestEffortFill 创建pool时初始conn新建失败不返回错误, 在后台按退避时间补建直到达到WithInitialConns的数量,
+// 每次补建调用onFill, onFill可以为nil
+func WithBestEffortFill(onFill OnFill) Option {
+	return func(p *ChannelPool) {
+		p.bestEffortFill = true
+		p.onFill = onFill
+	}
+}
+
+// WithUnlimitedConns 不限制conn总数, Get在没有空闲conn时总是新建, 此时maxConn须为0;
+// maxFree仍限制放回时保留的空闲conn数
+func WithUnlimitedConns() Option {
+	return func(p *ChannelPool) {
+		p.unlimited = true
+	}
+}
+
+// WithWeightedSemaphore 由外部的带权重信号量(如 golang.org/x/sync/semaphore.Weighted)决定pool容量,
+// 每个取出或正在新建的conn占用w的weight个单位, 空闲conn不占用; 设置后不再按maxConn限制conn总数,
+// 可与WithUnlimitedConns一起使用. 关闭pool不影响w
+func WithWeightedSemaphore(w poolcore.Weighted, weight int64) Option {
+	return func(p *ChannelPool) {
+		p.weighted = w
+		p.weight = weight
+	}
+}
+
+// WithSpinWait 容量已满时先让出CPU并重试spins次再进入等待队列, 用CPU换取conn很快放回时更低的获取延迟;
+// 只适合持有conn时间极短的场景, 见BenchmarkSpinWait
+func WithSpinWait(spins int) Option {
+	return func(p *ChannelPool) {
+		p.spinWait = spins
+	}
+}
+
+// WithQuarantine 同一后端的conn连续threshold次健康检查失败后隔离该后端minBackoff,
+// 隔离期内到该后端的空闲conn被关闭, 新建到该后端的conn返回ErrQuarantined;
+// 隔离结束后再次检查失败则隔离时长翻倍直到maxBackoff, 检查通过后解除. 通过Quarantine或DebugHandler查看
+func WithQuarantine(threshold int, minBackoff, maxBackoff time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.quarantine = &quarantine{threshold: threshold, min: minBackoff, max: maxBackoff, entries: make(map[string]*QuarantineEntry)}
+	}
+}
+
+// WithDegradeThreshold 连续threshold次新建或健康检查失败后进入降级状态并调用onDegrade, 第一次成功后恢复,
+// 通过Degraded查看; onDegrade可以为nil
+func WithDegradeThreshold(threshold int, onDegrade OnDegrade) Option {
+	return func(p *ChannelPool) {
+		p.degradeThreshold = threshold
+		p.onDegrade = onDegrade
+	}
+}
+
+// WithStrictAccounting 开启严格统计, Put发现重复放回或未知conn时返回AccountingError并计入Stats.Anomalies,
+// 而不是忽略; 适合在测试环境中发现使用方的bug
+func WithStrictAccounting() Option {
+	return func(p *ChannelPool) {
+		p.strictAccounting = true
+	}
+}
+
+// WithClosedPut 设置pool关闭后Put的处理方式, policy为ClosedPutCallback时onClosedPut不能为nil
+func WithClosedPut(policy ClosedPutPolicy, onClosedPut OnClosedPut) Option {
+	return func(p *ChannelPool) {
+		p.closedPutPolicy = policy
+		p.onClosedPut = onClosedPut
+	}
+}
+
+// WithTLSClient 用config将factory创建的conn包装为TLS客户端conn, 在WithWrapConn之前包装;
+// stage为HandshakeOnCheckout时预热只建立TCP连接, 握手推迟到第一次取出并受调用方ctx的deadline限制,
+// 耗时记录在PoolConn.Acquisition的HandshakeDuration中
+func WithTLSClient(config *tls.Config, stage HandshakeStage) Option {
+	return func(p *ChannelPool) {
+		p.tlsConfig = config
+		p.handshakeStage = stage
+	}
+}
+
+// WithName 设置pool名称, 作为指标的pool标签
+func WithName(name string) Option {
+	return func(p *ChannelPool) {
+		p.name = name
+	}
+}
+
+// WithMetricsConfig 设置WriteMetrics附加的标签和输出的指标, 多后端时用于控制标签基数
+func WithMetricsConfig(config MetricsConfig) Option {
+	return func(p *ChannelPool) {
+		p.metricsConfig = config
+	}
+}
+
+// WithBackendTrace 每次成功取出conn后调用onBackend, 传入调用方的ctx和conn的对端地址, 用于在请求的trace中记录后端;
+// 与WithGetInterceptor一样按添加顺序加入拦截器链
+func WithBackendTrace(onBackend OnBackend) Option {
+	return func(p *ChannelPool) {
+		p.interceptors = append(p.interceptors, p.backendInterceptor(onBackend))
+	}
+}
+
+// WithGetErrorContext Get失败时返回GetError, 附带pool名称、失败时的取出/空闲/等待数和最近一次新建失败的错误;
+// 原来的错误仍可用errors.Is判断
+func WithGetErrorContext() Option {
+	return func(p *ChannelPool) {
+		p.getErrContext = true
+	}
+}
+
+// WithLivenessProbe 空闲conn取出时先用probe探测对端是否存活, 放回时WithUnreadCheck也使用probe;
+// 未设置时只对开启keepalive的TCP conn使用PeekLiveness
+func WithLivenessProbe(probe LivenessProbe) Option {
+	return func(p *ChannelPool) {
+		p.liveness = probe
+	}
+}
+
+// WithWorkloadRecorder 把每次取出conn的时间(见WorkloadRecord)以JSON Lines格式在后台写入w, 写入跟不上时丢弃记录;
+// pool关闭后写完缓冲的记录, w由调用方关闭, 应在WaitStopped返回后关闭. 记录可以用ReplayWorkload回放
+func WithWorkloadRecorder(w io.Writer) Option {
+	return func(p *ChannelPool) {
+		// CloneWith继承的配置不创建recorder, 避免两个pool同时写入w
+		if p.inheriting {
+			return
+		}
+		p.workload = newWorkloadRecorder(w)
+	}
+}
+
+// WithDevelopmentMode 发现误用时panic, panic的值为*MisuseError, 包含相关conn的取出、放回和误用的调用位置;
+// 检测放回其他pool或已关闭的conn、重复放回、放回后继续使用PoolConn和pool关闭后获取conn.
+// 每次取出都会新建PoolConn并记录调用位置, 只应在开发和测试中开启
+func WithDevelopmentMode() Option {
+	return func(p *ChannelPool) {
+		p.devMode = true
+	}
+}
+
+// WithDialRetry 新建conn时后端不可用(连接失败、处于隔离期、握手或认证失败)则重新调用factory, 共最多尝试maxTries次, <= 1 不重试;
+// 用于factory每次连接不同后端的情况, 如TCPFactory配合WithResolver时每次从下一个地址开始.
+// ctx有截止时间时每次尝试最多使用剩余时间的1/剩余次数, 避免无响应的后端耗尽调用方的全部时间
+func WithDialRetry(maxTries int) Option {
+	return func(p *ChannelPool) {
+		p.dialTries = maxTries
+	}
+}
+
+// WithStandby 在后台保持n个连接备用后端(如另一个区域)的conn, 平时不使用, 不计入Open和maxConn;
+// 调用Failover时备用conn直接成为空闲conn, 切换后不需要等待新建. 备用conn同样经过TLS握手、OnCreate和认证,
+// 并定期检查对端是否存活; n为0时Failover只切换factory
+func WithStandby(factory FactoryContext, n int) Option {
+	return func(p *ChannelPool) {
+		p.standbyFactory = factory
+		p.standbySize = n
+	}
+}
+
+// WithBudget 加入共享conn总数上限的Budget, 新建conn占用其名额; dest标识连接的后端,
+// 名额用完时从dest相同的其他成员转来空闲conn代替新建, dest为空时只计入名额不参与转移
+func WithBudget(b *Budget, dest string) Option {
+	return func(p *ChannelPool) {
+		p.budget = b
+		p.budgetDest = dest
+	}
+}
+
+// WithDefaultGetTimeout 设置Get的超时时间, 只作用于不带ctx的Get, <= 0 不限制
+func WithDefaultGetTimeout(timeout time.Duration) Option {
+	return func(p *ChannelPool) {
+		p.getTimeout = timeout
+	}
+}
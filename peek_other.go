@@ -0,0 +1,10 @@
+//go:build !unix
+
+package pool
+
+import "net"
+
+// peekUnread 查看conn上是否有未读数据, 非unix平台上短暂读取conn
+func peekUnread(conn net.Conn) error {
+	return probeAlive(conn)
+}
@@ -0,0 +1,99 @@
+package pool
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPeekUnread(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+	client, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	server := <-accepted
+
+	if err := peekUnread(client); err != nil {
+		t.Errorf("peekUnread error. Expecting nil, got %v", err)
+	}
+
+	server.Write([]byte("x"))
+	time.Sleep(time.Millisecond * 20)
+	// 查看不消耗数据
+	for i := 0; i < 2; i++ {
+		if err := peekUnread(client); err != ErrUnexpectedRead {
+			t.Errorf("peekUnread error. Expecting %v, got %v", ErrUnexpectedRead, err)
+		}
+	}
+	b := make([]byte, 1)
+	if _, err := client.Read(b); err != nil || b[0] != 'x' {
+		t.Errorf("Read error. Expecting %q, got %q %v", "x", b, err)
+	}
+
+	server.Close()
+	time.Sleep(time.Millisecond * 20)
+	if err := peekUnread(client); err != io.EOF {
+		t.Errorf("peekUnread error. Expecting %v, got %v", io.EOF, err)
+	}
+}
+
+func TestChannelPool_UnreadCheck(t *testing.T) {
+	p, err := NewChannelPool(2, 2, factory, WithUnreadCheck())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 读完响应的conn被复用
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write error: %s", err)
+	}
+	buf := make([]byte, 256)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("Read error: %s", err)
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 2 {
+		t.Errorf("Put error. Expecting %d, got %d", 2, p.Len())
+	}
+
+	// 没读响应的conn被关闭
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write error: %s", err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+	if p.Len() != 1 || p.OpenNum() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d idle %d open", 1, p.Len(), p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonUnreadData]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
@@ -0,0 +1,47 @@
+//go:build unix
+
+package pool
+
+import (
+	"io"
+	"net"
+	"syscall"
+)
+
+// peekUnread 不阻塞地查看conn上是否有未读数据, 不消耗数据;
+// 有数据时返回ErrUnexpectedRead, 对端已关闭时返回io.EOF
+func peekUnread(conn net.Conn) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return probeAlive(conn)
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var (
+		n    int
+		perr error
+		b    [1]byte
+	)
+	err = rc.Read(func(fd uintptr) bool {
+		n, _, perr = syscall.Recvfrom(int(fd), b[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		// 不等待可读
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case perr == syscall.EAGAIN || perr == syscall.EWOULDBLOCK:
+		return nil
+	case perr != nil:
+		return perr
+	case n > 0:
+		return ErrUnexpectedRead
+	default:
+		return io.EOF
+	}
+}
@@ -0,0 +1,67 @@
+package pool
+
+import (
+	"context"
+	"net"
+)
+
+type pinnedKey struct{}
+
+// pinTicket 一次GetPinned持有的长期持有名额
+type pinTicket struct {
+	used bool // 名额已交给取出的conn, 由conn放回或关闭时归还
+}
+
+// GetPinned 获取长期持有的conn, 如订阅、通知通道; 不计入Hits/Misses和持有时间采样,
+// 在Stats中单独统计, 用完后同样通过Put放回. 设置了WithMaxPinned时长期持有的conn数达到上限后等待
+func (p *ChannelPool) GetPinned(ctx context.Context) (net.Conn, error) {
+	if p.pinSem != nil {
+		if err := p.pinSem.Acquire(ctx, 1); err != nil {
+			if err == ErrClosed {
+				return nil, ErrClosed
+			}
+			return nil, timeoutErr(ctx)
+		}
+	}
+
+	ticket := &pinTicket{}
+	conn, err := p.GetWitchContext(p.withCaller(context.WithValue(ctx, pinnedKey{}, ticket), 2))
+	if err != nil && p.pinSem != nil {
+		p.mu.Lock()
+		used := ticket.used
+		p.mu.Unlock()
+		if !used {
+			p.pinSem.Release(1)
+		}
+	}
+	return conn, err
+}
+
+// isPinned ctx是否来自GetPinned
+func isPinned(ctx context.Context) bool {
+	_, ok := ctx.Value(pinnedKey{}).(*pinTicket)
+	return ok
+}
+
+// pin 标记conn被长期持有, 名额交给conn, 需持有p.mu
+func (p *ChannelPool) pin(ctx context.Context, m *connMeta) {
+	ticket, ok := ctx.Value(pinnedKey{}).(*pinTicket)
+	if !ok || m == nil || m.pinned {
+		return
+	}
+	ticket.used = true
+	m.pinned = true
+	p.pinnedNum++
+}
+
+// unpin conn放回或关闭时取消标记并归还名额, 需持有p.mu
+func (p *ChannelPool) unpin(m *connMeta) {
+	if !m.pinned {
+		return
+	}
+	m.pinned = false
+	p.pinnedNum--
+	if p.pinSem != nil {
+		p.pinSem.Release(1)
+	}
+}
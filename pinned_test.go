@@ -0,0 +1,138 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_GetPinned(t *testing.T) {
+	p, err := NewChannelPool(2, 3, factory, WithHoldProfile(1, 4, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	pinned, err := p.GetPinned(context.Background())
+	if err != nil {
+		t.Fatalf("GetPinned error: %s", err)
+	}
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	s := p.Stats()
+	if s.Pinned != 1 || s.InUse != 2 {
+		t.Errorf("Stats error. Expecting %d pinned %d inUse, got %d %d", 1, 2, s.Pinned, s.InUse)
+	}
+	if s.PinnedGets != 1 || s.Hits != 1 || s.Misses != 0 {
+		t.Errorf("Stats error. Expecting %d pinnedGets %d hits %d misses, got %d %d %d",
+			1, 1, 0, s.PinnedGets, s.Hits, s.Misses)
+	}
+	// 长期持有的conn不参与持有时间采样
+	if hp := p.HoldProfile(); len(hp.Current) != 1 {
+		t.Errorf("HoldProfile error. Expecting %d current, got %d", 1, len(hp.Current))
+	}
+
+	p.Put(conn)
+	p.Put(pinned)
+	if s := p.Stats(); s.Pinned != 0 {
+		t.Errorf("Stats error. Expecting %d pinned, got %d", 0, s.Pinned)
+	}
+	if hp := p.HoldProfile(); hp.Holds.Count != 1 {
+		t.Errorf("HoldProfile error. Expecting %d holds, got %d", 1, hp.Holds.Count)
+	}
+
+	// 丢弃长期持有的conn同样取消标记
+	pinned, err = p.GetPinned(context.Background())
+	if err != nil {
+		t.Fatalf("GetPinned error: %s", err)
+	}
+	p.Discard(pinned)
+	if s := p.Stats(); s.Pinned != 0 || s.PinnedGets != 2 {
+		t.Errorf("Stats error. Expecting %d pinned %d pinnedGets, got %d %d", 0, 2, s.Pinned, s.PinnedGets)
+	}
+}
+
+func TestChannelPool_MaxPinned(t *testing.T) {
+	p, err := NewChannelPool(2, 3, factory, WithMaxPinned(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	pinned, err := p.GetPinned(context.Background())
+	if err != nil {
+		t.Fatalf("GetPinned error: %s", err)
+	}
+
+	// 长期持有名额已满, 普通请求不受影响
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	if _, err := p.GetPinned(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("GetPinned error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	var conns []net.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		p.Put(conn)
+	}
+
+	// 放回后名额归还
+	done := make(chan error, 1)
+	go func() {
+		conn, err := p.GetPinned(context.Background())
+		if err == nil {
+			p.Put(conn)
+		}
+		done <- err
+	}()
+	time.Sleep(time.Millisecond * 20)
+	p.Put(pinned)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("GetPinned error: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("GetPinned error. Expecting woken after Put")
+	}
+	if s := p.Stats(); s.Pinned != 0 || p.pinSem.Held() != 0 {
+		t.Errorf("Stats error. Expecting %d pinned, got %d (%d held)", 0, s.Pinned, p.pinSem.Held())
+	}
+}
+
+func TestChannelPool_MaxPinnedFailedGet(t *testing.T) {
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		return nil, errors.New("dial failed")
+	}, WithInitialConns(0), WithMaxPinned(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 获取失败时归还名额
+	if _, err := p.GetPinned(context.Background()); err == nil {
+		t.Fatalf("GetPinned error. Expecting dial error")
+	}
+	if p.pinSem.Held() != 0 {
+		t.Errorf("GetPinned error. Expecting %d held, got %d", 0, p.pinSem.Held())
+	}
+}
+
+func TestNew_InvalidMaxPinned(t *testing.T) {
+	for _, n := range []int{-1, 3} {
+		if _, err := NewChannelPool(2, 3, factory, WithMaxPinned(n)); err == nil {
+			t.Errorf("New error. Expecting max pinned %d rejected", n)
+		}
+	}
+}
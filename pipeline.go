@@ -0,0 +1,134 @@
+package pool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrPipelineClosed   = errors.New("pipeline closed")
+	ErrNoPendingRequest = errors.New("no pending request")
+)
+
+// Pipeline 在一个取出的conn上批量发送请求, 再按顺序读取响应, 用于支持流水线的协议;
+// 响应全部读完且没有出错时Close把conn放回pool, 否则关闭conn. 不能并发使用
+type Pipeline struct {
+	conn *BufferedPoolConn
+
+	pending int // 已发送未读取响应的请求数
+
+	unflushed bool // 写缓冲中有未发送的请求
+
+	err error // 第一次出错的error, 出错后conn不再复用
+
+	closed bool
+}
+
+// Pipeline 取出conn用于流水线请求, ctx的截止时间作为conn的deadline, Close时清除
+func (p *ChannelPool) Pipeline(ctx context.Context) (*Pipeline, error) {
+	conn, err := p.GetBuffered(p.withCaller(ctx, 2))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.discard(CloseReasonBroken)
+			return nil, err
+		}
+	}
+	return &Pipeline{conn: conn}, nil
+}
+
+// Conn 返回流水线使用的conn
+func (pl *Pipeline) Conn() *BufferedPoolConn {
+	return pl.conn
+}
+
+// Send 写入一个完整的请求, 请求先进入写缓冲, 在Flush或Receive时发送
+func (pl *Pipeline) Send(req []byte) error {
+	if err := pl.check(); err != nil {
+		return err
+	}
+	if _, err := pl.conn.Writer.Write(req); err != nil {
+		return pl.fail(err)
+	}
+	pl.pending++
+	pl.unflushed = true
+	return nil
+}
+
+// Flush 发送写缓冲中的请求
+func (pl *Pipeline) Flush() error {
+	if err := pl.check(); err != nil {
+		return err
+	}
+	if !pl.unflushed {
+		return nil
+	}
+	if err := pl.conn.Writer.Flush(); err != nil {
+		return pl.fail(err)
+	}
+	pl.unflushed = false
+	return nil
+}
+
+// Receive 读取下一个响应, read应从r中读取恰好一个完整的响应; 有未发送的请求时先发送.
+// read返回error时认为conn上的协议状态未知, 流水线结束
+func (pl *Pipeline) Receive(read func(r *bufio.Reader) error) error {
+	if err := pl.Flush(); err != nil {
+		return err
+	}
+	if pl.pending == 0 {
+		return ErrNoPendingRequest
+	}
+	if err := read(pl.conn.Reader); err != nil {
+		return pl.fail(err)
+	}
+	pl.pending--
+	return nil
+}
+
+// Pending 已发送未读取响应的请求数
+func (pl *Pipeline) Pending() int {
+	return pl.pending
+}
+
+// Close 结束流水线, 响应全部读完且没有出错时放回conn, 否则关闭conn; 返回流水线中第一次出错的error
+func (pl *Pipeline) Close() error {
+	if pl.closed {
+		return ErrPipelineClosed
+	}
+	pl.closed = true
+
+	switch {
+	case pl.err != nil:
+		pl.conn.discard(CloseReasonBroken)
+		return pl.err
+	case pl.pending > 0:
+		// 还有响应未读, conn不能给下一个使用方
+		pl.conn.discard(CloseReasonUnreadData)
+		return nil
+	}
+	if err := pl.conn.SetDeadline(time.Time{}); err != nil {
+		pl.conn.discard(CloseReasonBroken)
+		return err
+	}
+	return pl.conn.p.Put(pl.conn)
+}
+
+func (pl *Pipeline) check() error {
+	switch {
+	case pl.closed:
+		return ErrPipelineClosed
+	case pl.err != nil:
+		return pl.err
+	}
+	return nil
+}
+
+func (pl *Pipeline) fail(err error) error {
+	pl.err = err
+	return err
+}
@@ -0,0 +1,136 @@
+package pool
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// lineEchoServer 按行回显的server, 支持流水线请求
+func lineEchoServer(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadBytes('\n')
+					if err != nil {
+						return
+					}
+					if _, err := conn.Write(line); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func readLine(got *[]string) func(r *bufio.Reader) error {
+	return func(r *bufio.Reader) error {
+		line, err := r.ReadString('\n')
+		if err == nil {
+			*got = append(*got, line)
+		}
+		return err
+	}
+}
+
+func TestChannelPool_Pipeline(t *testing.T) {
+	p, err := NewChannelPool(1, 2, TCPFactory("tcp", lineEchoServer(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	pl, err := p.Pipeline(context.Background())
+	if err != nil {
+		t.Fatalf("Pipeline error: %s", err)
+	}
+	for _, req := range []string{"a\n", "b\n", "c\n"} {
+		if err := pl.Send([]byte(req)); err != nil {
+			t.Fatalf("Send error: %s", err)
+		}
+	}
+	var got []string
+	for pl.Pending() > 0 {
+		if err := pl.Receive(readLine(&got)); err != nil {
+			t.Fatalf("Receive error: %s", err)
+		}
+	}
+	if len(got) != 3 || got[0] != "a\n" || got[2] != "c\n" {
+		t.Errorf("Receive error. Expecting [a b c], got %q", got)
+	}
+	if err := pl.Receive(readLine(&got)); err != ErrNoPendingRequest {
+		t.Errorf("Receive error. Expecting %v, got %v", ErrNoPendingRequest, err)
+	}
+	if err := pl.Close(); err != nil {
+		t.Errorf("Close error: %s", err)
+	}
+	if p.Len() != 1 || p.OpenNum() != 1 {
+		t.Errorf("Close error. Expecting conn returned, got %d idle %d open", p.Len(), p.OpenNum())
+	}
+	if err := pl.Send([]byte("d\n")); err != ErrPipelineClosed {
+		t.Errorf("Send error. Expecting %v, got %v", ErrPipelineClosed, err)
+	}
+
+	// 还有响应未读时关闭conn
+	pl, err = p.Pipeline(context.Background())
+	if err != nil {
+		t.Fatalf("Pipeline error: %s", err)
+	}
+	pl.Send([]byte("a\n"))
+	pl.Flush()
+	if err := pl.Close(); err != nil {
+		t.Errorf("Close error: %s", err)
+	}
+	if p.OpenNum() != 0 {
+		t.Errorf("Close error. Expecting %d, got %d", 0, p.OpenNum())
+	}
+	if h := p.ConnAgeStats()[CloseReasonUnreadData]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
+
+func TestChannelPool_PipelineDeadline(t *testing.T) {
+	p, err := NewChannelPool(1, 2, TCPFactory("tcp", lineEchoServer(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	pl, err := p.Pipeline(ctx)
+	if err != nil {
+		t.Fatalf("Pipeline error: %s", err)
+	}
+	// 没有换行, server不会响应
+	pl.Send([]byte("a"))
+	var got []string
+	err = pl.Receive(readLine(&got))
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Receive error. Expecting timeout, got %v", err)
+	}
+	if cerr := pl.Close(); cerr != err {
+		t.Errorf("Close error. Expecting %v, got %v", err, cerr)
+	}
+	if h := p.ConnAgeStats()[CloseReasonBroken]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+}
@@ -1,12 +1,16 @@
 package pool
 
 import (
+	"context"
 	"errors"
 	"net"
+
+	"ConnPool/poolcore"
 )
 
 var (
-	ErrClosed = errors.New("pool is closed")
+	ErrClosed       = poolcore.ErrClosed
+	ErrNotSupported = errors.New("operation not supported")
 )
 
 type Pool interface {
@@ -18,3 +22,23 @@ type Pool interface {
 	//Close 关闭管理的所有conn，pool不可用
 	Close() error
 }
+
+// PoolV2 第二版pool接口, 所有可能阻塞的操作都接受ctx
+type PoolV2 interface {
+	//GetContext 获得conn, ctx结束时返回超时错误
+	GetContext(ctx context.Context) (net.Conn, error)
+
+	Put(net.Conn) error
+
+	//Stats 返回pool当前的统计信息
+	Stats() Stats
+
+	//Warmup 建立conn直到至少有n个空闲conn
+	Warmup(ctx context.Context, n int) error
+
+	//Drain 淘汰当前所有conn, 空闲的立即关闭, 取出的放回时关闭, 之后获取的是新建的conn
+	Drain(ctx context.Context) error
+
+	//CloseContext 关闭pool并等待取出的conn放回
+	CloseContext(ctx context.Context) error
+}
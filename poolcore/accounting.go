@@ -0,0 +1,27 @@
+package poolcore
+
+// Accounting conn数量统计; 不是并发安全的, 由调用方加锁
+type Accounting struct {
+	Open int64 // 已创建未关闭的conn数
+
+	Created int64 // 累计创建的conn数
+
+	Closed int64 // 累计关闭的conn数
+}
+
+// Add 记录新建一个conn
+func (a *Accounting) Add() {
+	a.Open++
+	a.Created++
+}
+
+// Remove 记录关闭一个conn
+func (a *Accounting) Remove() {
+	a.Open--
+	a.Closed++
+}
+
+// InUse 除去idle个空闲conn后正在使用的conn数
+func (a *Accounting) InUse(idle int) int {
+	return int(a.Open) - idle
+}
@@ -0,0 +1,16 @@
+package poolcore
+
+import "testing"
+
+func TestAccounting(t *testing.T) {
+	var a Accounting
+	a.Add()
+	a.Add()
+	a.Remove()
+	if a.Open != 1 || a.Created != 2 || a.Closed != 1 {
+		t.Errorf("Accounting error. Expecting 1/2/1, got %d/%d/%d", a.Open, a.Created, a.Closed)
+	}
+	if a.InUse(1) != 0 {
+		t.Errorf("InUse error. Expecting %d, got %d", 0, a.InUse(1))
+	}
+}
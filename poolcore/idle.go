@@ -0,0 +1,54 @@
+package poolcore
+
+import "net"
+
+// IdleList 空闲conn列表, 按放回顺序取出(FIFO), 使每个conn都能被轮到, 空闲超时更准确;
+// 不是并发安全的, 由调用方加锁
+type IdleList struct {
+	conns []net.Conn
+}
+
+// Len 空闲conn数
+func (l *IdleList) Len() int {
+	return len(l.conns)
+}
+
+// Push 放回conn
+func (l *IdleList) Push(conn net.Conn) {
+	l.conns = append(l.conns, conn)
+}
+
+// Pop 取出最早放回的满足match的conn, match为nil时取出最早放回的conn
+func (l *IdleList) Pop(match func(net.Conn) bool) (net.Conn, bool) {
+	for i, conn := range l.conns {
+		if match != nil && !match(conn) {
+			continue
+		}
+		if i == 0 {
+			l.conns[0] = nil
+			l.conns = l.conns[1:]
+		} else {
+			copy(l.conns[i:], l.conns[i+1:])
+			l.conns[len(l.conns)-1] = nil
+			l.conns = l.conns[:len(l.conns)-1]
+		}
+		return conn, true
+	}
+	return nil, false
+}
+
+// Range 按放回顺序遍历空闲conn, f返回false时停止, 遍历期间不能修改列表
+func (l *IdleList) Range(f func(conn net.Conn) bool) {
+	for _, conn := range l.conns {
+		if !f(conn) {
+			return
+		}
+	}
+}
+
+// Drain 取出全部空闲conn
+func (l *IdleList) Drain() []net.Conn {
+	conns := l.conns
+	l.conns = nil
+	return conns
+}
@@ -0,0 +1,46 @@
+package poolcore
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIdleList(t *testing.T) {
+	var l IdleList
+	conns := make([]net.Conn, 3)
+	for i := range conns {
+		c, s := net.Pipe()
+		defer c.Close()
+		defer s.Close()
+		conns[i] = c
+		l.Push(c)
+	}
+	if l.Len() != 3 {
+		t.Errorf("Len error. Expecting %d, got %d", 3, l.Len())
+	}
+
+	// 按放回顺序取出
+	if conn, ok := l.Pop(nil); !ok || conn != conns[0] {
+		t.Errorf("Pop error. Expecting first conn, got %v %t", conn, ok)
+	}
+	// 跳过不满足match的conn
+	conn, ok := l.Pop(func(c net.Conn) bool { return c == conns[2] })
+	if !ok || conn != conns[2] {
+		t.Errorf("Pop error. Expecting last conn, got %v %t", conn, ok)
+	}
+	if _, ok := l.Pop(func(net.Conn) bool { return false }); ok {
+		t.Errorf("Pop error. Expecting no match")
+	}
+
+	n := 0
+	l.Range(func(net.Conn) bool {
+		n++
+		return true
+	})
+	if n != 1 {
+		t.Errorf("Range error. Expecting %d, got %d", 1, n)
+	}
+	if drained := l.Drain(); len(drained) != 1 || drained[0] != conns[1] || l.Len() != 0 {
+		t.Errorf("Drain error. Expecting [conn1], got %v, %d left", drained, l.Len())
+	}
+}
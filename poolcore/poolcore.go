@@ -0,0 +1,12 @@
+// Package poolcore 连接池的基础组件: 容量等待队列、空闲conn列表和conn数量统计.
+//
+// ConnPool 由这些组件组装而成, 需要自定义pool(如按stream复用的QUIC连接)时可以直接使用,
+// 不必重新实现等待和唤醒等并发逻辑.
+package poolcore
+
+import "errors"
+
+var (
+	// ErrClosed 等待队列或pool已关闭
+	ErrClosed = errors.New("pool is closed")
+)
@@ -0,0 +1,198 @@
+package poolcore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// WaitQueue 容量单位的等待队列, 等待者按FIFO顺序获得
+type WaitQueue interface {
+	// Acquire 获得n个单位, 阻塞直到获得、ctx结束或队列关闭
+	Acquire(ctx context.Context, n int64) error
+
+	// TryAcquire 不阻塞地获得n个单位, 有等待者时不插队
+	TryAcquire(n int64) bool
+
+	// Release 归还n个单位
+	Release(n int64)
+
+	// Close 关闭队列, 等待者返回ErrClosed
+	Close()
+
+	// Waiters 等待者数量
+	Waiters() int
+
+	// Held 已被获得的单位数
+	Held() int64
+
+	// Counters 返回等待者直接获得容量的次数和等待者被唤醒的次数
+	Counters() (handoffs, wakeups int64)
+
+	// OverReleases 归还的单位数超过已获得的单位数的次数, 通常说明重复归还; 多出的部分被忽略
+	OverReleases() int64
+}
+
+// Semaphore 带权重的信号量, 每个等待者一个channel, 归还时直接交给队首的等待者;
+// 高并发下比CondSemaphore快(见BenchmarkWaitQueue), 是pool使用的实现
+type Semaphore struct {
+	mu      sync.Mutex
+	size    int64
+	cur     int64
+	waiters list.List
+	closed  bool
+
+	handoffs int64 // 需持有mu
+
+	wakeups int64 // 原子访问
+
+	overReleases int64 // 需持有mu
+}
+
+type semWaiter struct {
+	n     int64
+	ready chan struct{} // 获得或信号量关闭时close
+	err   error         // 信号量关闭时为ErrClosed
+}
+
+func NewSemaphore(n int64) *Semaphore {
+	return &Semaphore{size: n}
+}
+
+// Acquire 获得n个单位, 阻塞直到获得、ctx结束或信号量关闭
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return ErrClosed
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	w := &semWaiter{n: n, ready: make(chan struct{})}
+	elem := s.waiters.PushBack(w)
+	s.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		atomic.AddInt64(&s.wakeups, 1)
+		s.mu.Lock()
+		select {
+		case <-w.ready:
+			// ctx结束的同时获得了, 视为未获得
+			if w.err == nil {
+				s.cur -= n
+				s.notifyWaiters()
+			}
+		default:
+			isFront := s.waiters.Front() == elem
+			s.waiters.Remove(elem)
+			// 队首放弃后后面的等待者可能可以获得
+			if isFront && s.size > s.cur {
+				s.notifyWaiters()
+			}
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+
+	case <-w.ready:
+		atomic.AddInt64(&s.wakeups, 1)
+		return w.err
+	}
+}
+
+// TryAcquire 不阻塞地获得n个单位, 有等待者时不插队
+func (s *Semaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.size-s.cur < n || s.waiters.Len() > 0 {
+		return false
+	}
+	s.cur += n
+	return true
+}
+
+// Release 归还n个单位
+func (s *Semaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n > s.cur {
+		s.overReleases++
+		n = s.cur
+	}
+	s.cur -= n
+	s.notifyWaiters()
+}
+
+// Close 关闭信号量, 等待者返回ErrClosed, 之后的Acquire直接返回ErrClosed
+func (s *Semaphore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	for e := s.waiters.Front(); e != nil; e = e.Next() {
+		w := e.Value.(*semWaiter)
+		w.err = ErrClosed
+		close(w.ready)
+	}
+	s.waiters.Init()
+}
+
+// Waiters 等待者数量
+func (s *Semaphore) Waiters() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len()
+}
+
+// Held 已被获得的单位数
+func (s *Semaphore) Held() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cur
+}
+
+// Counters 实现WaitQueue
+func (s *Semaphore) Counters() (handoffs, wakeups int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handoffs, atomic.LoadInt64(&s.wakeups)
+}
+
+// OverReleases 实现WaitQueue
+func (s *Semaphore) OverReleases() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.overReleases
+}
+
+// notifyWaiters 按顺序唤醒可以获得的等待者, 需持有s.mu
+func (s *Semaphore) notifyWaiters() {
+	if s.closed {
+		return
+	}
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			return
+		}
+		w := next.Value.(*semWaiter)
+		// 队首不够时不唤醒后面的, 避免大权重的等待者饿死
+		if s.size-s.cur < w.n {
+			return
+		}
+		s.cur += w.n
+		s.handoffs++
+		s.waiters.Remove(next)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,172 @@
+package poolcore
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// CondSemaphore 用sync.Cond实现的等待队列, 归还时广播唤醒所有等待者, 由队首的等待者获得;
+// 每次唤醒都会惊醒全部等待者, 高并发下慢于semaphore, 只用于对比
+type CondSemaphore struct {
+	mu      sync.Mutex
+	cond    sync.Cond
+	size    int64
+	cur     int64
+	waiters list.List
+	closed  bool
+
+	handoffs     int64
+	wakeups      int64
+	overReleases int64
+}
+
+type condWaiter struct {
+	n       int64
+	granted bool
+}
+
+func NewCondSemaphore(n int64) *CondSemaphore {
+	s := &CondSemaphore{size: n}
+	s.cond.L = &s.mu
+	return s
+}
+
+// Acquire 获得n个单位, 阻塞直到获得、ctx结束或信号量关闭
+func (s *CondSemaphore) Acquire(ctx context.Context, n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return nil
+	}
+
+	w := &condWaiter{n: n}
+	elem := s.waiters.PushBack(w)
+
+	// sync.Cond 不能等待ctx, ctx结束时广播唤醒
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.cond.Broadcast()
+			s.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	for !w.granted && !s.closed && ctx.Err() == nil {
+		s.cond.Wait()
+		s.wakeups++
+	}
+	switch {
+	case w.granted:
+		return nil
+	case s.closed:
+		return ErrClosed
+	}
+	isFront := s.waiters.Front() == elem
+	s.waiters.Remove(elem)
+	if isFront && s.size > s.cur {
+		s.notifyWaiters()
+	}
+	return ctx.Err()
+}
+
+// TryAcquire 不阻塞地获得n个单位, 有等待者时不插队
+func (s *CondSemaphore) TryAcquire(n int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.size-s.cur < n || s.waiters.Len() > 0 {
+		return false
+	}
+	s.cur += n
+	return true
+}
+
+// Release 归还n个单位
+func (s *CondSemaphore) Release(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n > s.cur {
+		s.overReleases++
+		n = s.cur
+	}
+	s.cur -= n
+	s.notifyWaiters()
+}
+
+// Close 关闭信号量, 等待者返回ErrClosed, 之后的Acquire直接返回ErrClosed
+func (s *CondSemaphore) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.waiters.Init()
+	s.cond.Broadcast()
+}
+
+// Waiters 等待者数量
+func (s *CondSemaphore) Waiters() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.waiters.Len()
+}
+
+// Held 已被获得的单位数
+func (s *CondSemaphore) Held() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cur
+}
+
+// Counters 实现WaitQueue
+func (s *CondSemaphore) Counters() (handoffs, wakeups int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.handoffs, s.wakeups
+}
+
+// OverReleases 实现WaitQueue
+func (s *CondSemaphore) OverReleases() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.overReleases
+}
+
+// notifyWaiters 按顺序把容量交给可以获得的等待者并广播, 需持有s.mu
+func (s *CondSemaphore) notifyWaiters() {
+	if s.closed {
+		return
+	}
+	granted := false
+	for {
+		next := s.waiters.Front()
+		if next == nil {
+			break
+		}
+		w := next.Value.(*condWaiter)
+		if s.size-s.cur < w.n {
+			break
+		}
+		s.cur += w.n
+		s.handoffs++
+		w.granted = true
+		s.waiters.Remove(next)
+		granted = true
+	}
+	if granted {
+		s.cond.Broadcast()
+	}
+}
@@ -0,0 +1,164 @@
+package poolcore
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// implementations 参与测试和对比的等待队列实现
+var implementations = []struct {
+	name string
+	new  func(n int64) WaitQueue
+}{
+	{"chan", func(n int64) WaitQueue { return NewSemaphore(n) }},
+	{"cond", func(n int64) WaitQueue { return NewCondSemaphore(n) }},
+	{"weighted", func(n int64) WaitQueue { return NewWeightedQueue(NewSemaphore(n), 1) }},
+}
+
+func TestSemaphore(t *testing.T) {
+	for _, q := range implementations {
+		t.Run(q.name, func(t *testing.T) {
+			s := q.new(2)
+
+			if err := s.Acquire(context.Background(), 2); err != nil {
+				t.Fatalf("Acquire error: %s", err)
+			}
+			if s.TryAcquire(1) {
+				t.Errorf("TryAcquire error. Expecting false when full")
+			}
+
+			// 等待者按FIFO顺序获得
+			order := make(chan int, 2)
+			for i := 1; i <= 2; i++ {
+				go func(i int) {
+					if err := s.Acquire(context.Background(), 1); err != nil {
+						t.Errorf("Acquire error: %s", err)
+					}
+					order <- i
+				}(i)
+				for s.Waiters() != i {
+					time.Sleep(time.Millisecond)
+				}
+			}
+
+			s.Release(1)
+			if i := <-order; i != 1 {
+				t.Errorf("Acquire error. Expecting waiter %d first, got %d", 1, i)
+			}
+			s.Release(1)
+			if i := <-order; i != 2 {
+				t.Errorf("Acquire error. Expecting waiter %d, got %d", 2, i)
+			}
+			if s.Held() != 2 {
+				t.Errorf("Held error. Expecting %d, got %d", 2, s.Held())
+			}
+			if handoffs, wakeups := s.Counters(); handoffs != 2 || wakeups < 2 {
+				t.Errorf("Counters error. Expecting %d handoffs at least %d wakeups, got %d %d", 2, 2, handoffs, wakeups)
+			}
+		})
+	}
+}
+
+func TestSemaphore_Cancel(t *testing.T) {
+	for _, q := range implementations {
+		t.Run(q.name, func(t *testing.T) {
+			s := q.new(1)
+			_ = s.Acquire(context.Background(), 1)
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*20)
+			defer cancel()
+			if err := s.Acquire(ctx, 1); err != context.DeadlineExceeded {
+				t.Errorf("Acquire error. Expecting %v, got %v", context.DeadlineExceeded, err)
+			}
+			if s.Waiters() != 0 {
+				t.Errorf("Acquire error. Expecting %d waiters, got %d", 0, s.Waiters())
+			}
+
+			s.Release(1)
+			if !s.TryAcquire(1) {
+				t.Errorf("TryAcquire error. Expecting true after release")
+			}
+		})
+	}
+}
+
+func TestSemaphore_Close(t *testing.T) {
+	for _, q := range implementations {
+		t.Run(q.name, func(t *testing.T) {
+			s := q.new(1)
+			_ = s.Acquire(context.Background(), 1)
+
+			done := make(chan error)
+			go func() {
+				done <- s.Acquire(context.Background(), 1)
+			}()
+			for s.Waiters() != 1 {
+				time.Sleep(time.Millisecond)
+			}
+
+			s.Close()
+			if err := <-done; err != ErrClosed {
+				t.Errorf("Acquire error. Expecting %v, got %v", ErrClosed, err)
+			}
+			if err := s.Acquire(context.Background(), 1); err != ErrClosed {
+				t.Errorf("Acquire error. Expecting %v, got %v", ErrClosed, err)
+			}
+			// 关闭后归还不会panic
+			s.Release(1)
+		})
+	}
+}
+
+func TestSemaphore_OverRelease(t *testing.T) {
+	for _, q := range implementations {
+		t.Run(q.name, func(t *testing.T) {
+			s := q.new(2)
+			_ = s.Acquire(context.Background(), 1)
+
+			s.Release(1)
+			if n := s.OverReleases(); n != 0 {
+				t.Errorf("OverReleases error. Expecting %d, got %d", 0, n)
+			}
+			// 重复归还被记录, 多出的部分不增加容量
+			s.Release(1)
+			if n := s.OverReleases(); n != 1 {
+				t.Errorf("OverReleases error. Expecting %d, got %d", 1, n)
+			}
+			if s.Held() != 0 {
+				t.Errorf("Held error. Expecting %d, got %d", 0, s.Held())
+			}
+			if !s.TryAcquire(2) || s.TryAcquire(1) {
+				t.Errorf("TryAcquire error. Expecting capacity %d after over release", 2)
+			}
+		})
+	}
+}
+
+// BenchmarkWaitQueue 高并发下等待队列的获得和归还, spurious/op 为每次操作的无效唤醒数
+func BenchmarkWaitQueue(b *testing.B) {
+	for _, q := range implementations {
+		for _, size := range []int64{1, 4, 16} {
+			b.Run(fmt.Sprintf("%s/size=%d", q.name, size), func(b *testing.B) {
+				s := q.new(size)
+				ctx := context.Background()
+				b.SetParallelism(16)
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						if err := s.Acquire(ctx, 1); err != nil {
+							b.Error(err)
+							return
+						}
+						// 持有期间让出, 让其他goroutine进入等待
+						runtime.Gosched()
+						s.Release(1)
+					}
+				})
+				handoffs, wakeups := s.Counters()
+				b.ReportMetric(float64(wakeups-handoffs)/float64(b.N), "spurious/op")
+			})
+		}
+	}
+}
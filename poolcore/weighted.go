@@ -0,0 +1,159 @@
+package poolcore
+
+import (
+	"context"
+	"sync"
+)
+
+// Weighted 带权重的信号量, 方法与 golang.org/x/sync/semaphore.Weighted 一致
+type Weighted interface {
+	Acquire(ctx context.Context, n int64) error
+	TryAcquire(n int64) bool
+	Release(n int64)
+}
+
+// WeightedQueue 用外部的Weighted实现WaitQueue, 每个单位占用w的weight个单位,
+// 多个pool或pool与其他任务可以共用同一个w; Close只让经过本队列的等待返回ErrClosed, 不影响w
+type WeightedQueue struct {
+	w Weighted
+
+	weight int64
+
+	mu sync.Mutex
+
+	held int64 // 经过本队列获得未归还的单位数
+
+	waiters int
+
+	closed bool
+
+	done chan struct{} // Close时关闭, 中断等待中的Acquire
+
+	handoffs int64
+
+	wakeups int64
+
+	overReleases int64 // 归还超过held的次数
+}
+
+// NewWeightedQueue 创建WeightedQueue, weight须大于0
+func NewWeightedQueue(w Weighted, weight int64) *WeightedQueue {
+	return &WeightedQueue{w: w, weight: weight, done: make(chan struct{})}
+}
+
+// Acquire 获得n个单位, 阻塞直到获得、ctx结束或队列关闭
+func (q *WeightedQueue) Acquire(ctx context.Context, n int64) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return ErrClosed
+	}
+	if q.w.TryAcquire(n * q.weight) {
+		q.held += n
+		q.mu.Unlock()
+		return nil
+	}
+	q.waiters++
+	q.mu.Unlock()
+
+	// 关闭时取消等待
+	waitCtx, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-q.done:
+			cancel()
+		case <-stop:
+		}
+	}()
+	err := q.w.Acquire(waitCtx, n*q.weight)
+	close(stop)
+	cancel()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.waiters--
+	q.wakeups++
+	switch {
+	case err == nil && q.closed:
+		q.w.Release(n * q.weight)
+		return ErrClosed
+	case err == nil:
+		q.held += n
+		q.handoffs++
+		return nil
+	case ctx.Err() != nil:
+		return ctx.Err()
+	case q.closed:
+		return ErrClosed
+	}
+	return err
+}
+
+// TryAcquire 不阻塞地获得n个单位
+func (q *WeightedQueue) TryAcquire(n int64) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || !q.w.TryAcquire(n*q.weight) {
+		return false
+	}
+	q.held += n
+	return true
+}
+
+// Release 归还n个单位, 最多归还经过本队列获得的单位数, 避免多归还给w
+func (q *WeightedQueue) Release(n int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if n > q.held {
+		q.overReleases++
+		n = q.held
+	}
+	if n <= 0 {
+		return
+	}
+	q.held -= n
+	q.w.Release(n * q.weight)
+}
+
+// Close 关闭队列, 等待者返回ErrClosed, 之后的Acquire直接返回ErrClosed; 已获得的单位仍需Release
+func (q *WeightedQueue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+}
+
+// Waiters 经过本队列等待的数量
+func (q *WeightedQueue) Waiters() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.waiters
+}
+
+// Held 经过本队列获得未归还的单位数
+func (q *WeightedQueue) Held() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.held
+}
+
+// Counters 实现WaitQueue, handoffs为等待后获得的次数
+func (q *WeightedQueue) Counters() (handoffs, wakeups int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.handoffs, q.wakeups
+}
+
+// OverReleases 实现WaitQueue, 多出的部分不归还给w
+func (q *WeightedQueue) OverReleases() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.overReleases
+}
@@ -0,0 +1,59 @@
+package poolcore
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWeightedQueue_Shared(t *testing.T) {
+	shared := NewSemaphore(4)
+	q1 := NewWeightedQueue(shared, 2)
+	q2 := NewWeightedQueue(shared, 1)
+
+	if !q1.TryAcquire(1) || !q2.TryAcquire(2) {
+		t.Fatalf("TryAcquire error. Expecting both to fit in shared budget")
+	}
+	// 共用的4个单位已用完
+	if q1.TryAcquire(1) || q2.TryAcquire(1) {
+		t.Errorf("TryAcquire error. Expecting false when shared budget is used up")
+	}
+	if q1.Held() != 1 || q2.Held() != 2 || shared.Held() != 4 {
+		t.Errorf("Held error. Expecting 1/2/4, got %d/%d/%d", q1.Held(), q2.Held(), shared.Held())
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- q1.Acquire(context.Background(), 1)
+	}()
+	for q1.Waiters() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	// q2 归还后q1的等待者获得
+	q2.Release(2)
+	if err := <-done; err != nil {
+		t.Errorf("Acquire error: %s", err)
+	}
+	if shared.Held() != 4 {
+		t.Errorf("Held error. Expecting %d, got %d", 4, shared.Held())
+	}
+
+	// 多归还的部分不会归还给shared
+	q2.Release(1)
+	if shared.Held() != 4 {
+		t.Errorf("Release error. Expecting %d, got %d", 4, shared.Held())
+	}
+	if q2.OverReleases() != 1 || shared.OverReleases() != 0 {
+		t.Errorf("OverReleases error. Expecting 1/0, got %d/%d", q2.OverReleases(), shared.OverReleases())
+	}
+
+	// 关闭q1不影响shared, 已获得的单位仍可归还
+	q1.Close()
+	if err := q1.Acquire(context.Background(), 1); err != ErrClosed {
+		t.Errorf("Acquire error. Expecting %v, got %v", ErrClosed, err)
+	}
+	q1.Release(2)
+	if shared.Held() != 0 {
+		t.Errorf("Release error. Expecting %d, got %d", 0, shared.Held())
+	}
+}
@@ -0,0 +1,109 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrPortExhausted = errors.New("local ports exhausted")
+)
+
+// 本地端口耗尽后暂停新建的默认时长
+const (
+	defaultPortBackoffMin = 100 * time.Millisecond
+	defaultPortBackoffMax = 5 * time.Second
+)
+
+// OnPortExhausted 新建conn因本地端口耗尽失败时调用, err为factory返回的error
+type OnPortExhausted func(err error)
+
+// isPortExhausted factory返回的error是否由本地端口耗尽引起
+func isPortExhausted(err error) bool {
+	return errors.Is(err, syscall.EADDRNOTAVAIL)
+}
+
+// portBackoffErr 处于退避期时返回error, 需持有p.mu
+func (p *ChannelPool) portBackoffErr(now time.Time) error {
+	if now.Before(p.portBackoffUntil) {
+		return fmt.Errorf("%w: dial backing off for %s", ErrPortExhausted, p.portBackoffUntil.Sub(now))
+	}
+	return nil
+}
+
+// portExhausted 记录一次端口耗尽并延长退避时间, 需持有p.mu
+func (p *ChannelPool) portExhausted(now time.Time) {
+	p.portExhaustedNum++
+	switch {
+	case p.portBackoff <= 0:
+		p.portBackoff = p.portBackoffMin
+	case p.portBackoff < p.portBackoffMax:
+		p.portBackoff *= 2
+	}
+	if p.portBackoff > p.portBackoffMax {
+		p.portBackoff = p.portBackoffMax
+	}
+	p.portBackoffUntil = now.Add(p.portBackoff)
+}
+
+// dialFactory 调用factory, 本地端口耗尽时进入退避期, 退避期内或新建速率超过WithChurnGuard上限时不调用factory;
+// 传给factory的ctx在pool关闭时取消
+func (p *ChannelPool) dialFactory(ctx context.Context) (net.Conn, error) {
+	now := time.Now()
+	p.mu.Lock()
+	factory := p.factory
+	err := p.portBackoffErr(now)
+	var churn *ChurnEvent
+	if err == nil {
+		churn, err = p.churnCheck(now)
+	}
+	p.mu.Unlock()
+	if churn != nil && p.onChurn != nil {
+		p.onChurn(*churn)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := p.factoryContext(ctx)
+	conn, err := factory(ctx)
+	cancel()
+	if err == nil {
+		p.mu.Lock()
+		p.portBackoff = 0
+		p.mu.Unlock()
+		return conn, nil
+	}
+	if !isPortExhausted(err) {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	p.portExhausted(time.Now())
+	p.mu.Unlock()
+	if p.onPortExhausted != nil {
+		p.onPortExhausted(err)
+	}
+	return nil, fmt.Errorf("%w: %w", ErrPortExhausted, err)
+}
+
+// factoryContext 返回在ctx结束或pool关闭时取消的ctx
+func (p *ChannelPool) factoryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := make(chan struct{})
+	p.goBackground(func() {
+		select {
+		case <-p.done:
+			cancel()
+		case <-stop:
+		}
+	})
+	return ctx, func() {
+		close(stop)
+		cancel()
+	}
+}
@@ -0,0 +1,93 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_PortExhaustion(t *testing.T) {
+	var (
+		dials     int
+		exhausted = true
+		alerts    []error
+	)
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		dials++
+		if exhausted {
+			return nil, &net.OpError{Op: "dial", Net: "tcp",
+				Err: os.NewSyscallError("connect", syscall.EADDRNOTAVAIL)}
+		}
+		return factory()
+	},
+		WithInitialConns(0),
+		WithPortExhaustionBackoff(time.Millisecond*50, time.Millisecond*100),
+		WithOnPortExhausted(func(err error) {
+			alerts = append(alerts, err)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	_, err = p.Get()
+	if !errors.Is(err, ErrPortExhausted) || !errors.Is(err, syscall.EADDRNOTAVAIL) {
+		t.Fatalf("Get error. Expecting %v, got %v", ErrPortExhausted, err)
+	}
+	if len(alerts) != 1 {
+		t.Errorf("OnPortExhausted error. Expecting %d, got %d", 1, len(alerts))
+	}
+
+	// 退避期内不调用factory
+	_, err = p.Get()
+	if !errors.Is(err, ErrPortExhausted) {
+		t.Errorf("Get error. Expecting %v, got %v", ErrPortExhausted, err)
+	}
+	if dials != 1 {
+		t.Errorf("Get error. Expecting %d dials, got %d", 1, dials)
+	}
+	if s := p.Stats(); s.PortExhausted != 1 {
+		t.Errorf("Stats error. Expecting %d, got %d", 1, s.PortExhausted)
+	}
+
+	// 退避期结束后恢复新建
+	exhausted = false
+	time.Sleep(time.Millisecond * 60)
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if dials != 2 {
+		t.Errorf("Get error. Expecting %d dials, got %d", 2, dials)
+	}
+	if p.InUse() != 1 {
+		t.Errorf("Get error. Expecting %d, got %d", 1, p.InUse())
+	}
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChannelPool_PortBackoffGrowth(t *testing.T) {
+	p := &ChannelPool{portBackoffMin: time.Millisecond * 10, portBackoffMax: time.Millisecond * 30}
+	now := time.Now()
+	for _, want := range []time.Duration{10, 20, 30, 30} {
+		p.portExhausted(now)
+		if p.portBackoff != want*time.Millisecond {
+			t.Errorf("portExhausted error. Expecting %s, got %s", want*time.Millisecond, p.portBackoff)
+		}
+	}
+	if p.portExhaustedNum != 4 {
+		t.Errorf("portExhausted error. Expecting %d, got %d", 4, p.portExhaustedNum)
+	}
+}
+
+func TestNew_InvalidPortBackoff(t *testing.T) {
+	_, err := NewChannelPool(1, 2, factory, WithPortExhaustionBackoff(time.Second, time.Millisecond))
+	if err == nil {
+		t.Errorf("New error. Expecting invalid backoff error")
+	}
+}
@@ -0,0 +1,45 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ctx没有截止时间时Healthy等待conn的时间
+const defaultHealthyTimeout = time.Second
+
+// Healthy 检查pool当前能否在短时间内提供可用的conn: 取出一个conn(没有空闲conn时新建),
+// 设置了WithReadinessProbe时在conn上执行probe, 之后放回; pool已满且在截止时间内没有conn放回时同样返回error
+func (p *ChannelPool) Healthy(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultHealthyTimeout)
+		defer cancel()
+	}
+
+	conn, err := p.GetWitchContext(p.withCaller(ctx, 2))
+	if err != nil {
+		return err
+	}
+	if p.readinessProbe != nil {
+		if err := p.readinessProbe(ctx, conn); err != nil {
+			p.discard(conn, CloseReasonHealthCheck)
+			return err
+		}
+	}
+	return p.Put(conn)
+}
+
+// HealthyHandler 调用Healthy的http.Handler, 可用作readiness探针, 不可用时返回503
+func (p *ChannelPool) HealthyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := p.Healthy(r.Context()); err != nil {
+			http.Error(w, fmt.Sprintf("not ready: %s", err), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
@@ -0,0 +1,76 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_Healthy(t *testing.T) {
+	var probeErr error
+	p, err := NewChannelPool(1, 1, factory,
+		WithReadinessProbe(func(ctx context.Context, conn net.Conn) error {
+			return probeErr
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if err := p.Healthy(context.Background()); err != nil {
+		t.Errorf("Healthy error: %s", err)
+	}
+	if p.Len() != 1 || p.InUse() != 0 {
+		t.Errorf("Healthy error. Expecting conn returned, got %d idle %d inUse", p.Len(), p.InUse())
+	}
+
+	// probe失败的conn被关闭
+	probeErr = errors.New("ping failed")
+	if err := p.Healthy(context.Background()); err != probeErr {
+		t.Errorf("Healthy error. Expecting %v, got %v", probeErr, err)
+	}
+	if p.OpenNum() != 0 {
+		t.Errorf("Healthy error. Expecting %d, got %d", 0, p.OpenNum())
+	}
+
+	rec := httptest.NewRecorder()
+	p.HealthyHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("HealthyHandler error. Expecting %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	probeErr = nil
+	rec = httptest.NewRecorder()
+	p.HealthyHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("HealthyHandler error. Expecting %d, got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestChannelPool_HealthySaturated(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	if err := p.Healthy(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("Healthy error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+	p.Put(conn)
+
+	p.Close()
+	if err := p.Healthy(context.Background()); err != ErrClosed {
+		t.Errorf("Healthy error. Expecting %v, got %v", ErrClosed, err)
+	}
+}
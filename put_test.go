@@ -0,0 +1,204 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_PutNil(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+
+	if err := p.Put(nil); !errors.Is(err, ErrNilConn) {
+		t.Errorf("Put error. Expecting %v, got %v", ErrNilConn, err)
+	}
+	if err := p.Discard(nil); !errors.Is(err, ErrNilConn) {
+		t.Errorf("Discard error. Expecting %v, got %v", ErrNilConn, err)
+	}
+
+	_ = p.Close()
+	if err := p.Put(nil); !errors.Is(err, ErrNilConn) {
+		t.Errorf("Put error. Expecting %v, got %v", ErrNilConn, err)
+	}
+}
+
+func TestChannelPool_PutCloseOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(p *ChannelPool, conns []net.Conn)
+	}{
+		{
+			name: "put then close",
+			run: func(p *ChannelPool, conns []net.Conn) {
+				for _, conn := range conns {
+					_ = p.Put(conn)
+				}
+				_ = p.Close()
+			},
+		},
+		{
+			name: "close then put",
+			run: func(p *ChannelPool, conns []net.Conn) {
+				_ = p.Close()
+				for _, conn := range conns {
+					_ = p.Put(conn)
+				}
+			},
+		},
+		{
+			name: "put concurrent with close",
+			run: func(p *ChannelPool, conns []net.Conn) {
+				var wg sync.WaitGroup
+				for _, conn := range conns {
+					wg.Add(1)
+					go func(conn net.Conn) {
+						defer wg.Done()
+						_ = p.Put(conn)
+					}(conn)
+				}
+				_ = p.Close()
+				wg.Wait()
+			},
+		},
+		{
+			name: "put twice around close",
+			run: func(p *ChannelPool, conns []net.Conn) {
+				for _, conn := range conns {
+					_ = p.Put(conn)
+				}
+				_ = p.Close()
+				for _, conn := range conns {
+					_ = p.Put(conn)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+
+			conns := make([]net.Conn, 0, maxConn)
+			for i := 0; i < maxConn; i++ {
+				conn, err := p.Get()
+				if err != nil {
+					t.Fatalf("Get error: %s", err)
+				}
+				conns = append(conns, conn)
+			}
+
+			tt.run(p, conns)
+
+			if p.OpenNum() != 0 || p.Len() != 0 {
+				t.Errorf("Close error. Expecting open=0 idle=0, got open=%d idle=%d",
+					p.OpenNum(), p.Len())
+			}
+			if err := p.Close(); err != ErrClosed {
+				t.Errorf("Close error. Expecting %v, got %v", ErrClosed, err)
+			}
+		})
+	}
+}
+
+func TestChannelPool_OnFull(t *testing.T) {
+	var calls []int64
+	p, err := NewChannelPool(1, 3, factory, WithOnFull(func(overflows int64) {
+		calls = append(calls, overflows)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	var conns []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		p.Put(conn)
+	}
+	if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+		t.Errorf("OnFull error. Expecting [1 2], got %v", calls)
+	}
+	if s := p.Stats(); s.Overflows != 2 {
+		t.Errorf("Stats error. Expecting %d, got %d", 2, s.Overflows)
+	}
+}
+
+func TestChannelPool_PutNeverBlocks(t *testing.T) {
+	f := func() (net.Conn, error) {
+		conn, err := factory()
+		if err != nil {
+			return nil, err
+		}
+		return &slowCloseConn{Conn: conn, delay: 300 * time.Millisecond}, nil
+	}
+	p, err := NewChannelPool(1, 8, f, WithInitialConns(0), WithCloseQueueSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conns := make([]net.Conn, 0, 8)
+	for i := 0; i < 8; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		conns = append(conns, conn)
+	}
+
+	// 后台关闭卡在第一个conn上, 队列已满后Put仍立即返回
+	start := time.Now()
+	for _, conn := range conns {
+		if err := p.Put(conn); err != nil {
+			t.Errorf("Put error: %s", err)
+		}
+	}
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Errorf("Put error. Expecting Put not to wait for Close, took %s", d)
+	}
+	if p.OpenNum() != 1 {
+		t.Errorf("Put error. Expecting %d open, got %d", 1, p.OpenNum())
+	}
+
+	p.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.WaitStopped(ctx); err != nil {
+		t.Errorf("WaitStopped error: %s", err)
+	}
+}
+
+// BenchmarkPut 多个goroutine竞争时Put的耗时, 以put-ns/op报告, 不包括Get
+func BenchmarkPut(b *testing.B) {
+	p, err := NewChannelPool(64, 64, factory)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer p.Close()
+
+	var total int64
+	b.SetParallelism(4)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			conn, err := p.Get()
+			if err != nil {
+				b.Error(err)
+				return
+			}
+			start := time.Now()
+			_ = p.Put(conn)
+			atomic.AddInt64(&total, int64(time.Since(start)))
+		}
+	})
+	b.ReportMetric(float64(total)/float64(b.N), "put-ns/op")
+}
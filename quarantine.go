@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+)
+
+var (
+	ErrQuarantined = errors.New("backend quarantined")
+)
+
+// QuarantineEntry 一个健康检查失败的后端
+type QuarantineEntry struct {
+	Backend  string        // 对端地址
+	Failures int           // 上次隔离结束后连续健康检查失败的次数
+	Backoff  time.Duration // 最近一次隔离的时长, 为0时尚未被隔离
+	Until    time.Time     // 隔离结束时间
+}
+
+// Active 在now时是否处于隔离期
+func (e QuarantineEntry) Active(now time.Time) bool {
+	return now.Before(e.Until)
+}
+
+// quarantine 按后端记录健康检查失败, 由p.mu保护
+type quarantine struct {
+	threshold int // 进入隔离的连续失败次数
+
+	min, max time.Duration // 隔离时长的范围
+
+	entries map[string]*QuarantineEntry
+}
+
+// backendOf conn的对端地址
+func backendOf(conn net.Conn) string {
+	if addr := conn.RemoteAddr(); addr != nil {
+		return addr.String()
+	}
+	return ""
+}
+
+// healthResult 记录conn的一次健康检查结果, 需持有p.mu
+func (p *ChannelPool) healthResult(conn net.Conn, err error) {
+	q := p.quarantine
+	if q == nil {
+		return
+	}
+	m, ok := p.conns[conn]
+	if !ok || m.backend == "" {
+		return
+	}
+	if err == nil {
+		delete(q.entries, m.backend)
+		return
+	}
+
+	now := time.Now()
+	e, ok := q.entries[m.backend]
+	if !ok {
+		e = &QuarantineEntry{Backend: m.backend}
+		q.entries[m.backend] = e
+	}
+	if e.Active(now) {
+		// 隔离期开始前取出的conn, 不重复计算
+		return
+	}
+	e.Failures++
+	// 被隔离过的后端在恢复后第一次失败即重新隔离
+	if e.Failures < q.threshold && e.Backoff == 0 {
+		return
+	}
+	switch {
+	case e.Backoff <= 0:
+		e.Backoff = q.min
+	case e.Backoff < q.max:
+		e.Backoff *= 2
+	}
+	if e.Backoff > q.max {
+		e.Backoff = q.max
+	}
+	e.Failures = 0
+	e.Until = now.Add(e.Backoff)
+	p.quarantinedNum++
+}
+
+// quarantined 后端在now时是否处于隔离期, 需持有p.mu
+func (p *ChannelPool) quarantined(backend string, now time.Time) bool {
+	if p.quarantine == nil {
+		return false
+	}
+	e, ok := p.quarantine.entries[backend]
+	return ok && e.Active(now)
+}
+
+// quarantineErr 新建的conn连到处于隔离期的后端时返回error
+func (p *ChannelPool) quarantineErr(raw net.Conn) error {
+	if p.quarantine == nil {
+		return nil
+	}
+	backend := backendOf(raw)
+	now := time.Now()
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if !p.quarantined(backend, now) {
+		return nil
+	}
+	until := p.quarantine.entries[backend].Until
+	return fmt.Errorf("%w: %s for %s", ErrQuarantined, backend, until.Sub(now).Round(time.Millisecond))
+}
+
+// Quarantine 返回健康检查失败的后端, 包括处于隔离期和隔离结束后尚未通过检查的, 按后端地址排序
+func (p *ChannelPool) Quarantine() []QuarantineEntry {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.quarantine == nil {
+		return nil
+	}
+	entries := make([]QuarantineEntry, 0, len(p.quarantine.entries))
+	for _, e := range p.quarantine.entries {
+		entries = append(entries, *e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Backend < entries[j].Backend })
+	return entries
+}
@@ -0,0 +1,111 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_Quarantine(t *testing.T) {
+	var failing atomic.Bool
+	check := func(ctx context.Context, conn net.Conn) error {
+		if failing.Load() {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0), WithHealthCheck(check),
+		WithQuarantine(2, 50*time.Millisecond, 80*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conns := make([]net.Conn, 0, 3)
+	for i := 0; i < 3; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+		conns = append(conns, conn)
+	}
+	for _, c := range conns {
+		p.Put(c)
+	}
+
+	// 连续两次检查失败后隔离, 剩余的空闲conn被关闭, 新建返回ErrQuarantined
+	failing.Store(true)
+	if _, err := p.Get(); !errors.Is(err, ErrQuarantined) {
+		t.Fatalf("Get error. Expecting %v, got %v", ErrQuarantined, err)
+	}
+	if p.Len() != 0 || p.OpenNum() != 0 {
+		t.Errorf("Get error. Expecting 0 idle 0 open, got %d %d", p.Len(), p.OpenNum())
+	}
+	entries := p.Quarantine()
+	if len(entries) != 1 || entries[0].Backend != address || !entries[0].Active(time.Now()) {
+		t.Fatalf("Quarantine error. Expecting %s active, got %+v", address, entries)
+	}
+	if entries[0].Backoff != 50*time.Millisecond {
+		t.Errorf("Quarantine error. Expecting %s, got %s", 50*time.Millisecond, entries[0].Backoff)
+	}
+	if h := p.ConnAgeStats()[CloseReasonQuarantined]; h.Count != 1 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 1, h.Count)
+	}
+	if s := p.Stats(); s.Quarantined != 1 {
+		t.Errorf("Stats error. Expecting %d, got %d", 1, s.Quarantined)
+	}
+
+	// 隔离结束后第一次失败即重新隔离, 时长翻倍但不超过上限
+	time.Sleep(60 * time.Millisecond)
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if _, err := p.Get(); !errors.Is(err, ErrQuarantined) {
+		t.Fatalf("Get error. Expecting %v, got %v", ErrQuarantined, err)
+	}
+	if entries := p.Quarantine(); len(entries) != 1 || entries[0].Backoff != 80*time.Millisecond {
+		t.Errorf("Quarantine error. Expecting backoff %s, got %+v", 80*time.Millisecond, entries)
+	}
+
+	rec := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/?quarantine", nil))
+	if body := rec.Body.String(); !strings.Contains(body, "backend="+address) || !strings.Contains(body, "backoff=80ms") {
+		t.Errorf("DebugHandler error. Expecting quarantined backend, got %q", body)
+	}
+
+	// 检查通过后解除
+	time.Sleep(90 * time.Millisecond)
+	failing.Store(false)
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if entries := p.Quarantine(); len(entries) != 0 {
+		t.Errorf("Quarantine error. Expecting empty, got %+v", entries)
+	}
+}
+
+func TestWithQuarantine_Invalid(t *testing.T) {
+	for _, opt := range []Option{
+		WithQuarantine(0, time.Second, time.Second),
+		WithQuarantine(1, 0, time.Second),
+		WithQuarantine(1, time.Second, time.Millisecond),
+	} {
+		if _, err := NewChannelPool(3, 5, factory, opt); err == nil {
+			t.Errorf("NewChannelPool error. Expecting invalid quarantine")
+		}
+	}
+}
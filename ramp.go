@@ -0,0 +1,114 @@
+package pool
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// RampCurve 返回爬坡开始elapsed之后允许同时新建的conn数, 达到pool容量时爬坡结束
+type RampCurve func(elapsed time.Duration) int
+
+// 爬坡期间等待新建名额时重新计算上限的间隔
+const rampRecheck = 10 * time.Millisecond
+
+// LinearRamp 从initial开始每秒增加perSecond个并发新建名额
+func LinearRamp(initial int, perSecond float64) RampCurve {
+	return func(elapsed time.Duration) int {
+		return initial + int(perSecond*elapsed.Seconds())
+	}
+}
+
+// ExponentialRamp 从initial开始每经过doubling并发新建名额翻倍
+func ExponentialRamp(initial int, doubling time.Duration) RampCurve {
+	return func(elapsed time.Duration) int {
+		n := float64(initial) * math.Pow(2, float64(elapsed)/float64(doubling))
+		if n > math.MaxInt32 {
+			return math.MaxInt32
+		}
+		return int(n)
+	}
+}
+
+// RampUp 开始一次爬坡, 之后的新建按RampCurve逐步放开, 用于后端故障恢复或切换之后;
+// 未设置RampCurve时不起作用. pool中conn全部关闭后新建时也会自动开始爬坡
+func (p *ChannelPool) RampUp() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rampCurve != nil {
+		p.rampStart = time.Now()
+	}
+}
+
+// rampCapacity 爬坡结束时的并发新建数
+func (p *ChannelPool) rampCapacity() int {
+	if p.unlimited {
+		return int(p.maxIdle)
+	}
+	return int(p.maxConn)
+}
+
+// rampLimit 当前允许同时新建的conn数, 0 表示不限制, 需持有p.mu
+func (p *ChannelPool) rampLimit(now time.Time) int {
+	if p.rampStart.IsZero() && p.acct.Open == 0 {
+		// conn已经全部关闭, 重新建立时爬坡
+		p.rampStart = now
+	}
+	if p.rampStart.IsZero() {
+		return 0
+	}
+	n := p.rampCurve(now.Sub(p.rampStart))
+	if n >= p.rampCapacity() {
+		p.rampStart = time.Time{}
+		return 0
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// acquireDial 爬坡期间等待新建名额, 等待期间ctx结束时返回DialThrottledError
+func (p *ChannelPool) acquireDial(ctx context.Context) error {
+	if p.rampCurve == nil {
+		return nil
+	}
+	for waited := false; ; waited = true {
+		p.mu.Lock()
+		if limit := p.rampLimit(time.Now()); limit == 0 || p.dialing < limit {
+			p.dialing++
+			p.mu.Unlock()
+			return nil
+		}
+		if !waited {
+			p.dialThrottledNum++
+		}
+		wake := p.dialWake
+		p.mu.Unlock()
+
+		timer := time.NewTimer(rampRecheck)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &DialThrottledError{Limiter: LimiterRamp, Err: timeoutErr(ctx)}
+		case <-p.done:
+			timer.Stop()
+			return ErrClosed
+		case <-wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// releaseDial 新建结束, 唤醒等待新建名额的调用
+func (p *ChannelPool) releaseDial() {
+	if p.rampCurve == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dialing--
+	close(p.dialWake)
+	p.dialWake = make(chan struct{})
+}
@@ -0,0 +1,146 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrentDials 记录同时进行的最大新建数
+func concurrentDials(peak *int64) Factory {
+	var cur int64
+	return func() (net.Conn, error) {
+		n := atomic.AddInt64(&cur, 1)
+		defer atomic.AddInt64(&cur, -1)
+		for {
+			m := atomic.LoadInt64(peak)
+			if n <= m || atomic.CompareAndSwapInt64(peak, m, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond * 20)
+		return factory()
+	}
+}
+
+func getAll(t *testing.T, p *ChannelPool, n int) []net.Conn {
+	conns := make([]net.Conn, n)
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conn, err := p.Get()
+			if err != nil {
+				t.Errorf("Get error: %s", err)
+				return
+			}
+			conns[i] = conn
+		}(i)
+	}
+	wg.Wait()
+	return conns
+}
+
+func TestChannelPool_RampUp(t *testing.T) {
+	var peak int64
+	p, err := NewChannelPool(5, 5, concurrentDials(&peak),
+		WithInitialConns(0),
+		WithRampUp(func(elapsed time.Duration) int {
+			if elapsed < time.Second {
+				return 1
+			}
+			return 5
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// conn全部关闭时开始爬坡, 同时只新建一个
+	conns := getAll(t, p, 5)
+	if peak != 1 {
+		t.Errorf("RampUp error. Expecting %d concurrent dials, got %d", 1, peak)
+	}
+	for _, conn := range conns {
+		if conn != nil {
+			p.Put(conn)
+		}
+	}
+	if p.OpenNum() != 5 {
+		t.Errorf("RampUp error. Expecting %d, got %d", 5, p.OpenNum())
+	}
+}
+
+func TestChannelPool_RampEnds(t *testing.T) {
+	var peak int64
+	p, err := NewChannelPool(5, 5, concurrentDials(&peak),
+		WithInitialConns(0),
+		WithRampUp(LinearRamp(5, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 曲线已达到容量, 不限制
+	conns := getAll(t, p, 5)
+	if peak < 2 {
+		t.Errorf("RampUp error. Expecting concurrent dials, got %d", peak)
+	}
+	for _, conn := range conns {
+		if conn != nil {
+			p.Put(conn)
+		}
+	}
+}
+
+func TestRampCurves(t *testing.T) {
+	linear := LinearRamp(1, 2)
+	if n := linear(time.Second * 2); n != 5 {
+		t.Errorf("LinearRamp error. Expecting %d, got %d", 5, n)
+	}
+	exp := ExponentialRamp(1, time.Second)
+	if n := exp(time.Second * 3); n != 8 {
+		t.Errorf("ExponentialRamp error. Expecting %d, got %d", 8, n)
+	}
+}
+
+func TestChannelPool_RampThrottled(t *testing.T) {
+	p, err := NewChannelPool(5, 5, func() (net.Conn, error) {
+		time.Sleep(time.Millisecond * 100)
+		return factory()
+	}, WithInitialConns(0), WithRampUp(func(time.Duration) int { return 1 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if conn, err := p.Get(); err == nil {
+			p.Put(conn)
+		}
+	}()
+	time.Sleep(time.Millisecond * 20)
+
+	// 爬坡期间等待新建名额超时, 与后端不可用区分
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*30)
+	defer cancel()
+	_, err = p.GetWitchContext(ctx)
+	var te *DialThrottledError
+	if !errors.As(err, &te) || te.Limiter != LimiterRamp {
+		t.Errorf("Get error. Expecting DialThrottledError, got %v", err)
+	}
+	if !errors.Is(err, ErrDialBudgetExceeded) || !errors.Is(err, ErrTimeOut) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get error. Expecting to match %v and %v, got %v", ErrDialBudgetExceeded, ErrTimeOut, err)
+	}
+	if s := p.Stats(); s.DialThrottled != 1 {
+		t.Errorf("Stats error. Expecting %d dial throttled, got %d", 1, s.DialThrottled)
+	}
+	<-done
+}
@@ -0,0 +1,356 @@
+package pool
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+)
+
+// connMeta pool管理的conn的元数据
+type connMeta struct {
+	id uint64 // 创建序号
+
+	createdAt time.Time // 创建时间
+
+	idle bool // 是否空闲
+
+	idleSince time.Time // 最近一次放回pool的时间
+
+	halfClosed bool // 已调用CloseWrite或CloseRead, 放回时关闭
+
+	expiryScale float64 // 过期时长的缩放系数, 用于错开同时创建的conn的过期时间
+
+	gen uint64 // 创建时pool的代数, 小于当前代数的conn已被Drain淘汰
+
+	hold *HoldRecord // 被采样的本次取出, 未采样时为nil
+
+	cred Credential // 认证凭据
+
+	pinned bool // 通过GetPinned取出
+
+	events *eventRing // 生命周期事件, 未开启trace时为nil
+
+	conn net.Conn // 当前使用的conn, 经过WrapConn包装后为包装后的conn
+
+	handle *PoolConn // 取出时返回的PoolConn
+
+	dialDuration time.Duration // factory耗时
+
+	handshakeDuration time.Duration // OnCreate耗时
+
+	tls *TLSInfo // TLS参数, 不是TLS conn或尚未握手时为nil
+
+	acquired AcquireInfo // 最近一次取出的情况
+
+	holder string // 本次取出方, 空闲时为空
+
+	uses int64 // 被取出的次数
+
+	forced bool // 取出期间被CloseConn关闭, 放回时不再复用
+
+	backend string // 对端地址
+
+	tlsConn *tls.Conn // WithTLSClient创建的TLS conn, 未设置时为nil
+
+	pendingHandshake bool // TLS握手推迟到第一次取出
+
+	handoff bool // 放回时有等待者, 在p.handoff中等待被等待者取出
+
+	workloadPending bool // 本次取出尚未写入WorkloadRecord
+
+	unusable bool // 本次取出被MarkUnusable标记, 放回时关闭
+
+	migrating bool // MigrateTo开始前创建, 等待被淘汰
+
+	migrated bool // 取出期间被MigrateTo淘汰, 放回时关闭
+
+	validating bool // 已放回, 正在后台执行WithHealthCheckOnPut的检查
+
+	validated bool // 通过过WithHealthCheck的检查
+}
+
+// dial 调用factory新建conn并登记, 返回底层conn; ctx传给OnCreate
+func (p *ChannelPool) dial(ctx context.Context) (net.Conn, error) {
+	raw, err := p.createRetry(ctx)
+	if err != nil && isBackendFailure(ctx, err) && !errors.Is(err, ErrBackendUnavailable) {
+		p.mu.Lock()
+		p.lastDialErr, p.lastDialErrAt = err, time.Now()
+		p.mu.Unlock()
+	}
+	p.observeDegrade(ctx, err)
+	return raw, err
+}
+
+// create 同dial, 不记录连续失败; 设置了WithBudget时先占用名额, 名额用完时可能返回从其他成员转来的conn
+func (p *ChannelPool) create(ctx context.Context) (net.Conn, error) {
+	if p.budget == nil {
+		return p.createConn(ctx, false)
+	}
+	donated, err := p.budgetAcquire()
+	if err != nil || donated != nil {
+		return donated, err
+	}
+	raw, err := p.createConn(ctx, true)
+	if err != nil {
+		p.budget.release()
+	}
+	return raw, err
+}
+
+// createConn 新建并登记conn, reserved为true时已占用Budget名额
+func (p *ChannelPool) createConn(ctx context.Context, reserved bool) (net.Conn, error) {
+	if err := p.acquireDial(ctx); err != nil {
+		return nil, err
+	}
+	defer p.releaseDial()
+
+	prober, err := p.enterProbe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	raw, err := p.dialFactory(ctx)
+	dialDuration := time.Since(start)
+	p.exitProbe(ctx, prober, err)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFactoryConn(raw); err != nil {
+		return nil, err
+	}
+	if err := p.quarantineErr(raw); err != nil {
+		p.closeAsync(raw)
+		return nil, err
+	}
+	c, err := p.prepare(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	c.dialDuration = dialDuration
+	c.reserved = reserved
+
+	p.mu.Lock()
+	// 新建期间pool被关闭
+	if p.closed {
+		p.mu.Unlock()
+		_ = p.closeConn(c.conn)
+		return nil, ErrClosed
+	}
+	p.registerPrepared(c)
+	p.mu.Unlock()
+	return raw, nil
+}
+
+// preparedConn 完成建立、尚未登记的conn
+type preparedConn struct {
+	raw net.Conn // factory创建的底层conn
+
+	conn net.Conn // 经过TLS和WrapConn包装后的conn
+
+	tc *tls.Conn // WithTLSClient创建的TLS conn, 未设置时为nil
+
+	dialDuration time.Duration
+
+	handshakeDuration time.Duration
+
+	cred Credential
+
+	tls *TLSInfo
+
+	reserved bool // 已占用Budget名额
+}
+
+// prepare 对factory创建的conn设置keepalive, 按配置进行TLS握手、包装、OnCreate和认证, 失败时关闭conn
+func (p *ChannelPool) prepare(ctx context.Context, raw net.Conn) (*preparedConn, error) {
+	if err := p.setKeepAlive(raw); err != nil {
+		p.closeAsync(raw)
+		return nil, err
+	}
+	c := &preparedConn{raw: raw, conn: raw}
+	c.tc = p.clientTLS(raw)
+	if c.tc != nil {
+		c.conn = c.tc
+		if p.handshakeStage == HandshakeOnDial {
+			start := time.Now()
+			if err := c.tc.HandshakeContext(ctx); err != nil {
+				p.closeAsync(c.tc)
+				return nil, err
+			}
+			c.handshakeDuration = time.Since(start)
+		}
+	}
+	if p.wrapConn != nil {
+		c.conn = p.wrapConn(c.conn)
+	}
+	if p.onCreate != nil {
+		start := time.Now()
+		if err := p.onCreate(ctx, c.conn); err != nil {
+			p.closeAsync(c.conn)
+			return nil, err
+		}
+		c.handshakeDuration += time.Since(start)
+	}
+	cred, err := p.authenticate(ctx, c.conn)
+	if err != nil {
+		p.closeAsync(c.conn)
+		return nil, err
+	}
+	c.cred = cred
+	c.tls = tlsInfoOf(c.conn, raw)
+	if c.tc != nil {
+		c.tls = tlsInfoOf(c.tc)
+	}
+	return c, nil
+}
+
+// registerPrepared 登记prepare完成的conn, 需持有p.mu
+func (p *ChannelPool) registerPrepared(c *preparedConn) *connMeta {
+	m := p.register(c.raw, c.conn)
+	m.dialDuration = c.dialDuration
+	m.handshakeDuration = c.handshakeDuration
+	m.cred = c.cred
+	m.tls = c.tls
+	m.tlsConn = c.tc
+	m.pendingHandshake = c.tc != nil && p.handshakeStage == HandshakeOnCheckout
+	if !c.reserved {
+		p.budget.add()
+	}
+	p.dialLatency.observe(c.dialDuration)
+	if p.onCreate != nil || (c.tc != nil && !m.pendingHandshake) {
+		p.handshakeLatency.observe(c.handshakeDuration)
+	}
+	return m
+}
+
+// checkFactoryConn 检查factory返回的conn, nil或已关闭时返回FactoryError;
+// 通过清除deadline判断conn是否已关闭, 不读取数据, factory设置的deadline会被清除
+func checkFactoryConn(conn net.Conn) error {
+	if conn == nil {
+		return &FactoryError{Err: ErrNilConn}
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+		return &FactoryError{Err: err}
+	}
+	return nil
+}
+
+// register 登记新建的底层conn及其当前使用的conn, 需持有p.mu
+func (p *ChannelPool) register(raw, conn net.Conn) *connMeta {
+	p.nextID++
+	m := &connMeta{id: p.nextID, createdAt: time.Now(), conn: conn, expiryScale: p.expiryScale(), gen: p.gen, backend: backendOf(raw)}
+	if p.traceSize > 0 {
+		m.events = newEventRing(p.traceSize)
+	}
+	p.conns[raw] = m
+	p.acct.Add()
+	p.traceEvent(m, ConnEventCreated, "")
+	return m
+}
+
+// forget 移除conn的登记并记录关闭原因, 返回应关闭的conn(包装后的conn), 需持有p.mu
+func (p *ChannelPool) forget(conn net.Conn, reason CloseReason) net.Conn {
+	m, ok := p.conns[conn]
+	if !ok {
+		return conn
+	}
+	p.endHold(m)
+	p.unpin(m)
+	delete(p.conns, conn)
+	if m.migrating {
+		p.migratePending--
+	}
+	p.acct.Remove()
+	p.budget.release()
+	if p.acct.Open < 0 && p.anomaly(AnomalyNegativeOpen, m.id) != nil {
+		p.acct.Open = 0
+	}
+	p.observeAge(m, reason)
+	p.traceEvent(m, ConnEventClosed, reason.String())
+	p.traceClosed(m)
+	return m.conn
+}
+
+// markIdle 标记conn放回pool, 需持有p.mu
+func (p *ChannelPool) markIdle(conn net.Conn) {
+	if m, ok := p.conns[conn]; ok {
+		p.endHold(m)
+		p.unpin(m)
+		m.idle = true
+		m.idleSince = time.Now()
+		m.holder = ""
+		if m.tls == nil && m.tlsConn != nil {
+			m.tls = tlsInfoOf(m.tlsConn)
+		}
+		if m.tls == nil {
+			m.tls = tlsInfoOf(m.conn, conn)
+		}
+		p.traceEvent(m, ConnEventReturned, "")
+	}
+}
+
+// markBusy 标记conn被取出, 需持有p.mu
+func (p *ChannelPool) markBusy(conn net.Conn) {
+	if m, ok := p.conns[conn]; ok {
+		m.idle = false
+	}
+}
+
+// markUnusable 标记c对应的本次取出不可用, c已不是conn当前的PoolConn时不标记
+func (p *ChannelPool) markUnusable(c *PoolConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if m, ok := p.conns[c.raw]; ok && !m.idle && m.handle == c {
+		m.unusable = true
+	}
+}
+
+// markHalfClosed 标记conn已被半关闭
+func (p *ChannelPool) markHalfClosed(conn net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if m, ok := p.conns[conn]; ok {
+		m.halfClosed = true
+	}
+}
+
+// discard 丢弃一个已取出的conn, 释放其容量单位并在后台关闭
+func (p *ChannelPool) discard(conn net.Conn, reason CloseReason) {
+	pc, _ := conn.(*PoolConn)
+	conn = rawConn(conn)
+	p.mu.Lock()
+	m, ok := p.conns[conn]
+	if ok && (m.idle || pc.stale()) {
+		// 已经放回pool, 或PoolConn已失效, conn由当前持有方使用
+		p.mu.Unlock()
+		return
+	}
+	if ok {
+		p.recordCheckin(m)
+		p.poison(m)
+	}
+	c := p.forget(conn, reason)
+	p.mu.Unlock()
+
+	if ok {
+		p.release()
+	}
+	p.closeAsync(c)
+}
+
+// oldestIdle 最早放回pool的空闲conn的放回时间, 需持有p.mu
+func (p *ChannelPool) oldestIdle() (time.Time, bool) {
+	var (
+		oldest time.Time
+		found  bool
+	)
+	for _, m := range p.conns {
+		if m.idle && (!found || m.idleSince.Before(oldest)) {
+			oldest = m.idleSince
+			found = true
+		}
+	}
+	return oldest, found
+}
@@ -0,0 +1,40 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// StartStatsReporter 在pool的后台goroutine中每隔interval调用一次report, 直到ctx结束或pool关闭;
+// 可用DiffStats计算相邻两次之间的变化. report在后台goroutine中串行调用, 耗时较长时推迟下一次调用
+func (p *ChannelPool) StartStatsReporter(ctx context.Context, interval time.Duration, report func(Stats)) error {
+	if interval <= 0 {
+		return errors.New("invalid stats report interval")
+	}
+	if report == nil {
+		return errors.New("stats report func is nil")
+	}
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return ErrClosed
+	}
+
+	p.goBackground(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.done:
+				return
+			case <-ticker.C:
+				report(p.Stats())
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,46 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_StartStatsReporter(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reports := make(chan Stats, 16)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := p.StartStatsReporter(ctx, 10*time.Millisecond, func(s Stats) { reports <- s }); err != nil {
+		t.Fatalf("StartStatsReporter error: %s", err)
+	}
+
+	first := <-reports
+	conn, _ := p.Get()
+	p.Put(conn)
+	var second Stats
+	for second.Hits == first.Hits {
+		second = <-reports
+	}
+	if d := DiffStats(first, second); d.Hits != 1 || d.Interval <= 0 {
+		t.Errorf("StartStatsReporter error. Expecting 1 hit between reports, got %+v", d)
+	}
+
+	// pool关闭后停止
+	p.Close()
+	stopCtx, stop := context.WithTimeout(context.Background(), time.Second)
+	defer stop()
+	if err := p.WaitStopped(stopCtx); err != nil {
+		t.Errorf("WaitStopped error: %s", err)
+	}
+	if err := p.StartStatsReporter(ctx, time.Second, func(Stats) {}); err != ErrClosed {
+		t.Errorf("StartStatsReporter error. Expecting %v, got %v", ErrClosed, err)
+	}
+	if err := p.StartStatsReporter(ctx, 0, func(Stats) {}); err == nil {
+		t.Errorf("StartStatsReporter error. Expecting invalid interval")
+	}
+}
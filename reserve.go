@@ -0,0 +1,179 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	ErrReservationUsed = errors.New("reservation already used")
+)
+
+// Reservation Reserve 返回的凭证, 持有一个空闲conn或者一个新建conn的名额
+type Reservation struct {
+	p *ChannelPool
+
+	mu sync.Mutex
+
+	conn net.Conn // 预留到的空闲conn, 为nil时表示预留的是新建名额
+
+	done bool // 已经Activate或Cancel
+
+	waited time.Duration // Reserve等待容量的时长
+}
+
+// Reserve 预留容量但不建立连接, 之后通过Activate获得conn, 或Cancel释放
+func (p *ChannelPool) Reserve(ctx context.Context) (*Reservation, error) {
+
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	if ctx.Err() != nil {
+		return nil, timeoutErr(ctx)
+	}
+
+	// 已达到最大链接数时等待其他conn放回
+	waited, err := p.acquireWait(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		p.release()
+		return nil, ErrClosed
+	}
+
+	// 等待者先取放回时交接的conn, 再取空闲链接
+	var (
+		conn net.Conn
+		ok   bool
+	)
+	if waited > 0 {
+		conn, ok = p.popHandoff(protocolOf(ctx))
+	}
+	if !ok {
+		conn, ok = p.popIdle(protocolOf(ctx))
+	}
+	p.settleHandoff()
+	switch {
+	case isPinned(ctx):
+		p.pinnedGets++
+	case ok:
+		p.hits++
+	default:
+		// 没有空闲链接, 持有的容量单位用于新建
+		p.misses++
+	}
+	return &Reservation{p: p, conn: conn, waited: waited}, nil
+}
+
+// Activate 使用预留的容量获得conn, 预留的是新建名额时才会调用factory
+func (p *ChannelPool) Activate(r *Reservation) (net.Conn, error) {
+	return r.activate(p.withCaller(context.Background(), 2))
+}
+
+// Activate 同 ChannelPool.Activate
+func (r *Reservation) Activate() (net.Conn, error) {
+	return r.activate(r.p.withCaller(context.Background(), 2))
+}
+
+func (r *Reservation) activate(ctx context.Context) (net.Conn, error) {
+	start := time.Now()
+	conn, err := r.take(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p := r.p
+	handshake, err := p.handshakeOnCheckout(ctx, conn)
+	if err != nil {
+		p.discard(conn, CloseReasonHandshakeFailed)
+		p.observeDegrade(ctx, err)
+		return nil, err
+	}
+	if proto := protocolOf(ctx); proto != "" {
+		if err := p.checkProtocol(conn, proto); err != nil {
+			_ = p.Put(conn)
+			return nil, err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.traceConn(conn, ConnEventCheckout, holderOf(ctx))
+	p.recordAcquire(p.conns[conn], start, r.waited, holderOf(ctx))
+	if m, ok := p.conns[conn]; ok {
+		m.acquired.HandshakeDuration = handshake
+		m.acquired.Operation = OperationFrom(ctx)
+	}
+	if s := p.operationStats(ctx); s != nil {
+		s.Gets++
+	}
+	if isPinned(ctx) {
+		p.pin(ctx, p.conns[conn])
+	} else {
+		p.startHold(p.conns[conn], holderOf(ctx))
+	}
+	return p.handle(conn), nil
+}
+
+func (r *Reservation) take(ctx context.Context) (net.Conn, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		return nil, ErrReservationUsed
+	}
+	r.done = true
+
+	p := r.p
+	if r.conn != nil {
+		if !p.checksIdle() {
+			return r.conn, nil
+		}
+		return p.validate(ctx, r.conn)
+	}
+
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		p.release()
+		return nil, ErrClosed
+	}
+
+	conn, err := p.dial(ctx)
+	if err != nil {
+		p.release()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Cancel 放弃预留, 归还占用的空闲conn或新建名额
+func (r *Reservation) Cancel() error {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.done {
+		return ErrReservationUsed
+	}
+	r.done = true
+
+	if r.conn != nil {
+		return r.p.Put(r.conn)
+	}
+	r.p.release()
+	return nil
+}
@@ -0,0 +1,129 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_Reserve(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	// 先预留完所有空闲conn
+	rs := make([]*Reservation, 0, maxConn)
+	for i := 0; i < maxFree; i++ {
+		r, err := p.Reserve(context.Background())
+		if err != nil {
+			t.Fatalf("Reserve error: %s", err)
+		}
+		if r.conn == nil {
+			t.Errorf("Reserve error. Expecting idle conn, got nil")
+		}
+		rs = append(rs, r)
+	}
+
+	// 再预留新建名额, 不会调用factory
+	for i := maxFree; i < maxConn; i++ {
+		r, err := p.Reserve(context.Background())
+		if err != nil {
+			t.Fatalf("Reserve error: %s", err)
+		}
+		if r.conn != nil {
+			t.Errorf("Reserve error. Expecting nil conn, got %v", r.conn)
+		}
+		rs = append(rs, r)
+	}
+
+	if p.OpenNum() != maxFree {
+		t.Errorf("Reserve error. Expecting %d, got %d",
+			maxFree, p.OpenNum())
+	}
+	if p.sem.Held() != int64(maxConn) {
+		t.Errorf("Reserve error. Expecting %d, got %d",
+			maxConn, p.sem.Held())
+	}
+
+	// 容量已满
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	if _, err := p.Reserve(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("Reserve error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+
+	// 放弃一个新建名额
+	if err := rs[maxConn-1].Cancel(); err != nil {
+		t.Errorf("Cancel error: %s", err)
+	}
+	if p.sem.Held() != int64(maxConn-1) {
+		t.Errorf("Cancel error. Expecting %d, got %d",
+			maxConn-1, p.sem.Held())
+	}
+	if _, err := rs[maxConn-1].Activate(); err != ErrReservationUsed {
+		t.Errorf("Activate error. Expecting %v, got %v", ErrReservationUsed, err)
+	}
+
+	// 激活剩余的预留
+	for _, r := range rs[:maxConn-1] {
+		conn, err := p.Activate(r)
+		if err != nil {
+			t.Errorf("Activate error: %s", err)
+			continue
+		}
+		if conn == nil {
+			t.Errorf("Activate error. Expecting conn, got nil")
+		}
+	}
+	if p.OpenNum() != maxConn-1 {
+		t.Errorf("Activate error. Expecting %d, got %d",
+			maxConn-1, p.OpenNum())
+	}
+}
+
+func TestChannelPool_ReserveCancelIdle(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	r, err := p.Reserve(context.Background())
+	if err != nil {
+		t.Fatalf("Reserve error: %s", err)
+	}
+	if p.Len() != maxFree-1 {
+		t.Errorf("Reserve error. Expecting %d, got %d",
+			maxFree-1, p.Len())
+	}
+
+	if err := r.Cancel(); err != nil {
+		t.Errorf("Cancel error: %s", err)
+	}
+	if p.Len() != maxFree {
+		t.Errorf("Cancel error. Expecting %d, got %d",
+			maxFree, p.Len())
+	}
+}
+
+func TestChannelPool_ActivateAfterClose(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+
+	// 拿到所有的free conn, 下一次预留为新建名额
+	for i := 0; i < maxFree; i++ {
+		if _, err := p.Get(); err != nil {
+			t.Fatalf("Get error: %s", err)
+		}
+	}
+	r, err := p.Reserve(context.Background())
+	if err != nil {
+		t.Fatalf("Reserve error: %s", err)
+	}
+
+	_ = p.Close()
+
+	if _, err := r.Activate(); err != ErrClosed {
+		t.Errorf("Activate error. Expecting %v, got %v", ErrClosed, err)
+	}
+	if p.OpenNum() != maxFree {
+		t.Errorf("Activate error. Expecting %d, got %d",
+			maxFree, p.OpenNum())
+	}
+}
@@ -0,0 +1,127 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// resolveCache 缓存主机名的解析结果, 同一主机名同时只有一个解析在进行
+type resolveCache struct {
+	lookupHost func(ctx context.Context, host string) ([]string, error)
+
+	ttl time.Duration
+
+	mu sync.Mutex
+
+	entries map[string]*resolveEntry
+
+	next uint32 // 轮流从不同的地址开始连接
+}
+
+// resolveEntry 一个主机名的解析结果
+type resolveEntry struct {
+	done chan struct{} // 解析完成时close
+
+	addrs []string
+
+	err error
+
+	canceled bool // 解析因发起解析的调用方的ctx结束而失败, 其他等待者应重新解析
+
+	expires time.Time
+}
+
+func newResolveCache(resolver *net.Resolver, ttl time.Duration) *resolveCache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &resolveCache{lookupHost: resolver.LookupHost, ttl: ttl, entries: make(map[string]*resolveEntry)}
+}
+
+// lookup 返回host的地址, 缓存未过期时不调用resolver; 解析失败的结果不缓存,
+// 共用的解析因发起方的ctx结束而失败时, ctx未结束的等待者重新解析
+func (c *resolveCache) lookup(ctx context.Context, host string) ([]string, error) {
+	for {
+		c.mu.Lock()
+		e, ok := c.entries[host]
+		if ok {
+			select {
+			case <-e.done:
+				ok = time.Now().Before(e.expires)
+			default:
+			}
+		}
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		select {
+		case <-e.done:
+			if e.canceled && ctx.Err() == nil {
+				continue
+			}
+			return e.addrs, e.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	e := &resolveEntry{done: make(chan struct{})}
+	c.entries[host] = e
+	c.mu.Unlock()
+
+	addrs, err := c.lookupHost(ctx, host)
+	c.mu.Lock()
+	e.addrs, e.err = addrs, err
+	e.canceled = err != nil && ctx.Err() != nil
+	if err == nil {
+		e.expires = time.Now().Add(c.ttl)
+	} else if c.entries[host] == e {
+		delete(c.entries, host)
+	}
+	c.mu.Unlock()
+	close(e.done)
+	return addrs, err
+}
+
+// dial 解析address中的主机名, 按地址族排列后依次用dialAddr连接, 返回第一个成功的conn
+func (c *resolveCache) dial(ctx context.Context, dialAddr func(ctx context.Context, network, address string) (net.Conn, error),
+	network, address string, family AddressFamily, require bool) (net.Conn, error) {
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	var addrs []string
+	if net.ParseIP(host) != nil {
+		addrs = []string{host}
+	} else if addrs, err = c.lookup(ctx, host); err != nil {
+		return nil, err
+	}
+
+	// 轮流从不同的地址开始, 再按地址族排列
+	start := int(atomic.AddUint32(&c.next, 1))
+	rotated := make([]string, len(addrs))
+	for i := range addrs {
+		rotated[i] = addrs[(start+i)%len(addrs)]
+	}
+	rotated = orderAddrs(rotated, family, require)
+	if len(rotated) == 0 {
+		return nil, fmt.Errorf("%w: %s has no %s address", ErrAddressFamily, host, family)
+	}
+
+	for _, addr := range rotated {
+		var conn net.Conn
+		conn, err = dialAddr(ctx, network, net.JoinHostPort(addr, port))
+		if err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,121 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResolveCache(t *testing.T) {
+	var lookups int32
+	c := newResolveCache(nil, 50*time.Millisecond)
+	c.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&lookups, 1)
+		time.Sleep(10 * time.Millisecond)
+		if host == "bad.example" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+
+	// 同时解析只调用一次
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if addrs, err := c.lookup(context.Background(), "backend.example"); err != nil || len(addrs) != 1 {
+				t.Errorf("lookup error. Expecting 1 addr, got %v %v", addrs, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&lookups); n != 1 {
+		t.Errorf("lookup error. Expecting %d lookups, got %d", 1, n)
+	}
+
+	// 过期后重新解析
+	time.Sleep(60 * time.Millisecond)
+	c.lookup(context.Background(), "backend.example")
+	if n := atomic.LoadInt32(&lookups); n != 2 {
+		t.Errorf("lookup error. Expecting %d lookups, got %d", 2, n)
+	}
+
+	// 失败不缓存
+	for i := 0; i < 2; i++ {
+		if _, err := c.lookup(context.Background(), "bad.example"); err == nil {
+			t.Errorf("lookup error. Expecting error")
+		}
+	}
+	if n := atomic.LoadInt32(&lookups); n != 4 {
+		t.Errorf("lookup error. Expecting %d lookups, got %d", 4, n)
+	}
+}
+
+func TestResolveCache_CanceledLookup(t *testing.T) {
+	var lookups int32
+	c := newResolveCache(nil, time.Minute)
+	c.lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		// 第一次解析一直等到发起方的ctx结束
+		if atomic.AddInt32(&lookups, 1) == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []string{"127.0.0.1"}, nil
+	}
+	dialAddr := func(ctx context.Context, network, address string) (net.Conn, error) {
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.dial(ctx, dialAddr, "tcp", "backend.example:80", FamilyUnknown, false)
+		first <- err
+	}()
+	for atomic.LoadInt32(&lookups) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan error, 1)
+	go func() {
+		conn, err := c.dial(context.Background(), dialAddr, "tcp", "backend.example:80", FamilyUnknown, false)
+		if err == nil {
+			conn.Close()
+		}
+		second <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// 第一个调用方取消, 第二个不受影响, 重新解析
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("dial error. Expecting %v, got %v", context.Canceled, err)
+	}
+	if err := <-second; err != nil {
+		t.Errorf("dial error. Expecting success, got %v", err)
+	}
+	if n := atomic.LoadInt32(&lookups); n != 2 {
+		t.Errorf("lookup error. Expecting %d lookups, got %d", 2, n)
+	}
+}
+
+func TestTCPFactory_Resolver(t *testing.T) {
+	_, port, _ := net.SplitHostPort(address)
+	f := TCPFactoryContext(network, net.JoinHostPort("localhost", port), WithResolver(nil, time.Minute))
+	for i := 0; i < 2; i++ {
+		conn, err := f(context.Background())
+		if err != nil {
+			t.Fatalf("dial error: %s", err)
+		}
+		if conn.RemoteAddr().String() != address {
+			t.Errorf("dial error. Expecting %s, got %s", address, conn.RemoteAddr())
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,44 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// createRetry 同create, 开启WithDialRetry时后端不可用则在ctx的剩余时间内重新新建, 返回最后一次的error
+func (p *ChannelPool) createRetry(ctx context.Context) (net.Conn, error) {
+	if p.dialTries <= 1 {
+		return p.create(ctx)
+	}
+	var err error
+	for try := 0; try < p.dialTries; try++ {
+		if try > 0 {
+			p.mu.Lock()
+			p.dialRetryNum++
+			p.mu.Unlock()
+		}
+		tryCtx, cancel := tryContext(ctx, p.dialTries-try)
+		var conn net.Conn
+		conn, err = p.create(tryCtx)
+		cancel()
+		if err == nil {
+			return conn, nil
+		}
+		// 探测期间的快速失败和pool自身的限制换一个后端也不会成功
+		if !isBackendFailure(ctx, err) || errors.Is(err, ErrBackendUnavailable) {
+			break
+		}
+	}
+	return nil, err
+}
+
+// tryContext 把ctx的剩余时间平分给剩余的tries次尝试, ctx没有截止时间时不限制
+func tryContext(ctx context.Context, tries int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || tries <= 1 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(tries))
+}
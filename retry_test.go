@@ -0,0 +1,109 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errBackendDown = errors.New("backend down")
+
+func TestChannelPool_DialRetry(t *testing.T) {
+	// 奇数次连接不可用的后端, 偶数次连接可用的后端
+	var calls int32
+	f := func(ctx context.Context) (net.Conn, error) {
+		if atomic.AddInt32(&calls, 1)%2 == 1 {
+			return nil, errBackendDown
+		}
+		return net.Dial(network, address)
+	}
+
+	p, err := NewChannelPoolContext(1, 2, f, WithInitialConns(0), WithDialRetry(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	_ = p.Put(conn)
+	if s := p.Stats(); s.DialRetries != 1 {
+		t.Errorf("Stats error. Expecting %d, got %d", 1, s.DialRetries)
+	}
+
+	// 未开启时返回第一个后端的错误
+	atomic.StoreInt32(&calls, 0)
+	p2, err := NewChannelPoolContext(1, 2, f, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p2.Close()
+	if _, err := p2.Get(); !errors.Is(err, errBackendDown) {
+		t.Errorf("Get error. Expecting %v, got %v", errBackendDown, err)
+	}
+}
+
+func TestChannelPool_DialRetryExhausted(t *testing.T) {
+	var calls int32
+	p, err := NewChannelPoolContext(1, 2, func(ctx context.Context) (net.Conn, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errBackendDown
+	}, WithInitialConns(0), WithDialRetry(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if _, err := p.Get(); !errors.Is(err, errBackendDown) {
+		t.Errorf("Get error. Expecting %v, got %v", errBackendDown, err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("factory error. Expecting %d calls, got %d", 3, n)
+	}
+
+	// 调用方取消不重试
+	atomic.StoreInt32(&calls, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = p.GetWitchContext(ctx)
+	if n := atomic.LoadInt32(&calls); n > 1 {
+		t.Errorf("factory error. Expecting at most %d call, got %d", 1, n)
+	}
+}
+
+func TestChannelPool_DialRetryBudget(t *testing.T) {
+	// 第一个后端无响应, 直到本次尝试的ctx结束
+	var calls int32
+	p, err := NewChannelPoolContext(1, 2, func(ctx context.Context) (net.Conn, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return net.Dial(network, address)
+	}, WithInitialConns(0), WithDialRetry(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*400)
+	defer cancel()
+	start := time.Now()
+	conn, err := p.GetWitchContext(ctx)
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if d := time.Since(start); d > time.Millisecond*350 {
+		t.Errorf("Get error. Expecting the first try to use half of the budget, took %s", d)
+	}
+	_ = p.Put(conn)
+
+	if _, err := NewChannelPool(1, 2, factory, WithDialRetry(-1)); err == nil {
+		t.Error("NewChannelPool error. Expecting invalid dial retry rejected")
+	}
+}
@@ -0,0 +1,175 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Distribution 按分布产生一个时长, 用于模拟新建耗时和持有时间; r不是并发安全的, 由调用方加锁
+type Distribution func(r *rand.Rand) time.Duration
+
+// ConstantDist 总是返回d
+func ConstantDist(d time.Duration) Distribution {
+	return func(*rand.Rand) time.Duration { return d }
+}
+
+// UniformDist [min, max)上的均匀分布
+func UniformDist(min, max time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		if max <= min {
+			return min
+		}
+		return min + time.Duration(r.Int63n(int64(max-min)))
+	}
+}
+
+// ExponentialDist 均值为mean的指数分布
+func ExponentialDist(mean time.Duration) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(r.ExpFloat64() * float64(mean))
+	}
+}
+
+// LogNormalDist 中位数为median的对数正态分布, sigma越大长尾越明显, 适合模拟请求耗时
+func LogNormalDist(median time.Duration, sigma float64) Distribution {
+	return func(r *rand.Rand) time.Duration {
+		return time.Duration(float64(median) * math.Exp(sigma*r.NormFloat64()))
+	}
+}
+
+// SimConfig 模拟的pool参数和负载
+type SimConfig struct {
+	MaxFree, MaxConn int64
+
+	Options []Option // 创建pool的其他配置, 与实际使用的一致
+
+	Rate float64 // 每秒获取conn的次数, 到达间隔服从指数分布
+
+	Duration time.Duration // 模拟时长
+
+	GetTimeout time.Duration // 每次获取的超时时间, <= 0 不限制
+
+	Dial Distribution // 新建conn的耗时, nil 不耗时
+
+	DialFailure float64 // 新建conn失败的概率, 创建pool时的初始conn也会失败, 需要时在Options中加入WithBestEffortFill
+
+	Hold Distribution // 持有conn的时间, nil 取出后立即放回
+
+	Seed int64 // 随机数种子, 相同的种子产生相同的到达序列
+}
+
+// SimResult 模拟的结果
+type SimResult struct {
+	Gets int // 发起的获取次数
+
+	Errors int // 获取失败的次数
+
+	SLO SLOIndicators // 模拟期间pool的SLO指标
+
+	Stats Stats // 模拟结束时pool的统计信息
+}
+
+var errSimDial = errors.New("simulated dial failure")
+
+// Simulate 用内存conn(net.Pipe)代替socket, 按cfg的负载驱动一个真实的pool, 用于容量规划,
+// 如评估2倍流量下maxConn=50是否足够; 模拟按实际时间运行, ctx结束时停止发起新的获取并等待已发起的完成
+func Simulate(ctx context.Context, cfg SimConfig) (SimResult, error) {
+	if cfg.Rate <= 0 || cfg.Duration <= 0 {
+		return SimResult{}, errors.New("invalid simulation rate or duration")
+	}
+
+	var mu sync.Mutex
+	r := rand.New(rand.NewSource(cfg.Seed))
+	sample := func(d Distribution) time.Duration {
+		if d == nil {
+			return 0
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		return d(r)
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return r.Float64() < cfg.DialFailure
+	}
+
+	var peers sync.Map // 模拟对端, pool关闭后一并关闭
+	factory := func(ctx context.Context) (net.Conn, error) {
+		timer := time.NewTimer(sample(cfg.Dial))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		if failed() {
+			return nil, errSimDial
+		}
+		client, server := net.Pipe()
+		peers.Store(server, struct{}{})
+		return client, nil
+	}
+	p, err := NewChannelPoolContext(cfg.MaxFree, cfg.MaxConn, factory, cfg.Options...)
+	if err != nil {
+		return SimResult{}, err
+	}
+	defer func() {
+		_ = p.Close()
+		peers.Range(func(k, _ interface{}) bool {
+			_ = k.(net.Conn).Close()
+			return true
+		})
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		errs int64
+		res  SimResult
+	)
+	before := p.Stats()
+	interval := ExponentialDist(time.Duration(float64(time.Second) / cfg.Rate))
+	for {
+		timer := time.NewTimer(sample(interval))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		res.Gets++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			getCtx, getCancel := context.Background(), context.CancelFunc(func() {})
+			if cfg.GetTimeout > 0 {
+				getCtx, getCancel = context.WithTimeout(getCtx, cfg.GetTimeout)
+			}
+			conn, err := p.GetWitchContext(getCtx)
+			getCancel()
+			if err != nil {
+				atomic.AddInt64(&errs, 1)
+				return
+			}
+			time.Sleep(sample(cfg.Hold))
+			_ = p.Put(conn)
+		}()
+	}
+	wg.Wait()
+
+	res.Errors = int(errs)
+	res.Stats = p.Stats()
+	res.SLO = ComputeSLO(before, res.Stats)
+	return res, nil
+}
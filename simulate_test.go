@@ -0,0 +1,62 @@
+package pool
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSimulate(t *testing.T) {
+	cfg := SimConfig{
+		MaxFree:    2,
+		MaxConn:    2,
+		Rate:       200,
+		Duration:   300 * time.Millisecond,
+		GetTimeout: 10 * time.Millisecond,
+		Dial:       ConstantDist(time.Millisecond),
+		Hold:       ConstantDist(20 * time.Millisecond),
+		Seed:       1,
+	}
+	small, err := Simulate(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if small.Gets == 0 || small.Errors == 0 {
+		t.Errorf("Simulate error. Expecting timeouts with maxConn=2, got %d gets %d errors", small.Gets, small.Errors)
+	}
+	if small.Stats.Open > 2 {
+		t.Errorf("Simulate error. Expecting at most %d open, got %d", 2, small.Stats.Open)
+	}
+
+	cfg.MaxConn = 20
+	large, err := Simulate(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if large.Errors != 0 || large.SLO.AcquireSuccessRate != 1 {
+		t.Errorf("Simulate error. Expecting no errors with maxConn=20, got %d %v", large.Errors, large.SLO.AcquireSuccessRate)
+	}
+
+	if _, err := Simulate(context.Background(), SimConfig{MaxFree: 1, MaxConn: 1}); err == nil {
+		t.Error("Simulate error. Expecting invalid rate")
+	}
+}
+
+func TestDistribution(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		if d := UniformDist(time.Millisecond, 2*time.Millisecond)(r); d < time.Millisecond || d >= 2*time.Millisecond {
+			t.Fatalf("UniformDist error. Expecting [1ms, 2ms), got %s", d)
+		}
+		if d := ExponentialDist(time.Millisecond)(r); d < 0 {
+			t.Fatalf("ExponentialDist error. Expecting >= 0, got %s", d)
+		}
+		if d := LogNormalDist(time.Millisecond, 0.5)(r); d <= 0 {
+			t.Fatalf("LogNormalDist error. Expecting > 0, got %s", d)
+		}
+	}
+	if d := ConstantDist(time.Second)(r); d != time.Second {
+		t.Errorf("ConstantDist error. Expecting %s, got %s", time.Second, d)
+	}
+}
@@ -0,0 +1,145 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// waitBounds 等待时长分布的桶上界, 最后一个桶不设上界
+var waitBounds = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// WaitBucket 等待时长分布中的一个桶, 计数从pool创建开始累计
+type WaitBucket struct {
+	UpperBound time.Duration // 桶上界, 0 表示不设上界
+
+	Count int64 // 等待时长落在 (上一个桶上界, UpperBound] 的次数, 包括超时和pool关闭
+}
+
+// observeWait 记录一次等待容量的时长, 需持有p.mu
+func (p *ChannelPool) observeWait(waited time.Duration) {
+	i := 0
+	for i < len(waitBounds) && waited > waitBounds[i] {
+		i++
+	}
+	p.waitHist[i]++
+}
+
+// waitBuckets 需持有p.mu
+func (p *ChannelPool) waitBuckets() []WaitBucket {
+	buckets := make([]WaitBucket, len(p.waitHist))
+	for i, n := range p.waitHist {
+		if i < len(waitBounds) {
+			buckets[i].UpperBound = waitBounds[i]
+		}
+		buckets[i].Count = n
+	}
+	return buckets
+}
+
+// countAcquire 记录一次获取conn的结果
+func (p *ChannelPool) countAcquire(err error) {
+	atomic.AddInt64(&p.acquireNum, 1)
+	if err != nil {
+		atomic.AddInt64(&p.acquireErrNum, 1)
+	}
+}
+
+// diffWaitBuckets b减去a, 桶不一致时返回nil
+func diffWaitBuckets(a, b []WaitBucket) []WaitBucket {
+	if len(a) != len(b) {
+		return nil
+	}
+	d := make([]WaitBucket, len(b))
+	for i := range b {
+		d[i] = WaitBucket{UpperBound: b[i].UpperBound, Count: b[i].Count - a[i].Count}
+	}
+	return d
+}
+
+// AcquireSuccessRate 获取conn成功的比例, 期间没有获取时为1
+func (d StatsDelta) AcquireSuccessRate() float64 {
+	if d.Acquires == 0 {
+		return 1
+	}
+	return 1 - ratio(d.AcquireErrors, d.Acquires)
+}
+
+// WaitQuantile 按等待时长分布估算期间等待时长的分位数, 返回所在桶的上界;
+// 落在最后一个桶时返回其下界, 即实际值不小于返回值. 没有等待时返回0
+func (d StatsDelta) WaitQuantile(q float64) time.Duration {
+	var total int64
+	for _, b := range d.WaitBuckets {
+		total += b.Count
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := int64(q * float64(total))
+	if rank >= total {
+		rank = total - 1
+	}
+	var seen int64
+	for i, b := range d.WaitBuckets {
+		seen += b.Count
+		if seen <= rank {
+			continue
+		}
+		if b.UpperBound == 0 && i > 0 {
+			return d.WaitBuckets[i-1].UpperBound
+		}
+		return b.UpperBound
+	}
+	return 0
+}
+
+// SLOIndicators 由两次Stats采样计算的面向SLO的指标
+type SLOIndicators struct {
+	Interval time.Duration
+
+	Acquires int64 // 期间获取conn的次数
+
+	AcquireSuccessRate float64 // 获取conn成功的比例, 没有获取时为1
+
+	WaitRate float64 // 获取conn时需要等待的比例
+
+	P99Wait time.Duration // 等待时长的P99估算值, 见StatsDelta.WaitQuantile
+
+	ChurnRate float64 // 每秒替换的conn数
+
+	ChurnRatio float64 // 期间新建的conn数与期末打开的conn数之比, 大于1说明conn基本没有被复用
+}
+
+// ComputeSLO 计算从a到b期间的SLO指标, a应早于b; 多个pool使用相同的计算方式, 便于统一接入错误预算
+func ComputeSLO(a, b Stats) SLOIndicators {
+	d := DiffStats(a, b)
+	return SLOIndicators{
+		Interval:           d.Interval,
+		Acquires:           d.Acquires,
+		AcquireSuccessRate: d.AcquireSuccessRate(),
+		WaitRate:           d.WaitRate(),
+		P99Wait:            d.WaitQuantile(0.99),
+		ChurnRate:          d.ChurnRate(),
+		ChurnRatio:         ratio(d.Created, int64(b.Open)),
+	}
+}
+
+// BurnRate 获取conn成功率目标为objective(如0.999)时错误预算的消耗速度, 1 表示恰好在期限内用完预算; objective不小于1时返回0
+func (s SLOIndicators) BurnRate(objective float64) float64 {
+	if objective >= 1 {
+		return 0
+	}
+	return (1 - s.AcquireSuccessRate) / (1 - objective)
+}
@@ -0,0 +1,70 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestComputeSLO(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	a := p.Stats()
+	conn, _ := p.Get()
+	// 一次等待超时, 一次等待约20ms后获得
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	if _, err := p.GetWitchContext(ctx); err == nil {
+		t.Error("Get error. Expecting time out")
+	}
+	cancel()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = p.Put(conn)
+	}()
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = p.Put(conn)
+	b := p.Stats()
+
+	s := ComputeSLO(a, b)
+	if s.Acquires != 3 {
+		t.Errorf("Acquires error. Expecting %d, got %d", 3, s.Acquires)
+	}
+	if s.AcquireSuccessRate < 0.66 || s.AcquireSuccessRate > 0.67 {
+		t.Errorf("AcquireSuccessRate error. Expecting %v, got %v", 2.0/3, s.AcquireSuccessRate)
+	}
+	if s.P99Wait < 25*time.Millisecond || s.P99Wait > 100*time.Millisecond {
+		t.Errorf("P99Wait error. Expecting within (25ms, 100ms], got %s", s.P99Wait)
+	}
+	if r := s.BurnRate(0.99); r < 33 || r > 34 {
+		t.Errorf("BurnRate error. Expecting %v, got %v", 100.0/3, r)
+	}
+
+	if s := ComputeSLO(b, b); s.AcquireSuccessRate != 1 || s.P99Wait != 0 || s.BurnRate(0.999) != 0 {
+		t.Errorf("ComputeSLO error. Expecting no budget burned without acquires, got %+v", s)
+	}
+}
+
+func TestStatsDelta_WaitQuantile(t *testing.T) {
+	d := StatsDelta{WaitBuckets: []WaitBucket{
+		{UpperBound: time.Millisecond, Count: 90},
+		{UpperBound: 10 * time.Millisecond, Count: 9},
+		{Count: 1},
+	}}
+	if q := d.WaitQuantile(0.5); q != time.Millisecond {
+		t.Errorf("WaitQuantile error. Expecting %s, got %s", time.Millisecond, q)
+	}
+	if q := d.WaitQuantile(0.95); q != 10*time.Millisecond {
+		t.Errorf("WaitQuantile error. Expecting %s, got %s", 10*time.Millisecond, q)
+	}
+	// 落在不设上界的桶时返回其下界
+	if q := d.WaitQuantile(0.999); q != 10*time.Millisecond {
+		t.Errorf("WaitQuantile error. Expecting %s, got %s", 10*time.Millisecond, q)
+	}
+}
@@ -0,0 +1,81 @@
+package pool
+
+import (
+	"sort"
+	"time"
+)
+
+// ConnSummary 单个conn的状态
+type ConnSummary struct {
+	ID uint64
+
+	CreatedAt time.Time
+
+	Idle bool
+
+	IdleSince time.Time // 最近一次放回pool的时间
+
+	Pinned bool // 通过GetPinned取出
+
+	Holder string // 取出方, 仅被持有时间采样的取出有值
+
+	HalfClosed bool
+
+	DialDuration time.Duration
+
+	CredentialExpiresAt time.Time // 凭据过期时间, 零值表示没有凭据或不过期
+
+	TLS *TLSInfo // TLS参数, 不是TLS conn或尚未握手时为nil
+}
+
+// PoolSnapshot pool在某一时刻的状态, 各字段都是拷贝, 之后pool的变化不影响快照
+type PoolSnapshot struct {
+	Stats // 上限和计数
+
+	Closed bool
+
+	InitialConns int
+	MaxPinned    int
+	MaxIdleTime  time.Duration
+	MaxLifetime  time.Duration
+
+	Conns []ConnSummary // 已创建未关闭的conn, 按ID排序
+}
+
+// Snapshot 在同一时刻采集pool的上限、计数和各conn的状态
+func (p *ChannelPool) Snapshot() PoolSnapshot {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	s := PoolSnapshot{
+		Stats:        p.stats(),
+		Closed:       p.closed,
+		InitialConns: int(p.initialConns),
+		MaxPinned:    int(p.maxPinned),
+		MaxIdleTime:  p.maxIdleTime,
+		MaxLifetime:  p.maxLifetime,
+		Conns:        make([]ConnSummary, 0, len(p.conns)),
+	}
+	for _, m := range p.conns {
+		c := ConnSummary{
+			ID:                  m.id,
+			CreatedAt:           m.createdAt,
+			Idle:                m.idle,
+			IdleSince:           m.idleSince,
+			Pinned:              m.pinned,
+			HalfClosed:          m.halfClosed,
+			DialDuration:        m.dialDuration,
+			CredentialExpiresAt: m.cred.ExpiresAt,
+		}
+		if m.tls != nil {
+			info := *m.tls
+			c.TLS = &info
+		}
+		if m.hold != nil {
+			c.Holder = m.hold.Holder
+		}
+		s.Conns = append(s.Conns, c)
+	}
+	sort.Slice(s.Conns, func(i, j int) bool { return s.Conns[i].ID < s.Conns[j].ID })
+	return s
+}
@@ -0,0 +1,51 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestChannelPool_Snapshot(t *testing.T) {
+	p, err := NewChannelPool(2, 3, factory, WithHoldProfile(1, 4, false), WithMaxPinned(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pinned, err := p.GetPinned(context.Background())
+	if err != nil {
+		t.Fatalf("GetPinned error: %s", err)
+	}
+
+	s := p.Snapshot()
+	if s.MaxFree != 2 || s.MaxConn != 3 || s.MaxPinned != 1 || s.InitialConns != 2 || s.Closed {
+		t.Errorf("Snapshot error. Unexpected limits %+v", s)
+	}
+	if s.Open != 2 || s.InUse != 2 || s.Pinned != 1 || len(s.Conns) != 2 {
+		t.Fatalf("Snapshot error. Expecting %d open %d conns, got %d %d", 2, 2, s.Open, len(s.Conns))
+	}
+	if s.Conns[0].ID != 1 || s.Conns[1].ID != 2 {
+		t.Errorf("Snapshot error. Expecting conns sorted by id, got %d %d", s.Conns[0].ID, s.Conns[1].ID)
+	}
+	if s.Conns[0].Idle || !strings.HasPrefix(s.Conns[0].Holder, "snapshot_test.go:") {
+		t.Errorf("Snapshot error. Expecting conn 1 held by test, got %+v", s.Conns[0])
+	}
+	if !s.Conns[1].Pinned {
+		t.Errorf("Snapshot error. Expecting conn 2 pinned")
+	}
+
+	// 快照不随pool变化
+	p.Put(conn)
+	p.Put(pinned)
+	if s.Conns[0].Idle || s.Idle != 0 {
+		t.Errorf("Snapshot error. Expecting snapshot unchanged")
+	}
+	if s2 := p.Snapshot(); !s2.Conns[0].Idle || s2.Idle != 2 {
+		t.Errorf("Snapshot error. Expecting conns idle after Put")
+	}
+}
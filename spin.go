@@ -0,0 +1,17 @@
+package pool
+
+import "runtime"
+
+// spinAcquire 容量已满时让出CPU并重试获得, 最多p.spinWait次, 用于在conn很快放回时避免进入等待队列
+func (p *ChannelPool) spinAcquire() bool {
+	for i := 0; i < p.spinWait; i++ {
+		runtime.Gosched()
+		if p.sem.TryAcquire(1) {
+			p.mu.Lock()
+			p.spinHits++
+			p.mu.Unlock()
+			return true
+		}
+	}
+	return false
+}
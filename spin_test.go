@@ -0,0 +1,71 @@
+package pool
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_SpinWait(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory, WithSpinWait(1000000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(time.Millisecond)
+		p.Put(conn)
+	}()
+
+	// conn很快放回, 自旋期间获得而不进入等待队列
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if s := p.Stats(); s.SpinHits != 1 || s.Waits != 0 {
+		t.Errorf("Stats error. Expecting %d spin hit %d waits, got %d %d", 1, 0, s.SpinHits, s.Waits)
+	}
+
+	if _, err := NewChannelPool(1, 1, factory, WithSpinWait(-1)); err == nil {
+		t.Errorf("NewChannelPool error. Expecting invalid spin wait")
+	}
+}
+
+// BenchmarkSpinWait 持有时间极短时自旋和直接进入等待队列的获取开销
+func BenchmarkSpinWait(b *testing.B) {
+	for _, spins := range []int{0, 10, 100} {
+		b.Run(fmt.Sprintf("spins=%d", spins), func(b *testing.B) {
+			p, err := NewChannelPool(4, 4, factory, WithSpinWait(spins))
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer p.Close()
+
+			b.SetParallelism(16)
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					conn, err := p.Get()
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					// 持有期间让出, 让其他goroutine遇到容量已满
+					runtime.Gosched()
+					p.Put(conn)
+				}
+			})
+			b.StopTimer()
+			s := p.Stats()
+			b.ReportMetric(float64(s.Waits)/float64(b.N), "waits/op")
+			b.ReportMetric(float64(s.SpinHits)/float64(b.N), "spins/op")
+		})
+	}
+}
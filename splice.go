@@ -0,0 +1,72 @@
+package pool
+
+import (
+	"io"
+)
+
+// ReadFrom 把r中的数据写入conn; conn支持io.ReaderFrom时交给conn处理, 保留*net.TCPConn的splice/sendfile;
+// r为PoolConn时先取出其使用的conn, 两端都是TCP时可以直接splice
+func (c *PoolConn) ReadFrom(r io.Reader) (int64, error) {
+	if err := c.checkReturned(); err != nil {
+		return 0, err
+	}
+	rf, ok := c.Conn.(io.ReaderFrom)
+	if !ok {
+		return io.Copy(writerOnly{c.Conn}, r)
+	}
+	switch v := r.(type) {
+	case *PoolConn:
+		if err := v.checkReturned(); err != nil {
+			return 0, err
+		}
+		return rf.ReadFrom(v.Conn)
+	case *io.LimitedReader:
+		if pc, ok := v.R.(*PoolConn); ok {
+			if err := pc.checkReturned(); err != nil {
+				return 0, err
+			}
+			lr := &io.LimitedReader{R: pc.Conn, N: v.N}
+			n, err := rf.ReadFrom(lr)
+			v.N = lr.N
+			return n, err
+		}
+	}
+	return rf.ReadFrom(r)
+}
+
+// WriteTo 把conn上收到的数据写入w直到EOF; conn支持io.WriterTo时交给conn处理, 保留splice
+func (c *PoolConn) WriteTo(w io.Writer) (int64, error) {
+	if err := c.checkReturned(); err != nil {
+		return 0, err
+	}
+	if wt, ok := c.Conn.(io.WriterTo); ok {
+		return wt.WriteTo(w)
+	}
+	return io.Copy(w, readerOnly{c.Conn})
+}
+
+// ReadFrom 写缓冲为空时直接交给conn, 否则先填满写缓冲, 保证数据顺序
+func (c *BufferedPoolConn) ReadFrom(r io.Reader) (int64, error) {
+	if err := c.checkReturned(); err != nil {
+		return 0, err
+	}
+	return c.Writer.ReadFrom(r)
+}
+
+// WriteTo 先写出读缓冲中的数据, 之后直接交给conn
+func (c *BufferedPoolConn) WriteTo(w io.Writer) (int64, error) {
+	if err := c.checkReturned(); err != nil {
+		return 0, err
+	}
+	return c.Reader.WriteTo(w)
+}
+
+// writerOnly 隐藏io.ReaderFrom, 避免io.Copy回调ReadFrom
+type writerOnly struct {
+	io.Writer
+}
+
+// readerOnly 隐藏io.WriterTo, 避免io.Copy回调WriteTo
+type readerOnly struct {
+	io.Reader
+}
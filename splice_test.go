@@ -0,0 +1,181 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+)
+
+// spliceConn 记录ReadFrom/WriteTo调用的conn
+type spliceConn struct {
+	net.Conn
+
+	readFrom []io.Reader
+
+	writeTo int
+}
+
+func (c *spliceConn) ReadFrom(r io.Reader) (int64, error) {
+	c.readFrom = append(c.readFrom, r)
+	return io.Copy(writerOnly{c.Conn}, r)
+}
+
+func (c *spliceConn) WriteTo(w io.Writer) (int64, error) {
+	c.writeTo++
+	return io.Copy(w, readerOnly{c.Conn})
+}
+
+func TestPoolConn_ReadFromWriteTo(t *testing.T) {
+	p, err := NewChannelPool(2, 2, TCPFactory("tcp", lineEchoServer(t)), WithWrapConn(func(conn net.Conn) net.Conn {
+		return &spliceConn{Conn: conn}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := conn.(*PoolConn)
+	sc := pc.Conn.(*spliceConn)
+	if _, err := io.Copy(pc, readerOnly{bytes.NewReader([]byte("a\n"))}); err != nil {
+		t.Fatalf("ReadFrom error: %s", err)
+	}
+	if len(sc.readFrom) != 1 {
+		t.Errorf("ReadFrom error. Expecting %d calls, got %d", 1, len(sc.readFrom))
+	}
+
+	// 另一个PoolConn作为源时取出其使用的conn
+	conn2, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc2 := conn2.(*PoolConn)
+	conn2.Write([]byte("b\n"))
+	pc.ReadFrom(&io.LimitedReader{R: pc2, N: 2})
+	if r, ok := sc.readFrom[1].(*io.LimitedReader); !ok || r.R != pc2.Conn {
+		t.Errorf("ReadFrom error. Expecting unwrapped conn, got %T", sc.readFrom[1])
+	}
+	p.Put(conn2)
+
+	line, err := io.ReadAll(&io.LimitedReader{R: pc, N: 4})
+	if err != nil || string(line) != "a\nb\n" {
+		t.Errorf("WriteTo error. Expecting %q, got %q %v", "a\nb\n", line, err)
+	}
+	// spliceConn不支持半关闭, 直接关闭底层conn的写端
+	pc.RawConn().(*net.TCPConn).CloseWrite()
+	var buf bytes.Buffer
+	if _, err := io.Copy(writerOnly{&buf}, pc); err != nil {
+		t.Fatalf("WriteTo error: %s", err)
+	}
+	if sc.writeTo != 1 {
+		t.Errorf("WriteTo error. Expecting %d calls, got %d", 1, sc.writeTo)
+	}
+	p.Put(conn)
+}
+
+func TestBufferedPoolConn_ReadFrom(t *testing.T) {
+	p, err := NewChannelPool(1, 2, TCPFactory("tcp", lineEchoServer(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	c, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Put(c)
+
+	// 写缓冲中的数据先于ReadFrom的数据发送
+	c.Write([]byte("a"))
+	if _, err := io.Copy(c, readerOnly{bytes.NewReader([]byte("b\n"))}); err != nil {
+		t.Fatalf("ReadFrom error: %s", err)
+	}
+	c.Flush()
+	line, err := c.Reader.ReadString('\n')
+	if err != nil || line != "ab\n" {
+		t.Errorf("ReadFrom error. Expecting %q, got %q %v", "ab\n", line, err)
+	}
+}
+
+// zeroServer 每个conn上持续发送数据
+func zeroServer(b testing.TB) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { ln.Close() })
+	go func() {
+		buf := make([]byte, 64<<10)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					if _, err := conn.Write(buf); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// BenchmarkProxyCopy 在两个pool的conn之间转发数据, splice时B/op接近0,
+// 隐藏ReadFrom/WriteTo后每次复制都分配缓冲区并经过用户态
+func BenchmarkProxyCopy(b *testing.B) {
+	const chunk = 1 << 20
+	src, err := NewChannelPool(1, 1, TCPFactory("tcp", zeroServer(b)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer src.Close()
+	dst, err := NewChannelPool(1, 1, TCPFactory("tcp", streamServer(b, nil)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer dst.Close()
+
+	for _, bc := range []struct {
+		name string
+		copy func(w io.Writer, r io.Reader) (int64, error)
+	}{
+		{"Splice", func(w io.Writer, r io.Reader) (int64, error) {
+			return io.CopyN(w, r, chunk)
+		}},
+		{"Userspace", func(w io.Writer, r io.Reader) (int64, error) {
+			return io.CopyN(writerOnly{w}, readerOnly{r}, chunk)
+		}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			r, err := src.Get()
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer src.Put(r)
+			w, err := dst.Get()
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer dst.Put(w)
+
+			b.SetBytes(chunk)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.copy(w, r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package sqlconn 把 ConnPool 作为 database/sql 的连接来源.
+//
+// database/sql 的连接使用pool的容量限制和健康检查. 关闭连接时默认关闭driver.Conn并丢弃底层net.Conn;
+// 设置WithReset后由ResetFunc把net.Conn恢复到刚建立时的状态, 然后放回pool复用.
+package sqlconn
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"sync"
+)
+
+// Source 提供net.Conn的pool, ConnPool.NewChannelPool 返回的pool满足该接口
+type Source interface {
+	GetWitchContext(ctx context.Context) (net.Conn, error)
+	Put(net.Conn) error
+	Discard(net.Conn) error
+}
+
+// OpenFunc 在pool取出的net.Conn上建立driver.Conn, 例如完成协议握手
+type OpenFunc func(ctx context.Context, conn net.Conn) (driver.Conn, error)
+
+// ResetFunc 在database/sql关闭连接时调用, 不关闭driver.Conn, 而是把底层net.Conn恢复到刚从factory建立时的状态
+// (例如结束协议会话), 使下次Connect可以在其上重新执行OpenFunc; 返回error时关闭driver.Conn并丢弃net.Conn
+type ResetFunc func(dc driver.Conn, raw net.Conn) error
+
+// Option Connector的配置
+type Option func(*Connector)
+
+// WithReset 关闭连接时用reset恢复底层net.Conn并放回pool复用, 未设置时关闭driver.Conn并丢弃net.Conn
+func WithReset(reset ResetFunc) Option {
+	return func(c *Connector) {
+		c.reset = reset
+	}
+}
+
+// Connector 实现 driver.Connector, 通过 sql.OpenDB 使用
+type Connector struct {
+	src   Source
+	drv   driver.Driver
+	open  OpenFunc
+	reset ResetFunc
+}
+
+// NewConnector 创建Connector, drv 为 Driver() 的返回值, 可以为nil
+func NewConnector(src Source, drv driver.Driver, open OpenFunc, opts ...Option) *Connector {
+	c := &Connector{src: src, drv: drv, open: open}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Connect 从pool取出conn并建立driver.Conn, 失败时丢弃conn; 交给OpenFunc的net.Conn在Close时被丢弃而不是放回pool
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	raw, err := c.src.GetWitchContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nc := &netConn{Conn: raw, src: c.src}
+	dc, err := c.open(ctx, nc)
+	if err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+	return &conn{Conn: dc, raw: nc, src: c.src, reset: c.reset}, nil
+}
+
+// Driver 返回NewConnector时传入的driver
+func (c *Connector) Driver() driver.Driver {
+	return c.drv
+}
+
+var errNotSupported = errors.New("sqlconn: operation not supported by driver conn")
+
+// netConn 交给OpenFunc的net.Conn, driver.Conn关闭它时从pool丢弃, 不会在未知状态下被复用
+type netConn struct {
+	net.Conn
+	src  Source
+	once sync.Once
+	err  error
+}
+
+// Close 丢弃conn, 重复调用返回第一次的结果
+func (c *netConn) Close() error {
+	c.once.Do(func() { c.err = c.src.Discard(c.Conn) })
+	return c.err
+}
+
+// conn 包装driver.Conn, Close时关闭driver.Conn并丢弃底层net.Conn, 或恢复后放回pool, 并透传常用的可选接口
+type conn struct {
+	driver.Conn
+	raw   *netConn
+	src   Source
+	reset ResetFunc
+	bad   bool
+}
+
+// Close 设置了ResetFunc且连接可用、恢复成功时把net.Conn放回pool, 否则关闭driver.Conn并丢弃net.Conn
+func (c *conn) Close() error {
+	if c.reset != nil && !c.bad && c.IsValid() && c.reset(c.Conn, c.raw) == nil {
+		return c.src.Put(c.raw.Conn)
+	}
+	err := c.Conn.Close()
+	if derr := c.raw.Close(); err == nil {
+		err = derr
+	}
+	return err
+}
+
+// IsValid 实现 driver.Validator
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// ResetSession 实现 driver.SessionResetter
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return c.check(r.ResetSession(ctx))
+	}
+	return nil
+}
+
+// Ping 实现 driver.Pinger
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return c.check(p.Ping(ctx))
+	}
+	return nil
+}
+
+// PrepareContext 实现 driver.ConnPrepareContext
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err := p.PrepareContext(ctx, query)
+		return stmt, c.check(err)
+	}
+	stmt, err := c.Conn.Prepare(query)
+	return stmt, c.check(err)
+}
+
+// BeginTx 实现 driver.ConnBeginTx
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err := b.BeginTx(ctx, opts)
+		return tx, c.check(err)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errNotSupported
+	}
+	// driver没有实现ConnBeginTx时只能使用Begin
+	tx, err := c.Conn.Begin()
+	return tx, c.check(err)
+}
+
+// ExecContext 实现 driver.ExecerContext, driver没有实现时返回driver.ErrSkip
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		res, err := e.ExecContext(ctx, query, args)
+		return res, c.check(err)
+	}
+	return nil, driver.ErrSkip
+}
+
+// QueryContext 实现 driver.QueryerContext, driver没有实现时返回driver.ErrSkip
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		rows, err := q.QueryContext(ctx, query, args)
+		return rows, c.check(err)
+	}
+	return nil, driver.ErrSkip
+}
+
+// check 记录driver.ErrBadConn, 这样的conn在Close时被丢弃
+func (c *conn) check(err error) error {
+	if errors.Is(err, driver.ErrBadConn) {
+		c.bad = true
+	}
+	return err
+}
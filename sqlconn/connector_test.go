@@ -0,0 +1,155 @@
+package sqlconn
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"testing"
+
+	pool "ConnPool"
+)
+
+// lineConn 把Exec的query按行写入net.Conn的driver.Conn
+type lineConn struct {
+	conn   net.Conn
+	closed *int
+}
+
+func (c *lineConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *lineConn) Close() error {
+	*c.closed++
+	return c.conn.Close()
+}
+func (c *lineConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+func (c *lineConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if query == "bad" {
+		return nil, driver.ErrBadConn
+	}
+	if _, err := c.conn.Write([]byte(query + "\n")); err != nil {
+		return nil, err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func newPool(t *testing.T) (*net.TCPListener, func() (net.Conn, error)) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 256)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						conn.Close()
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return l, func() (net.Conn, error) { return net.Dial("tcp", l.Addr().String()) }
+}
+
+func TestConnector(t *testing.T) {
+	l, factory := newPool(t)
+	defer l.Close()
+
+	p, err := pool.NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	opened, closed := 0, 0
+	db := sql.OpenDB(NewConnector(p, nil, func(ctx context.Context, conn net.Conn) (driver.Conn, error) {
+		opened++
+		return &lineConn{conn: conn, closed: &closed}, nil
+	}))
+	defer db.Close()
+	// database/sql 不保留空闲连接, 用完立即Close
+	db.SetMaxIdleConns(0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := db.Exec("hello"); err != nil {
+			t.Fatalf("Exec error: %s", err)
+		}
+	}
+	if opened != 3 || closed != 3 {
+		t.Errorf("Connect error. Expecting %d opened and closed, got %d %d", 3, opened, closed)
+	}
+	// 未设置WithReset时关闭driver.Conn并丢弃底层conn, 不在未知状态下复用
+	if s := p.Stats(); s.Open != 0 || s.Idle != 0 || s.Closed != 3 {
+		t.Errorf("Close error. Expecting open=0 idle=0 closed=3, got open=%d idle=%d closed=%d", s.Open, s.Idle, s.Closed)
+	}
+}
+
+func TestConnector_Reset(t *testing.T) {
+	l, factory := newPool(t)
+	defer l.Close()
+
+	p, err := pool.NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	opened, closed, resets := 0, 0, 0
+	failReset := false
+	reset := func(dc driver.Conn, raw net.Conn) error {
+		resets++
+		if failReset {
+			return errors.New("reset failed")
+		}
+		_, err := raw.Write([]byte("reset\n"))
+		return err
+	}
+	db := sql.OpenDB(NewConnector(p, nil, func(ctx context.Context, conn net.Conn) (driver.Conn, error) {
+		opened++
+		return &lineConn{conn: conn, closed: &closed}, nil
+	}, WithReset(reset)))
+	defer db.Close()
+	db.SetMaxIdleConns(0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := db.Exec("hello"); err != nil {
+			t.Fatalf("Exec error: %s", err)
+		}
+	}
+	if opened != 3 || resets != 3 || closed != 0 {
+		t.Errorf("Connect error. Expecting 3 opened 3 resets 0 closed, got %d %d %d", opened, resets, closed)
+	}
+	// 恢复后的底层conn被放回pool复用
+	if p.OpenNum() != 1 || p.Len() != 1 {
+		t.Errorf("Close error. Expecting open=1 idle=1, got open=%d idle=%d", p.OpenNum(), p.Len())
+	}
+
+	// 恢复失败时关闭driver.Conn并丢弃
+	failReset = true
+	if _, err := db.Exec("hello"); err != nil {
+		t.Fatalf("Exec error: %s", err)
+	}
+	if closed != 1 || p.OpenNum() != 0 {
+		t.Errorf("Close error. Expecting 1 closed 0 open, got %d %d", closed, p.OpenNum())
+	}
+
+	// driver.ErrBadConn 的conn不恢复而是丢弃, database/sql 重试时新建
+	failReset = false
+	resets = 0
+	if _, err := db.Exec("bad"); !errors.Is(err, driver.ErrBadConn) {
+		t.Errorf("Exec error. Expecting %v, got %v", driver.ErrBadConn, err)
+	}
+	if resets != 0 || p.OpenNum() != 0 {
+		t.Errorf("Close error. Expecting bad conns discarded without reset, got %d resets %d open", resets, p.OpenNum())
+	}
+}
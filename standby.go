@@ -0,0 +1,173 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNoStandby = errors.New("no standby endpoint")
+)
+
+// 检查备用conn对端是否存活的间隔
+const standbyCheckInterval = 5 * time.Second
+
+// standbyKeeper 在后台保持standbySize个连接备用后端的conn, 新建失败时按fillBackoffMin到fillBackoffMax退避,
+// 定期检查备用conn, 失效的关闭后补建; Failover后或pool关闭时关闭剩余的备用conn并退出
+func (p *ChannelPool) standbyKeeper() {
+	defer p.closeStandby()
+	backoff := fillBackoffMin
+	for {
+		p.mu.RLock()
+		factory := p.standbyFactory
+		stop := p.closed || factory == nil
+		need := len(p.standby) < p.standbySize
+		p.mu.RUnlock()
+		if stop {
+			return
+		}
+
+		wait := standbyCheckInterval
+		if need {
+			if err := p.dialStandby(factory); err == nil {
+				backoff = fillBackoffMin
+				continue
+			}
+			wait = backoff
+			if backoff *= 2; backoff > fillBackoffMax {
+				backoff = fillBackoffMax
+			}
+		} else if p.checkStandby() > 0 {
+			continue
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-p.done:
+			timer.Stop()
+			return
+		case <-p.standbyWake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// dialStandby 新建一个备用conn, 与其他conn一样进行TLS握手、OnCreate和认证, 但不登记在pool中
+func (p *ChannelPool) dialStandby(factory FactoryContext) error {
+	ctx, cancel := p.factoryContext(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	raw, err := factory(ctx)
+	if err != nil {
+		return err
+	}
+	if err := checkFactoryConn(raw); err != nil {
+		return err
+	}
+	dialDuration := time.Since(start)
+	c, err := p.prepare(ctx, raw)
+	if err != nil {
+		return err
+	}
+	c.dialDuration = dialDuration
+
+	p.mu.Lock()
+	if p.closed || p.standbyFactory == nil {
+		// 新建期间pool被关闭或已经切换
+		p.mu.Unlock()
+		_ = p.closeConn(c.conn)
+		return ErrNoStandby
+	}
+	p.standby = append(p.standby, c)
+	p.mu.Unlock()
+	return nil
+}
+
+// checkStandby 检查备用conn对端是否存活, 关闭失效的conn, 返回关闭的数量
+func (p *ChannelPool) checkStandby() int {
+	p.mu.Lock()
+	standby := p.standby
+	p.standby = nil
+	p.mu.Unlock()
+
+	live := standby[:0]
+	var dead []*preparedConn
+	for _, c := range standby {
+		if err := p.livenessProbe()(c.raw); err != nil {
+			dead = append(dead, c)
+			continue
+		}
+		live = append(live, c)
+	}
+
+	p.mu.Lock()
+	if p.closed || p.standbyFactory == nil {
+		// 检查期间pool被关闭或已经切换
+		dead = append(dead, live...)
+	} else {
+		p.standby = append(p.standby, live...)
+	}
+	p.mu.Unlock()
+
+	for _, c := range dead {
+		_ = p.closeConn(c.conn)
+	}
+	return len(dead)
+}
+
+// closeStandby 关闭所有备用conn
+func (p *ChannelPool) closeStandby() {
+	p.mu.Lock()
+	standby := p.standby
+	p.standby = nil
+	p.mu.Unlock()
+
+	for _, c := range standby {
+		_ = p.closeConn(c.conn)
+	}
+}
+
+// Failover 切换到WithStandby设置的备用后端: 之后新建的conn连接备用后端, 备用conn登记为空闲conn立即可用,
+// 当前的conn同Drain被淘汰(空闲的立即关闭, 取出的放回时关闭); 等待被淘汰的conn全部关闭, ctx结束时返回超时错误.
+// 未设置WithStandby或已经切换过时返回ErrNoStandby
+func (p *ChannelPool) Failover(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrClosed
+	}
+	if p.standbyFactory == nil {
+		p.mu.Unlock()
+		return ErrNoStandby
+	}
+	p.factory = p.standbyFactory
+	p.standbyFactory = nil
+	conns, gen := p.retireAll()
+	for _, c := range p.standby {
+		// 同adopt, 空闲conn和取出的conn不能超过maxConn
+		if int64(p.idle.Len()) >= p.maxIdle ||
+			(!p.unlimited && p.sem != nil && p.sem.Held()+int64(p.idle.Len()) >= p.maxConn) {
+			conns = append(conns, c.conn)
+			continue
+		}
+		p.registerPrepared(c)
+		p.idle.Push(c.raw)
+		p.markIdle(c.raw)
+	}
+	p.standby = nil
+	p.failoverNum++
+	p.mu.Unlock()
+
+	// 通知standbyKeeper退出
+	select {
+	case p.standbyWake <- struct{}{}:
+	default:
+	}
+	for _, c := range conns {
+		_ = p.closeConn(c)
+	}
+	return p.waitRetired(ctx, gen)
+}
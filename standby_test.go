@@ -0,0 +1,189 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// standbyServer 备用后端, 接收连接并保持到测试结束
+type standbyServer struct {
+	l     net.Listener
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func newStandbyServer(t *testing.T) *standbyServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &standbyServer{l: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+		}
+	}()
+	t.Cleanup(func() {
+		l.Close()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, c := range s.conns {
+			c.Close()
+		}
+	})
+	return s
+}
+
+// accepted 已接收的连接数, 等待已建立的连接被Accept
+func (s *standbyServer) accepted() int {
+	time.Sleep(time.Millisecond * 20)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+func (s *standbyServer) factory(ctx context.Context) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", s.l.Addr().String())
+}
+
+// waitStandby 等待备用conn数达到n
+func waitStandby(t *testing.T, p *ChannelPool, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second * 2)
+	for p.Stats().Standby != n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 5)
+	}
+	if got := p.Stats().Standby; got != n {
+		t.Fatalf("Standby error. Expecting %d, got %d", n, got)
+	}
+}
+
+func TestChannelPool_Failover(t *testing.T) {
+	secondary := newStandbyServer(t)
+	p, err := NewChannelPool(2, 4, factory, WithStandby(secondary.factory, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	waitStandby(t, p, 2)
+	if p.OpenNum() != 2 {
+		t.Errorf("OpenNum error. Expecting standby conns not counted, got %d", p.OpenNum())
+	}
+
+	// 取出的conn在放回时淘汰
+	held, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		_ = p.Put(held)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.Failover(ctx); err != nil {
+		t.Fatalf("Failover error: %s", err)
+	}
+	if s := p.Stats(); s.Open != 2 || s.Idle != 2 || s.Standby != 0 || s.Failovers != 1 {
+		t.Errorf("Failover error. Expecting 2 open 2 idle, got %d %d standby %d failovers %d",
+			s.Open, s.Idle, s.Standby, s.Failovers)
+	}
+
+	// 切换后直接使用备用conn, 不需要新建
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if b, _ := p.Backend(conn); b != secondary.l.Addr().String() {
+		t.Errorf("Failover error. Expecting backend %s, got %s", secondary.l.Addr(), b)
+	}
+	if secondary.accepted() != 2 {
+		t.Errorf("Failover error. Expecting %d dials, got %d", 2, secondary.accepted())
+	}
+	_ = p.Put(conn)
+
+	// 之后新建的conn也连接备用后端
+	conns := make([]net.Conn, 3)
+	for i := range conns {
+		if conns[i], err = p.Get(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if secondary.accepted() != 3 {
+		t.Errorf("Failover error. Expecting %d dials, got %d", 3, secondary.accepted())
+	}
+	for _, c := range conns {
+		_ = p.Put(c)
+	}
+
+	if err := p.Failover(ctx); !errors.Is(err, ErrNoStandby) {
+		t.Errorf("Failover error. Expecting %v, got %v", ErrNoStandby, err)
+	}
+}
+
+func TestChannelPool_StandbyCheck(t *testing.T) {
+	secondary := newStandbyServer(t)
+	p, err := NewChannelPool(1, 2, factory, WithStandby(secondary.factory, 2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	waitStandby(t, p, 2)
+
+	// 备用后端关闭的conn被关闭后补建
+	secondary.mu.Lock()
+	secondary.conns[0].Close()
+	secondary.mu.Unlock()
+	deadline := time.Now().Add(time.Second)
+	closed := 0
+	for closed == 0 && time.Now().Before(deadline) {
+		closed = p.checkStandby()
+		time.Sleep(time.Millisecond * 5)
+	}
+	if closed != 1 {
+		t.Errorf("checkStandby error. Expecting %d, got %d", 1, closed)
+	}
+	p.standbyWake <- struct{}{}
+	waitStandby(t, p, 2)
+	if secondary.accepted() != 3 {
+		t.Errorf("standby error. Expecting %d dials, got %d", 3, secondary.accepted())
+	}
+
+	// 关闭pool时关闭备用conn
+	_ = p.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := p.WaitStopped(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Stats(); s.Standby != 0 {
+		t.Errorf("Close error. Expecting %d, got %d", 0, s.Standby)
+	}
+}
+
+func TestChannelPool_NoStandby(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.Failover(context.Background()); !errors.Is(err, ErrNoStandby) {
+		t.Errorf("Failover error. Expecting %v, got %v", ErrNoStandby, err)
+	}
+
+	if _, err := NewChannelPool(1, 2, factory, WithStandby(nil, 1)); err == nil {
+		t.Error("NewChannelPool error. Expecting invalid standby rejected")
+	}
+}
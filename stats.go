@@ -0,0 +1,267 @@
+package pool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats pool的统计信息, 累计值从pool创建开始计算
+type Stats struct {
+	Time time.Time // 采样时间
+
+	MaxFree  int
+	MaxConn  int // 不限制时为0
+	MaxIdle  int // Put时保留的空闲conn上限, 见WithMaxIdle
+	WarmIdle int // 保持的空闲conn数, 见WithWarmIdle
+
+	Open       int // 已创建未关闭的conn数
+	Idle       int // 空闲conn数
+	InUse      int // 已被取出的conn数, 包含Validating
+	Validating int // 已放回、正在后台执行WithHealthCheckOnPut检查的conn数
+	Waiters    int // 正在等待的调用数
+	Pinned     int // 通过GetPinned取出未放回的conn数, 包含在InUse中
+
+	Degraded bool // 是否处于降级状态, 见WithDegradeThreshold
+
+	Created int64 // 累计新建conn数
+	Closed  int64 // 累计关闭conn数
+	Adopted int64 // 从其他pool转入的conn数, 包含在Created中
+	Donated int64 // 转给其他pool的conn数, 包含在Closed中
+
+	Overflows int64 // Put时空闲已满而关闭的conn数, 包含在Closed中
+	Anomalies int64 // 统计异常的次数, 仅开启WithStrictAccounting时记录
+
+	PutHandoffs int64 // Put时有等待者而直接交给等待者的conn数, 不经过空闲列表
+
+	Acquires      int64 // 调用Get、GetWitchContext等获取conn的次数
+	AcquireErrors int64 // 获取conn返回错误的次数, 包括等待超时和新建失败
+
+	Hits       int64 // 取到已有conn的次数
+	Misses     int64 // 需要新建conn的次数
+	PinnedGets int64 // GetPinned的次数, 不计入Hits和Misses
+
+	Waits        int64         // 需要等待conn放回的次数
+	WaitDuration time.Duration // 累计等待时间
+	Timeouts     int64         // 等待超时次数
+	WaitBuckets  []WaitBucket  // 等待时长分布, 见ComputeSLO
+	Handoffs     int64         // 等待者在conn放回或关闭时直接获得容量的次数
+	Wakeups      int64         // 等待者被唤醒的次数, 包括获得、超时和pool关闭; 远大于Handoffs+Timeouts说明有无效唤醒
+	SpinHits     int64         // 自旋期间获得容量而没有等待的次数, 见WithSpinWait
+	OverReleases int64         // 归还的容量单位超过已获得的次数, 不为0说明pool内部重复归还
+
+	PortExhausted int64 // 因本地端口耗尽导致的新建失败次数
+	ChurnLimited  int64 // 因新建速率超过WithChurnGuard上限被拒绝的新建次数
+	DialThrottled int64 // 新建被pool自身限速(爬坡、WithChurnGuard、WithBudget)推迟或拒绝的次数, 包含ChurnLimited
+	Quarantined   int64 // 后端因健康检查连续失败进入隔离期的次数, 见WithQuarantine
+	DialRetries   int64 // 新建因后端不可用而重试的次数, 见WithDialRetry
+
+	Standby   int   // 已建立的备用conn数, 不计入Open, 见WithStandby
+	Failovers int64 // 切换到备用后端的次数, 见Failover
+
+	Migrating        bool // 是否正在MigrateTo
+	MigrationTotal   int  // 最近一次迁移开始时需要淘汰的旧conn数
+	MigrationPending int  // 尚未关闭的旧conn数, 为0时迁移完成
+
+	Dial      LatencySummary // factory耗时
+	Handshake LatencySummary // OnCreate(如TLS握手)耗时
+
+	TLS []TLSCount // 按TLS参数分组的打开conn数, 按数量从多到少排序
+}
+
+// Stats 返回pool当前的统计信息
+func (p *ChannelPool) Stats() Stats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.stats()
+}
+
+// stats 需持有p.mu
+func (p *ChannelPool) stats() Stats {
+	s := Stats{
+		Time:        time.Now(),
+		MaxFree:     int(p.maxFree),
+		MaxConn:     int(p.maxConn),
+		MaxIdle:     int(p.maxIdle),
+		WarmIdle:    p.warmIdle,
+		Open:        int(p.acct.Open),
+		Idle:        p.idle.Len(),
+		InUse:       p.inUse(),
+		Validating:  p.validatingCount(),
+		Waiters:     p.waiterCount(),
+		Pinned:      p.pinnedNum,
+		Degraded:    p.degraded,
+		Created:     p.acct.Created,
+		Closed:      p.acct.Closed,
+		Adopted:     p.adoptedNum,
+		Donated:     p.donatedNum,
+		Overflows:   p.overflowNum,
+		Anomalies:   p.anomalyNum,
+		PutHandoffs: p.handoffNum,
+
+		Acquires:      atomic.LoadInt64(&p.acquireNum),
+		AcquireErrors: atomic.LoadInt64(&p.acquireErrNum),
+
+		Hits:         p.hits,
+		Misses:       p.misses,
+		PinnedGets:   p.pinnedGets,
+		Waits:        p.waits,
+		WaitDuration: p.waitDuration,
+		Timeouts:     p.timeouts,
+		WaitBuckets:  p.waitBuckets(),
+		SpinHits:     p.spinHits,
+
+		PortExhausted: p.portExhaustedNum,
+		ChurnLimited:  p.churnLimitedNum,
+		DialThrottled: p.dialThrottledNum,
+		Quarantined:   p.quarantinedNum,
+		DialRetries:   p.dialRetryNum,
+
+		Standby:   len(p.standby),
+		Failovers: p.failoverNum,
+
+		Migrating:        p.migrating,
+		MigrationTotal:   p.migrateTotal,
+		MigrationPending: p.migratePending,
+
+		Dial:      p.dialLatency.summary(),
+		Handshake: p.handshakeLatency.summary(),
+
+		TLS: p.tlsCounts(),
+	}
+	if p.sem != nil {
+		s.Handoffs, s.Wakeups = p.sem.Counters()
+		s.OverReleases = p.sem.OverReleases()
+	}
+	if p.pinSem != nil {
+		s.OverReleases += p.pinSem.OverReleases()
+	}
+	return s
+}
+
+// StatsDelta 两次Stats采样之间累计值的变化
+type StatsDelta struct {
+	Interval time.Duration
+
+	Created   int64
+	Closed    int64
+	Overflows int64
+	Anomalies int64
+
+	PutHandoffs int64
+
+	Acquires      int64
+	AcquireErrors int64
+
+	Hits       int64
+	Misses     int64
+	PinnedGets int64
+
+	Waits        int64
+	WaitDuration time.Duration
+	Timeouts     int64
+	WaitBuckets  []WaitBucket
+	Handoffs     int64
+	Wakeups      int64
+	SpinHits     int64
+	OverReleases int64
+
+	PortExhausted int64
+	ChurnLimited  int64
+	DialThrottled int64
+	Quarantined   int64
+	DialRetries   int64
+
+	Failovers int64
+}
+
+// DiffStats 计算从a到b的变化, a应早于b
+func DiffStats(a, b Stats) StatsDelta {
+	return StatsDelta{
+		Interval:    b.Time.Sub(a.Time),
+		Created:     b.Created - a.Created,
+		Closed:      b.Closed - a.Closed,
+		Overflows:   b.Overflows - a.Overflows,
+		Anomalies:   b.Anomalies - a.Anomalies,
+		PutHandoffs: b.PutHandoffs - a.PutHandoffs,
+
+		Acquires:      b.Acquires - a.Acquires,
+		AcquireErrors: b.AcquireErrors - a.AcquireErrors,
+
+		Hits:         b.Hits - a.Hits,
+		Misses:       b.Misses - a.Misses,
+		PinnedGets:   b.PinnedGets - a.PinnedGets,
+		Waits:        b.Waits - a.Waits,
+		WaitDuration: b.WaitDuration - a.WaitDuration,
+		Timeouts:     b.Timeouts - a.Timeouts,
+		WaitBuckets:  diffWaitBuckets(a.WaitBuckets, b.WaitBuckets),
+		Handoffs:     b.Handoffs - a.Handoffs,
+		Wakeups:      b.Wakeups - a.Wakeups,
+		SpinHits:     b.SpinHits - a.SpinHits,
+		OverReleases: b.OverReleases - a.OverReleases,
+
+		PortExhausted: b.PortExhausted - a.PortExhausted,
+		ChurnLimited:  b.ChurnLimited - a.ChurnLimited,
+		DialThrottled: b.DialThrottled - a.DialThrottled,
+		Quarantined:   b.Quarantined - a.Quarantined,
+		DialRetries:   b.DialRetries - a.DialRetries,
+
+		Failovers: b.Failovers - a.Failovers,
+	}
+}
+
+// Gets 期间获取conn的次数
+func (d StatsDelta) Gets() int64 {
+	return d.Hits + d.Misses + d.Timeouts
+}
+
+// DialRate 每秒新建conn数
+func (d StatsDelta) DialRate() float64 {
+	return d.perSecond(d.Created)
+}
+
+// CloseRate 每秒关闭conn数
+func (d StatsDelta) CloseRate() float64 {
+	return d.perSecond(d.Closed)
+}
+
+// GetRate 每秒获取conn次数
+func (d StatsDelta) GetRate() float64 {
+	return d.perSecond(d.Gets())
+}
+
+// ChurnRate 每秒替换的conn数, 即新建和关闭的平均值
+func (d StatsDelta) ChurnRate() float64 {
+	return d.perSecond(d.Created+d.Closed) / 2
+}
+
+// ReuseRate 获取conn时复用已有conn的比例
+func (d StatsDelta) ReuseRate() float64 {
+	return ratio(d.Hits, d.Hits+d.Misses)
+}
+
+// WaitRate 获取conn时需要等待的比例
+func (d StatsDelta) WaitRate() float64 {
+	return ratio(d.Waits, d.Gets())
+}
+
+// AvgWait 平均每次等待的时间
+func (d StatsDelta) AvgWait() time.Duration {
+	if d.Waits == 0 {
+		return 0
+	}
+	return d.WaitDuration / time.Duration(d.Waits)
+}
+
+func (d StatsDelta) perSecond(n int64) float64 {
+	if d.Interval <= 0 {
+		return 0
+	}
+	return float64(n) / d.Interval.Seconds()
+}
+
+func ratio(n, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
@@ -0,0 +1,104 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_Stats(t *testing.T) {
+	p, _ := NewChannelPool(1, 2, factory)
+	defer p.Close()
+
+	c1, _ := p.Get() // hit
+	c2, _ := p.Get() // miss
+
+	if s := p.Stats(); s.InUse != 2 || s.Idle != 0 || s.Open != 2 {
+		t.Errorf("Stats error. Expecting in use=2 idle=0 open=2, got %+v", s)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	if _, err := p.GetWitchContext(ctx); !errors.Is(err, ErrTimeOut) {
+		t.Errorf("Get error. Expecting %v, got %v", ErrTimeOut, err)
+	}
+
+	_ = p.Put(c1)
+	_ = p.Put(c2) // 空闲已满, 关闭
+
+	s := p.Stats()
+	if s.Open != 1 || s.Idle != 1 || s.InUse != 0 || s.Waiters != 0 {
+		t.Errorf("Stats error. Expecting open=1 idle=1 in use=0 waiters=0, got %+v", s)
+	}
+	if s.Created != 2 || s.Closed != 1 {
+		t.Errorf("Stats error. Expecting created=2 closed=1, got %+v", s)
+	}
+	if s.Hits != 1 || s.Misses != 1 || s.Waits != 1 || s.Timeouts != 1 {
+		t.Errorf("Stats error. Expecting hits=1 misses=1 waits=1 timeouts=1, got %+v", s)
+	}
+	if s.WaitDuration < time.Millisecond*50 {
+		t.Errorf("Stats error. Expecting wait >= 50ms, got %s", s.WaitDuration)
+	}
+}
+
+func TestDiffStats(t *testing.T) {
+	now := time.Now()
+	a := Stats{Time: now, Created: 10, Closed: 5, Hits: 100, Misses: 10, Waits: 5, WaitDuration: time.Second}
+	b := Stats{Time: now.Add(time.Second * 10), Created: 30, Closed: 25, Hits: 160, Misses: 30, Waits: 15, WaitDuration: time.Second * 3, Timeouts: 10}
+
+	d := DiffStats(a, b)
+	if d.Interval != time.Second*10 {
+		t.Errorf("DiffStats error. Expecting %s, got %s", time.Second*10, d.Interval)
+	}
+	if r := d.DialRate(); r != 2 {
+		t.Errorf("DialRate error. Expecting %v, got %v", 2, r)
+	}
+	if r := d.ChurnRate(); r != 2 {
+		t.Errorf("ChurnRate error. Expecting %v, got %v", 2, r)
+	}
+	if r := d.ReuseRate(); r != 0.75 {
+		t.Errorf("ReuseRate error. Expecting %v, got %v", 0.75, r)
+	}
+	if r := d.GetRate(); r != 9 {
+		t.Errorf("GetRate error. Expecting %v, got %v", 9, r)
+	}
+	if w := d.AvgWait(); w != time.Millisecond*200 {
+		t.Errorf("AvgWait error. Expecting %s, got %s", time.Millisecond*200, w)
+	}
+
+	// 空区间不除零
+	var zero StatsDelta
+	if zero.DialRate() != 0 || zero.ReuseRate() != 0 || zero.AvgWait() != 0 {
+		t.Errorf("StatsDelta error. Expecting zero rates, got %+v", zero)
+	}
+}
+
+func TestChannelPool_StatsHandoffs(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if conn, err := p.Get(); err == nil {
+			p.Put(conn)
+		}
+	}()
+	for p.Stats().Waiters != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	p.Put(conn)
+	<-done
+
+	if s := p.Stats(); s.Handoffs != 1 || s.Wakeups != 1 || s.OverReleases != 0 {
+		t.Errorf("Stats error. Expecting %d handoffs %d wakeups %d over releases, got %d %d %d", 1, 1, 0, s.Handoffs, s.Wakeups, s.OverReleases)
+	}
+}
@@ -0,0 +1,45 @@
+package pool
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrAccounting = errors.New("pool accounting anomaly")
+)
+
+// 统计异常的种类, 见AccountingError
+const (
+	AnomalyUnknownConn  = "unknown_conn"  // 放回的conn不是pool创建的, 或已被pool关闭
+	AnomalyDoublePut    = "double_put"    // 放回已经空闲的conn
+	AnomalyNegativeOpen = "negative_open" // 关闭conn后打开的conn数小于0
+)
+
+// AccountingError 开启WithStrictAccounting时Put发现的统计异常, 通常说明使用方重复放回或放回了其他pool的conn;
+// errors.Is 匹配 ErrAccounting
+type AccountingError struct {
+	Anomaly string // AnomalyUnknownConn 等
+
+	ConnID uint64 // 异常的conn, 不是pool创建的conn时为0
+}
+
+func (e *AccountingError) Error() string {
+	if e.ConnID == 0 {
+		return fmt.Sprintf("%s: %s", ErrAccounting, e.Anomaly)
+	}
+	return fmt.Sprintf("%s: %s (conn #%d)", ErrAccounting, e.Anomaly, e.ConnID)
+}
+
+func (e *AccountingError) Is(target error) bool {
+	return target == ErrAccounting
+}
+
+// anomaly 记录一次统计异常, 未开启WithStrictAccounting时返回nil, 需持有p.mu
+func (p *ChannelPool) anomaly(kind string, id uint64) error {
+	if !p.strictAccounting {
+		return nil
+	}
+	p.anomalyNum++
+	return &AccountingError{Anomaly: kind, ConnID: id}
+}
@@ -0,0 +1,74 @@
+package pool
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_StrictAccounting(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0), WithStrictAccounting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	id, _ := p.ConnID(conn)
+	if err := p.Put(conn); err != nil {
+		t.Errorf("Put error: %s", err)
+	}
+
+	// 重复放回
+	err = p.Put(conn)
+	var ae *AccountingError
+	if !errors.As(err, &ae) || ae.Anomaly != AnomalyDoublePut || ae.ConnID != id {
+		t.Errorf("Put error. Expecting %s on conn #%d, got %v", AnomalyDoublePut, id, err)
+	}
+
+	// 不是pool创建的conn
+	other, err := net.Dial(network, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Put(other); !errors.Is(err, ErrAccounting) {
+		t.Errorf("Put error. Expecting %v, got %v", ErrAccounting, err)
+	}
+	// unknown conn在后台关闭
+	closed := false
+	for deadline := time.Now().Add(time.Second); !closed && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		_, err := other.Write([]byte("x"))
+		closed = err != nil
+	}
+	if !closed {
+		t.Errorf("Put error. Expecting unknown conn closed")
+	}
+
+	if s := p.Stats(); s.Anomalies != 2 || s.Open != 1 || s.Idle != 1 {
+		t.Errorf("Stats error. Expecting 2 anomalies 1 open 1 idle, got %d %d %d", s.Anomalies, s.Open, s.Idle)
+	}
+}
+
+func TestChannelPool_LenientAccounting(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if err := p.Put(conn); err != nil {
+		t.Errorf("Put error. Expecting double Put ignored, got %v", err)
+	}
+	if s := p.Stats(); s.Anomalies != 0 {
+		t.Errorf("Stats error. Expecting %d, got %d", 0, s.Anomalies)
+	}
+}
@@ -0,0 +1,48 @@
+package pool
+
+import (
+	"net"
+	"syscall"
+	"testing"
+)
+
+// fdCloexec 描述符是否设置了CLOEXEC
+func fdCloexec(t *testing.T, fd int) bool {
+	t.Helper()
+	flags, _, errno := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), syscall.F_GETFD, 0)
+	if errno != 0 {
+		t.Fatal(errno)
+	}
+	return flags&syscall.FD_CLOEXEC != 0
+}
+
+func TestChannelPool_AdoptFdsKeepsCloexec(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := dupFd(t, f)
+	defer syscall.Close(fd)
+	if fdCloexec(t, fd) {
+		t.Fatal("dup error. Expecting CLOEXEC cleared")
+	}
+
+	// 未加入的描述符不设置CLOEXEC, 仍可传给子进程
+	if n := p.adoptFds([]listenFd{{fd: fd}}, nil); n != 0 {
+		t.Errorf("adoptFds error. Expecting %d, got %d", 0, n)
+	}
+	if fdCloexec(t, fd) {
+		t.Error("adoptFds error. Expecting CLOEXEC unchanged on listener")
+	}
+}
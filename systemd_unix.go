@@ -0,0 +1,128 @@
+//go:build unix
+
+package pool
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// systemd传递的第一个文件描述符, 即SD_LISTEN_FDS_START
+const listenFdsStart = 3
+
+// AdoptSystemdConns 把systemd socket activation通过LISTEN_FDS传入的已连接stream socket作为空闲conn加入pool,
+// 按正常的统计计入Created和Adopted, 返回加入的conn数; names非空时只加入LISTEN_FDNAMES中名字在names中的描述符.
+// 监听socket、非socket、不是stream的socket和名字不匹配的描述符保持打开且不做修改, 由调用方处理;
+// 加入的描述符被接管, 其中超过maxFree或maxConn的被关闭.
+// LISTEN_PID不是当前进程时不做处理; unsetEnv为true时清除LISTEN_PID、LISTEN_FDS和LISTEN_FDNAMES, 避免传给子进程.
+// 设置了WithWrapConn或WithTLSClient时返回ErrNotPassable
+func (p *ChannelPool) AdoptSystemdConns(unsetEnv bool, names ...string) (int, error) {
+	if !p.fdPassable() {
+		return 0, ErrNotPassable
+	}
+	fds, err := listenFds(unsetEnv)
+	if err != nil {
+		return 0, err
+	}
+	return p.adoptFds(fds, names), nil
+}
+
+// listenFd systemd传入的文件描述符
+type listenFd struct {
+	fd int
+
+	name string // LISTEN_FDNAMES中对应的名字, 未设置时为空
+}
+
+// listenFds 按LISTEN_PID、LISTEN_FDS和LISTEN_FDNAMES返回systemd传入的文件描述符, 不修改描述符
+func listenFds(unsetEnv bool) ([]listenFd, error) {
+	if unsetEnv {
+		defer func() {
+			_ = os.Unsetenv("LISTEN_PID")
+			_ = os.Unsetenv("LISTEN_FDS")
+			_ = os.Unsetenv("LISTEN_FDNAMES")
+		}()
+	}
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+	}
+	var names []string
+	if v := os.Getenv("LISTEN_FDNAMES"); v != "" {
+		names = strings.Split(v, ":")
+	}
+	fds := make([]listenFd, 0, n)
+	for i := 0; i < n; i++ {
+		fd := listenFd{fd: listenFdsStart + i}
+		// 名字数量与描述符数量不一致时忽略LISTEN_FDNAMES
+		if len(names) == n {
+			fd.name = names[i]
+		}
+		fds = append(fds, fd)
+	}
+	return fds, nil
+}
+
+// nameSelected names为空或包含name时返回true
+func nameSelected(names []string, name string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// connectedStream 判断fd是否为已连接的stream socket, 只查询不修改描述符
+func connectedStream(fd int) bool {
+	typ, err := syscall.GetsockoptInt(fd, syscall.SOL_SOCKET, syscall.SO_TYPE)
+	if err != nil || typ != syscall.SOCK_STREAM {
+		return false
+	}
+	// 监听socket和未连接的socket没有对端
+	_, err = syscall.Getpeername(fd)
+	return err == nil
+}
+
+// adoptFds 把名字匹配的已连接stream socket作为空闲conn加入pool, 返回加入的conn数;
+// 加入的描述符被接管并关闭, 其余描述符不做处理
+func (p *ChannelPool) adoptFds(fds []listenFd, names []string) int {
+	adopted := 0
+	now := time.Now()
+	for _, lf := range fds {
+		if !nameSelected(names, lf.name) {
+			continue
+		}
+		if !connectedStream(lf.fd) {
+			continue
+		}
+		syscall.CloseOnExec(lf.fd)
+		f := os.NewFile(uintptr(lf.fd), "LISTEN_FD_"+strconv.Itoa(lf.fd))
+		conn, err := net.FileConn(f)
+		_ = f.Close()
+		if err != nil {
+			continue
+		}
+		if checkFactoryConn(conn) != nil {
+			continue
+		}
+		if !p.adopt(conn, connMeta{conn: conn, createdAt: now, expiryScale: p.expiryScale()}) {
+			_ = conn.Close()
+			continue
+		}
+		adopted++
+	}
+	return adopted
+}
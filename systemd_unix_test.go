@@ -0,0 +1,150 @@
+//go:build unix
+
+package pool
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestListenFds(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "2")
+	if fds, err := listenFds(false); err != nil || fds != nil {
+		t.Errorf("listenFds error. Expecting nothing for other pid, got %v %v", fds, err)
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "2")
+	t.Setenv("LISTEN_FDNAMES", "db:api")
+	fds, err := listenFds(false)
+	if err != nil || len(fds) != 2 {
+		t.Fatalf("listenFds error. Expecting 2 fds, got %v %v", fds, err)
+	}
+	if fds[0] != (listenFd{fd: 3, name: "db"}) || fds[1] != (listenFd{fd: 4, name: "api"}) {
+		t.Errorf("listenFds error. Expecting fds 3 db and 4 api, got %v", fds)
+	}
+
+	// 名字数量不一致时忽略LISTEN_FDNAMES
+	t.Setenv("LISTEN_FDNAMES", "db")
+	if fds, _ := listenFds(false); len(fds) != 2 || fds[0].name != "" {
+		t.Errorf("listenFds error. Expecting names ignored, got %v", fds)
+	}
+
+	t.Setenv("LISTEN_FDS", "0")
+	t.Setenv("LISTEN_FDNAMES", "")
+	if fds, err := listenFds(true); err != nil || len(fds) != 0 {
+		t.Errorf("listenFds error. Expecting no fds, got %v %v", fds, err)
+	}
+	if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+		t.Errorf("listenFds error. Expecting LISTEN_FDS unset")
+	}
+
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "x")
+	if _, err := listenFds(false); err == nil {
+		t.Errorf("listenFds error. Expecting invalid LISTEN_FDS")
+	}
+}
+
+// dupFd 复制f的描述符并关闭f, 返回的描述符没有设置CLOEXEC
+func dupFd(t *testing.T, f *os.File) int {
+	t.Helper()
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd
+}
+
+// fdOpen 描述符是否仍然打开
+func fdOpen(fd int) bool {
+	var st syscall.Stat_t
+	return syscall.Fstat(fd, &st) == nil
+}
+
+func TestChannelPool_AdoptFds(t *testing.T) {
+	p, err := NewChannelPool(3, 5, factory, WithInitialConns(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	dialFd := func() int {
+		conn, err := net.Dial(network, address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		f, err := conn.(*net.TCPConn).File()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dupFd(t, f)
+	}
+	connFd, otherFd := dialFd(), dialFd()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listenFile, err := l.(*net.TCPListener).File()
+	l.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listenerFd := dupFd(t, listenFile)
+	defer syscall.Close(listenerFd)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	pipeFd := dupFd(t, r)
+	defer syscall.Close(pipeFd)
+
+	fds := []listenFd{
+		{fd: listenerFd, name: "db"},
+		{fd: connFd, name: "db"},
+		{fd: pipeFd, name: "db"},
+		{fd: otherFd, name: "api"},
+	}
+	if n := p.adoptFds(fds, []string{"db"}); n != 1 {
+		t.Fatalf("adoptFds error. Expecting %d, got %d", 1, n)
+	}
+	if s := p.Stats(); s.Idle != 1 || s.Open != 1 || s.Created != 1 || s.Adopted != 1 {
+		t.Errorf("Stats error. Expecting 1 idle open created adopted, got %+v", s)
+	}
+	if fdOpen(connFd) {
+		t.Errorf("adoptFds error. Expecting adopted fd %d taken over", connFd)
+	}
+
+	// 监听socket、非socket和名字不匹配的描述符保持打开
+	for _, fd := range []int{listenerFd, pipeFd, otherFd} {
+		if !fdOpen(fd) {
+			t.Errorf("adoptFds error. Expecting fd %d left open", fd)
+		}
+	}
+	if typ, err := syscall.GetsockoptInt(listenerFd, syscall.SOL_SOCKET, syscall.SO_TYPE); err != nil || typ != syscall.SOCK_STREAM {
+		t.Errorf("adoptFds error. Expecting listener untouched, got %d %v", typ, err)
+	}
+
+	// 不指定名字时加入剩余的已连接socket
+	if n := p.adoptFds(fds[3:], nil); n != 1 {
+		t.Errorf("adoptFds error. Expecting %d, got %d", 1, n)
+	}
+
+	c, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if b, _ := p.Backend(c); b != address {
+		t.Errorf("Backend error. Expecting %s, got %s", address, b)
+	}
+	p.Put(c)
+}
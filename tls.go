@@ -0,0 +1,126 @@
+package pool
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+)
+
+// TLSInfo TLS conn协商的参数和对端证书
+type TLSInfo struct {
+	Version     uint16 // tls.VersionTLS12 等
+	CipherSuite uint16
+	ServerName  string
+
+	NegotiatedProtocol string // ALPN协商的协议, 如"h2", 未协商时为空
+
+	PeerSubject     string    // 对端证书的Subject
+	PeerFingerprint string    // 对端证书的SHA-256指纹, 十六进制
+	PeerNotAfter    time.Time // 对端证书过期时间
+}
+
+func (i TLSInfo) String() string {
+	s := fmt.Sprintf("%s %s %q sha256:%s", tlsVersionName(i.Version), tls.CipherSuiteName(i.CipherSuite),
+		i.PeerSubject, i.PeerFingerprint)
+	if i.NegotiatedProtocol != "" {
+		s += " " + i.NegotiatedProtocol
+	}
+	return s
+}
+
+func tlsVersionName(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS1.0"
+	case tls.VersionTLS11:
+		return "TLS1.1"
+	case tls.VersionTLS12:
+		return "TLS1.2"
+	case tls.VersionTLS13:
+		return "TLS1.3"
+	default:
+		return fmt.Sprintf("0x%04X", v)
+	}
+}
+
+// TLSCount 使用相同TLS参数的打开conn数
+type TLSCount struct {
+	TLSInfo
+
+	Count int
+}
+
+type connectionStater interface {
+	ConnectionState() tls.ConnectionState
+}
+
+// tlsInfoOf 依次在conns中查找已完成握手的TLS conn, 都不是或未握手时返回nil
+func tlsInfoOf(conns ...net.Conn) *TLSInfo {
+	for _, c := range conns {
+		cs, ok := c.(connectionStater)
+		if !ok {
+			continue
+		}
+		state := cs.ConnectionState()
+		if !state.HandshakeComplete {
+			return nil
+		}
+		info := &TLSInfo{
+			Version:     state.Version,
+			CipherSuite: state.CipherSuite,
+			ServerName:  state.ServerName,
+
+			NegotiatedProtocol: state.NegotiatedProtocol,
+		}
+		if len(state.PeerCertificates) > 0 {
+			cert := state.PeerCertificates[0]
+			sum := sha256.Sum256(cert.Raw)
+			info.PeerSubject = cert.Subject.String()
+			info.PeerFingerprint = hex.EncodeToString(sum[:])
+			info.PeerNotAfter = cert.NotAfter
+		}
+		return info
+	}
+	return nil
+}
+
+// TLS 返回conn协商的TLS参数, 不是TLS conn或尚未完成握手时返回false;
+// 握手在取出后才完成的conn在放回时记录
+func (c *PoolConn) TLS() (TLSInfo, bool) {
+	c.p.mu.RLock()
+	defer c.p.mu.RUnlock()
+
+	m, ok := c.p.conns[c.raw]
+	if !ok || m.tls == nil {
+		return TLSInfo{}, false
+	}
+	return *m.tls, true
+}
+
+// tlsCounts 按TLS参数统计打开的conn数, 需持有p.mu
+func (p *ChannelPool) tlsCounts() []TLSCount {
+	counts := make(map[TLSInfo]int)
+	for _, m := range p.conns {
+		if m.tls != nil {
+			counts[*m.tls]++
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+	list := make([]TLSCount, 0, len(counts))
+	for info, n := range counts {
+		list = append(list, TLSCount{TLSInfo: info, Count: n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].String() < list[j].String()
+	})
+	return list
+}
@@ -0,0 +1,167 @@
+package pool
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTLSBackend(t *testing.T) (*httptest.Server, *tls.Config) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	return srv, &tls.Config{RootCAs: roots, ServerName: "example.com"}
+}
+
+func TestPoolConn_TLS(t *testing.T) {
+	srv, config := newTLSBackend(t)
+	defer srv.Close()
+
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		return tls.Dial("tcp", srv.Listener.Addr().String(), config)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	info, ok := conn.(*PoolConn).TLS()
+	if !ok {
+		t.Fatalf("TLS error. Expecting TLS info")
+	}
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	if info.PeerFingerprint != hex.EncodeToString(sum[:]) {
+		t.Errorf("TLS error. Expecting %x, got %s", sum, info.PeerFingerprint)
+	}
+	if info.Version != tls.VersionTLS13 || info.ServerName != "example.com" {
+		t.Errorf("TLS error. Expecting TLS1.3 example.com, got %s %s", tlsVersionName(info.Version), info.ServerName)
+	}
+	if !info.PeerNotAfter.Equal(srv.Certificate().NotAfter) {
+		t.Errorf("TLS error. Expecting %s, got %s", srv.Certificate().NotAfter, info.PeerNotAfter)
+	}
+
+	stats := p.Stats()
+	if len(stats.TLS) != 1 || stats.TLS[0].Count != 1 || stats.TLS[0].TLSInfo != info {
+		t.Errorf("Stats error. Expecting 1 conn with %s, got %v", info, stats.TLS)
+	}
+	if s := p.Snapshot(); len(s.Conns) != 1 || s.Conns[0].TLS == nil || *s.Conns[0].TLS != info {
+		t.Errorf("Snapshot error. Expecting %s, got %+v", info, s.Conns)
+	}
+	p.Put(conn)
+}
+
+func TestPoolConn_TLSLazyHandshake(t *testing.T) {
+	srv, config := newTLSBackend(t)
+	defer srv.Close()
+
+	// 握手在第一次读写时进行
+	p, err := NewChannelPool(1, 2, func() (net.Conn, error) {
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			return nil, err
+		}
+		return tls.Client(conn, config), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	pc := conn.(*PoolConn)
+	if _, ok := pc.TLS(); ok {
+		t.Errorf("TLS error. Expecting no info before handshake")
+	}
+	if _, err := fmt.Fprint(pc, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"); err != nil {
+		t.Fatalf("Write error: %s", err)
+	}
+	p.Put(pc)
+
+	if _, ok := pc.TLS(); !ok {
+		t.Errorf("TLS error. Expecting info recorded on Put")
+	}
+
+	if stats := p.Stats(); len(stats.TLS) != 1 {
+		t.Errorf("Stats error. Expecting %d, got %d", 1, len(stats.TLS))
+	}
+}
+
+func TestPoolConn_TLSPlain(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	if _, ok := conn.(*PoolConn).TLS(); ok {
+		t.Errorf("TLS error. Expecting no info for plain conn")
+	}
+	p.Put(conn)
+	if stats := p.Stats(); stats.TLS != nil {
+		t.Errorf("Stats error. Expecting nil, got %v", stats.TLS)
+	}
+}
+
+func TestChannelPool_TLSExpiryMargin(t *testing.T) {
+	srv, config := newTLSBackend(t)
+	defer srv.Close()
+	dial := func() (net.Conn, error) {
+		return tls.Dial("tcp", srv.Listener.Addr().String(), config)
+	}
+
+	// 默认在证书过期时淘汰
+	p, err := NewChannelPool(1, 2, dial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if p.Len() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d", 1, p.Len())
+	}
+	p.Close()
+
+	// 证书在margin内过期, 空闲的conn取出时淘汰, 取出的conn放回时淘汰
+	margin := time.Until(srv.Certificate().NotAfter) + time.Hour
+	p, err = NewChannelPool(1, 2, dial, WithTLSExpiryMargin(margin))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	conn, err = p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.Put(conn)
+	if p.Len() != 0 {
+		t.Errorf("Put error. Expecting %d, got %d", 0, p.Len())
+	}
+	if h := p.ConnAgeStats()[CloseReasonCertExpiry]; h.Count != 2 {
+		t.Errorf("ConnAgeStats error. Expecting %d, got %d", 2, h.Count)
+	}
+
+	if _, err := NewChannelPool(1, 2, dial, WithTLSExpiryMargin(-time.Second)); err == nil {
+		t.Errorf("NewChannelPool error. Expecting invalid tls expiry margin")
+	}
+}
@@ -0,0 +1,173 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// 保留最近关闭的conn的事件数
+const closedTraceSize = 64
+
+// ConnEventType conn生命周期事件类型
+type ConnEventType int
+
+const (
+	ConnEventCreated    ConnEventType = iota // 新建
+	ConnEventCheckout                        // 被取出
+	ConnEventReturned                        // 放回pool
+	ConnEventHealthFail                      // 健康检查失败
+	ConnEventClosed                          // 被pool关闭
+)
+
+func (t ConnEventType) String() string {
+	switch t {
+	case ConnEventCreated:
+		return "created"
+	case ConnEventCheckout:
+		return "checkout"
+	case ConnEventReturned:
+		return "returned"
+	case ConnEventHealthFail:
+		return "health_fail"
+	case ConnEventClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// ConnEvent conn生命周期中的一个事件
+type ConnEvent struct {
+	Time time.Time
+
+	Type ConnEventType
+
+	Detail string // checkout为取出方, health_fail为错误, closed为关闭原因
+}
+
+func (e ConnEvent) String() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("%s %s", e.Time.Format(time.RFC3339Nano), e.Type)
+	}
+	return fmt.Sprintf("%s %s %s", e.Time.Format(time.RFC3339Nano), e.Type, e.Detail)
+}
+
+// eventRing 固定大小的事件环形缓冲, 满了以后覆盖最早的事件
+type eventRing struct {
+	events []ConnEvent
+	next   int
+	full   bool
+}
+
+func newEventRing(size int) *eventRing {
+	return &eventRing{events: make([]ConnEvent, size)}
+}
+
+func (r *eventRing) add(e ConnEvent) {
+	r.events[r.next] = e
+	r.next++
+	if r.next == len(r.events) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// list 按时间顺序返回事件
+func (r *eventRing) list() []ConnEvent {
+	if !r.full {
+		return append([]ConnEvent(nil), r.events[:r.next]...)
+	}
+	events := make([]ConnEvent, 0, len(r.events))
+	events = append(events, r.events[r.next:]...)
+	return append(events, r.events[:r.next]...)
+}
+
+// closedTrace 已关闭conn的事件
+type closedTrace struct {
+	id     uint64
+	events []ConnEvent
+}
+
+// traceEvent 记录conn事件, 未开启trace时忽略, 需持有p.mu
+func (p *ChannelPool) traceEvent(m *connMeta, typ ConnEventType, detail string) {
+	if m == nil || m.events == nil {
+		return
+	}
+	m.events.add(ConnEvent{Time: time.Now(), Type: typ, Detail: detail})
+}
+
+// traceConn 同traceEvent, 按conn查找元数据, 需持有p.mu
+func (p *ChannelPool) traceConn(conn net.Conn, typ ConnEventType, detail string) {
+	p.traceEvent(p.conns[conn], typ, detail)
+}
+
+// traceClosed 保留已关闭conn的事件, 需持有p.mu
+func (p *ChannelPool) traceClosed(m *connMeta) {
+	if m.events == nil {
+		return
+	}
+	if len(p.closedTraces) == closedTraceSize {
+		p.closedTraces = p.closedTraces[1:]
+	}
+	p.closedTraces = append(p.closedTraces, closedTrace{id: m.id, events: m.events.list()})
+}
+
+// ConnTrace 返回conn的生命周期事件, 包括最近关闭的conn, 需通过WithConnTrace开启
+func (p *ChannelPool) ConnTrace(id uint64) ([]ConnEvent, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, m := range p.conns {
+		if m.id == id && m.events != nil {
+			return m.events.list(), true
+		}
+	}
+	for i := len(p.closedTraces) - 1; i >= 0; i-- {
+		if p.closedTraces[i].id == id {
+			return append([]ConnEvent(nil), p.closedTraces[i].events...), true
+		}
+	}
+	return nil, false
+}
+
+// ConnID 返回pool分配给conn的序号
+func (p *ChannelPool) ConnID(conn net.Conn) (uint64, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	m, ok := p.conns[rawConn(conn)]
+	if !ok {
+		return 0, false
+	}
+	return m.id, true
+}
+
+type holderKey struct{}
+
+// withCaller 开启trace、持有时间采样或开发模式时在ctx中记录取出conn的调用位置, 已记录时不覆盖
+func (p *ChannelPool) withCaller(ctx context.Context, skip int) context.Context {
+	if (p.traceSize <= 0 && p.holdProfiler == nil && !p.devMode) || ctx.Value(holderKey{}) != nil {
+		return ctx
+	}
+	_, file, line, ok := runtime.Caller(skip)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, holderKey{}, fmt.Sprintf("%s:%d", filepath.Base(file), line))
+}
+
+// WithHolder 在ctx中记录取出方, 如请求ID或模块名, 用ctx取出的conn在RangeInUse、持有时间采样和trace中显示该取出方;
+// 未设置时开启trace或持有时间采样才记录取出conn的调用位置
+func WithHolder(ctx context.Context, holder string) context.Context {
+	return context.WithValue(ctx, holderKey{}, holder)
+}
+
+// holderOf ctx中记录的取出方
+func holderOf(ctx context.Context) string {
+	holder, _ := ctx.Value(holderKey{}).(string)
+	return holder
+}
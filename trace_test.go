@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEventRing(t *testing.T) {
+	r := newEventRing(3)
+	for i := 0; i < 5; i++ {
+		r.add(ConnEvent{Type: ConnEventType(i)})
+	}
+
+	events := r.list()
+	if len(events) != 3 {
+		t.Fatalf("list error. Expecting %d, got %d", 3, len(events))
+	}
+	for i, e := range events {
+		if e.Type != ConnEventType(i+2) {
+			t.Errorf("list error. Expecting %s, got %s", ConnEventType(i+2), e.Type)
+		}
+	}
+}
+
+func TestChannelPool_ConnTrace(t *testing.T) {
+	fail := false
+	p, _ := NewChannelPool(1, 2, factory,
+		WithConnTrace(16),
+		WithHealthCheck(func(ctx context.Context, conn net.Conn) error {
+			if fail {
+				return errors.New("broken")
+			}
+			return nil
+		}))
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	id, ok := p.ConnID(conn)
+	if !ok {
+		t.Fatalf("ConnID error. Expecting conn registered")
+	}
+	_ = p.Put(conn)
+
+	fail = true
+	if _, err := p.Get(); err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	events, ok := p.ConnTrace(id)
+	if !ok {
+		t.Fatalf("ConnTrace error. Expecting trace of conn #%d", id)
+	}
+	want := []ConnEventType{
+		ConnEventCreated,
+		ConnEventReturned,
+		ConnEventCheckout,
+		ConnEventReturned,
+		ConnEventHealthFail,
+		ConnEventClosed,
+	}
+	if len(events) != len(want) {
+		t.Fatalf("ConnTrace error. Expecting %d events, got %v", len(want), events)
+	}
+	for i, e := range events {
+		if e.Type != want[i] {
+			t.Errorf("ConnTrace error. Expecting %s, got %s", want[i], e.Type)
+		}
+	}
+	if !strings.HasPrefix(events[2].Detail, "trace_test.go:") {
+		t.Errorf("ConnTrace error. Expecting holder trace_test.go, got %q", events[2].Detail)
+	}
+	if events[5].Detail != CloseReasonHealthCheck.String() {
+		t.Errorf("ConnTrace error. Expecting %s, got %q", CloseReasonHealthCheck, events[5].Detail)
+	}
+
+	w := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/?conn=1", nil))
+	if body := w.Body.String(); !strings.Contains(body, "health_fail broken") {
+		t.Errorf("DebugHandler error. Expecting health_fail event, got %q", body)
+	}
+}
+
+func TestChannelPool_ConnTraceDisabled(t *testing.T) {
+	p, _ := NewChannelPool(int64(maxFree), int64(maxConn), factory)
+	defer p.Close()
+
+	conn, _ := p.Get()
+	id, _ := p.ConnID(conn)
+	if _, ok := p.ConnTrace(id); ok {
+		t.Errorf("ConnTrace error. Expecting no trace when disabled")
+	}
+
+	w := httptest.NewRecorder()
+	p.DebugHandler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if body := w.Body.String(); !strings.Contains(body, "state:") {
+		t.Errorf("DebugHandler error. Expecting DebugString, got %q", body)
+	}
+}
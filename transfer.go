@@ -0,0 +1,57 @@
+package pool
+
+import (
+	"errors"
+)
+
+var (
+	ErrUnreadBuffered = errors.New("unread data in read buffer")
+)
+
+// Transfer 把取出的conn交给其他goroutine: 返回新的PoolConn, 原PoolConn随即失效,
+// 之后通过原PoolConn读写、Close或Put返回ErrConnReturned(开发模式下panic), 由新的持有方负责放回;
+// 本次取出的持有时间和取出方不变. 原PoolConn已放回或已Transfer时返回ErrConnReturned
+func (c *PoolConn) Transfer() (*PoolConn, error) {
+	if err := c.checkReturned(); err != nil {
+		return nil, err
+	}
+	p := c.p
+	p.mu.Lock()
+	m, ok := p.conns[c.raw]
+	if !ok || m.idle || c.stale() {
+		// 已被关闭或放回, 或并发的Transfer已使其失效
+		p.mu.Unlock()
+		return nil, ErrConnReturned
+	}
+	p.poison(m)
+	pc := p.handle(c.raw)
+	p.mu.Unlock()
+	return pc, nil
+}
+
+// Transfer 同PoolConn.Transfer, 先Flush写缓冲, 缓冲区随conn交给新的持有方;
+// 读缓冲中还有未读数据时返回ErrUnreadBuffered, 原BufferedPoolConn仍然有效
+func (c *BufferedPoolConn) Transfer() (*BufferedPoolConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.released {
+		return nil, ErrConnReturned
+	}
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	if c.Reader.Buffered() > 0 {
+		return nil, ErrUnreadBuffered
+	}
+	pc, err := c.PoolConn.Transfer()
+	if err != nil {
+		return nil, err
+	}
+	c.Reader.Reset(pc)
+	c.Writer.Reset(pc)
+	nc := &BufferedPoolConn{PoolConn: pc, Reader: c.Reader, Writer: c.Writer}
+	// 缓冲区已交给nc, 原BufferedPoolConn放回时不再归还
+	c.released = true
+	return nc, nil
+}
@@ -0,0 +1,135 @@
+package pool
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestPoolConn_Transfer(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := conn.(*PoolConn)
+	done := make(chan error, 1)
+	cur, err := old.Transfer()
+	if err != nil {
+		t.Fatalf("Transfer error: %s", err)
+	}
+	if cur == old || cur.RawConn() != old.RawConn() {
+		t.Fatal("Transfer error. Expecting a new PoolConn on the same conn")
+	}
+
+	// 原PoolConn失效, 不能放回新持有方的conn
+	if _, err := old.Write([]byte("x")); err != ErrConnReturned {
+		t.Errorf("Write error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if err := p.Put(old); err != ErrConnReturned {
+		t.Errorf("Put error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if _, err := old.Transfer(); err != ErrConnReturned {
+		t.Errorf("Transfer error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if p.InUse() != 1 {
+		t.Errorf("InUse error. Expecting %d, got %d", 1, p.InUse())
+	}
+
+	// 新的持有方在其他goroutine中使用并放回
+	go func() {
+		if _, err := cur.Write([]byte("x")); err != nil {
+			done <- err
+			return
+		}
+		done <- cur.Close()
+	}()
+	if err := <-done; err != nil {
+		t.Errorf("Transfer error: %s", err)
+	}
+	if p.Len() != 1 || p.InUse() != 0 {
+		t.Errorf("Close error. Expecting 1 idle 0 in use, got %d %d", p.Len(), p.InUse())
+	}
+	if s := p.Stats(); s.Anomalies != 0 {
+		t.Errorf("Stats error. Expecting no anomalies, got %d", s.Anomalies)
+	}
+	if _, err := cur.Transfer(); err != ErrConnReturned {
+		t.Errorf("Transfer error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+}
+
+func TestPoolConn_TransferDevelopmentMode(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory, WithDevelopmentMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := conn.(*PoolConn).Transfer()
+	if err != nil {
+		t.Fatalf("Transfer error: %s", err)
+	}
+	expectMisuse(t, MisuseUseAfterPut, func() { _ = p.Put(conn) })
+	if err := p.Put(cur); err != nil {
+		t.Errorf("Put error: %s", err)
+	}
+}
+
+func TestBufferedPoolConn_Transfer(t *testing.T) {
+	p, err := NewChannelPool(1, 1, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	bc, err := p.GetBuffered(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未Flush的数据在Transfer时发送
+	if _, err := bc.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	nc, err := bc.Transfer()
+	if err != nil {
+		t.Fatalf("Transfer error: %s", err)
+	}
+	// 测试server回写256字节, 读出前5字节后其余留在读缓冲中, 不能Transfer
+	buf := make([]byte, 256)
+	if _, err := io.ReadFull(nc, buf[:5]); err != nil {
+		t.Fatalf("Read error: %s", err)
+	}
+	if string(buf[:5]) != "hello" {
+		t.Errorf("Read error. Expecting %q, got %q", "hello", buf[:5])
+	}
+	if nc.Reader.Buffered() > 0 {
+		if _, err := nc.Transfer(); err != ErrUnreadBuffered {
+			t.Errorf("Transfer error. Expecting %v, got %v", ErrUnreadBuffered, err)
+		}
+	}
+	if _, err := io.ReadFull(nc, buf[5:]); err != nil {
+		t.Fatalf("Read error: %s", err)
+	}
+
+	if _, err := bc.Read(buf); err != ErrConnReturned {
+		t.Errorf("Read error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if err := p.Put(bc); err != ErrConnReturned {
+		t.Errorf("Put error. Expecting %v, got %v", ErrConnReturned, err)
+	}
+	if err := p.Put(nc); err != nil {
+		t.Errorf("Put error: %s", err)
+	}
+	if p.Len() != 1 {
+		t.Errorf("Put error. Expecting %d, got %d", 1, p.Len())
+	}
+}
@@ -0,0 +1,54 @@
+package pool
+
+import (
+	"context"
+	"time"
+)
+
+// warmKeeper 空闲conn被取出后少于warmIdle时在后台新建, 直到达到warmIdle、容量已满或有等待者;
+// 新建失败时等待fillBackoffMin后再试, 由下一次取出重新触发
+func (p *ChannelPool) warmKeeper() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-p.warmWake:
+		}
+
+		for p.belowWarm() && p.tryAcquire() {
+			conn, err := p.dial(context.Background())
+			if err != nil {
+				p.release()
+				timer := time.NewTimer(fillBackoffMin)
+				select {
+				case <-p.done:
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				break
+			}
+			if err := p.Put(conn); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// belowWarm 空闲conn是否少于warmIdle
+func (p *ChannelPool) belowWarm() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return !p.closed && p.idle.Len() < p.warmIdle
+}
+
+// wakeWarm 空闲conn少于warmIdle时唤醒warmKeeper, 需持有p.mu
+func (p *ChannelPool) wakeWarm() {
+	if p.warmWake == nil || p.idle.Len() >= p.warmIdle {
+		return
+	}
+	select {
+	case p.warmWake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,76 @@
+package pool
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_MaxIdle(t *testing.T) {
+	p, err := NewChannelPool(1, 5, factory, WithMaxIdle(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 初始conn数默认为空闲conn上限
+	if p.Len() != 3 {
+		t.Errorf("Len error. Expecting %d, got %d", 3, p.Len())
+	}
+	conns := make([]net.Conn, 0, 5)
+	for i := 0; i < 5; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conns = append(conns, conn)
+	}
+	for _, c := range conns {
+		_ = p.Put(c)
+	}
+	if p.Len() != 3 {
+		t.Errorf("Len error. Expecting %d, got %d", 3, p.Len())
+	}
+	if s := p.Stats(); s.MaxIdle != 3 || s.Overflows != 2 {
+		t.Errorf("Stats error. Expecting max idle 3 and 2 overflows, got %d %d", s.MaxIdle, s.Overflows)
+	}
+
+	for _, opts := range [][]Option{
+		{WithMaxIdle(6)},
+		{WithMaxIdle(-1)},
+		{WithMaxIdle(2), WithWarmIdle(3)},
+		{WithMaxIdle(2), WithInitialConns(3)},
+	} {
+		if _, err := NewChannelPool(1, 5, factory, opts...); err == nil {
+			t.Error("NewChannelPool error. Expecting invalid idle settings")
+		}
+	}
+}
+
+func TestChannelPool_WarmIdle(t *testing.T) {
+	p, err := NewChannelPool(4, 5, factory, WithWarmIdle(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 初始conn数默认为warmIdle
+	if p.Len() != 2 {
+		t.Errorf("Len error. Expecting %d, got %d", 2, p.Len())
+	}
+	a, _ := p.Get()
+	b, _ := p.Get()
+	defer p.Put(a)
+	defer p.Put(b)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if p.Len() != 2 {
+		t.Errorf("Len error. Expecting %d warm conns, got %d", 2, p.Len())
+	}
+	if p.OpenNum() != 4 {
+		t.Errorf("OpenNum error. Expecting %d, got %d", 4, p.OpenNum())
+	}
+}
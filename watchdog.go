@@ -0,0 +1,108 @@
+package pool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// StallEvent 有调用在等待conn, 但超过一段时间没有conn被放回或关闭
+type StallEvent struct {
+	Since time.Time // 最近一次有进展的时间
+
+	Waiters int // 正在等待的调用数
+
+	InUse int // 已被取出的conn数
+
+	Dump string // pool状态, 开启trace时包括各个取出conn的取出方
+}
+
+// OnStall 检测到等待无进展时调用, 每次无进展只调用一次
+type OnStall func(StallEvent)
+
+// 检查间隔的下限
+const minWatchdogInterval = 10 * time.Millisecond
+
+// progress 记录一次容量单位归还, 即有conn被放回或关闭
+func (p *ChannelPool) progress() {
+	atomic.AddInt64(&p.progressNum, 1)
+}
+
+// watchdog 定期检查等待者是否长时间没有进展
+func (p *ChannelPool) watchdog() {
+	interval := p.stallTimeout / 4
+	if interval < minWatchdogInterval {
+		interval = minWatchdogInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var (
+		last     = atomic.LoadInt64(&p.progressNum)
+		since    = time.Now()
+		reported bool
+	)
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			n := atomic.LoadInt64(&p.progressNum)
+			waiters := p.waiterCount()
+			if n != last || waiters == 0 {
+				last, since, reported = n, now, false
+				continue
+			}
+			if reported || now.Sub(since) < p.stallTimeout {
+				continue
+			}
+			reported = true
+			p.onStall(p.stallEvent(since, waiters))
+		}
+	}
+}
+
+// stallEvent 生成无进展事件
+func (p *ChannelPool) stallEvent(since time.Time, waiters int) StallEvent {
+	dump := p.DebugString()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return StallEvent{
+		Since:   since,
+		Waiters: waiters,
+		InUse:   p.inUse(),
+		Dump:    dump + p.holdersString(),
+	}
+}
+
+// holdersString 列出已取出conn的取出方, 优先使用取出时记录的取出方(WithHolder或调用位置),
+// 没有时从trace事件中查找, 需持有p.mu
+func (p *ChannelPool) holdersString() string {
+	var lines []string
+	for _, m := range p.conns {
+		if m.idle {
+			continue
+		}
+		if m.holder != "" {
+			lines = append(lines, fmt.Sprintf("  conn %d held by %s since %s\n",
+				m.id, m.holder, m.acquired.Time.Format(time.RFC3339Nano)))
+			continue
+		}
+		if m.events == nil {
+			continue
+		}
+		events := m.events.list()
+		for i := len(events) - 1; i >= 0; i-- {
+			if events[i].Type == ConnEventCheckout {
+				lines = append(lines, fmt.Sprintf("  conn %d held by %s since %s\n",
+					m.id, events[i].Detail, events[i].Time.Format(time.RFC3339Nano)))
+				break
+			}
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "")
+}
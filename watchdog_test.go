@@ -0,0 +1,117 @@
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_Watchdog(t *testing.T) {
+	events := make(chan StallEvent, 4)
+	p, err := NewChannelPool(1, 1, factory,
+		WithConnTrace(8),
+		WithWatchdog(time.Millisecond*50, func(e StallEvent) {
+			events <- e
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	conn, err := p.Get()
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+
+	// 唯一的conn不放回, 等待者没有进展
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+	defer cancel()
+	go p.GetWitchContext(ctx)
+
+	select {
+	case e := <-events:
+		if e.Waiters != 1 || e.InUse != 1 {
+			t.Errorf("Watchdog error. Expecting %d waiter %d inUse, got %d %d", 1, 1, e.Waiters, e.InUse)
+		}
+		if !strings.Contains(e.Dump, "held by watchdog_test.go:") {
+			t.Errorf("Watchdog error. Expecting holder in dump, got %s", e.Dump)
+		}
+	case <-time.After(time.Millisecond * 250):
+		t.Fatalf("Watchdog error. Expecting stall event")
+	}
+
+	// 同一次无进展只报告一次
+	select {
+	case e := <-events:
+		t.Errorf("Watchdog error. Expecting one event, got %+v", e)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	if err := p.Put(conn); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChannelPool_WatchdogProgress(t *testing.T) {
+	events := make(chan StallEvent, 4)
+	p, err := NewChannelPool(1, 1, factory,
+		WithWatchdog(time.Millisecond*50, func(e StallEvent) {
+			events <- e
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 一直有conn放回, 等待者有进展
+	stop := time.After(time.Millisecond * 200)
+	for done := false; !done; {
+		select {
+		case <-stop:
+			done = true
+		default:
+			conn, err := p.Get()
+			if err != nil {
+				t.Fatalf("Get error: %s", err)
+			}
+			go func() {
+				time.Sleep(time.Millisecond * 10)
+				p.Put(conn)
+			}()
+		}
+	}
+	select {
+	case e := <-events:
+		t.Errorf("Watchdog error. Expecting no event, got %+v", e)
+	default:
+	}
+}
+
+func TestChannelPool_HoldersString(t *testing.T) {
+	p, err := NewChannelPool(1, 2, factory)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// 未开启trace时使用WithHolder记录的取出方
+	conn, err := p.GetWitchContext(WithHolder(context.Background(), "request-42"))
+	if err != nil {
+		t.Fatalf("Get error: %s", err)
+	}
+	p.mu.RLock()
+	holders := p.holdersString()
+	p.mu.RUnlock()
+	if !strings.Contains(holders, "held by request-42 since") {
+		t.Errorf("holdersString error. Expecting holder without trace, got %q", holders)
+	}
+	_ = p.Put(conn)
+
+	p.mu.RLock()
+	holders = p.holdersString()
+	p.mu.RUnlock()
+	if holders != "" {
+		t.Errorf("holdersString error. Expecting no holders, got %q", holders)
+	}
+}
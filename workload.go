@@ -0,0 +1,216 @@
+package pool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 后台写入前缓冲的记录数, 写入跟不上时丢弃新记录
+const workloadQueueSize = 4096
+
+// WorkloadRecord 一次取出conn的记录, 只包含时间信息, 不包含地址、取出方和操作名,
+// 可以在生产环境记录后离线回放; 以JSON Lines格式写入, 时长的单位为纳秒
+type WorkloadRecord struct {
+	Start time.Duration `json:"start"` // 开始获取conn的时间, 相对于pool创建
+
+	Wait time.Duration `json:"wait"` // 获取conn的耗时, 包括等待容量和新建; 获取失败时为失败前的耗时
+
+	Hold time.Duration `json:"hold"` // 持有conn的时间, 获取失败时为0
+
+	Failed bool `json:"failed,omitempty"` // 获取conn失败
+}
+
+// workloadRecorder 把WorkloadRecord交给后台写入, 不阻塞Get和Put
+type workloadRecorder struct {
+	start time.Time
+
+	w io.Writer
+
+	queue chan WorkloadRecord
+
+	dropped int64 // 队列已满或写入失败丢弃的记录数, 原子访问
+}
+
+func newWorkloadRecorder(w io.Writer) *workloadRecorder {
+	return &workloadRecorder{start: time.Now(), w: w, queue: make(chan WorkloadRecord, workloadQueueSize)}
+}
+
+// record 不等待地把记录交给后台写入
+func (r *workloadRecorder) record(rec WorkloadRecord) {
+	select {
+	case r.queue <- rec:
+	default:
+		atomic.AddInt64(&r.dropped, 1)
+	}
+}
+
+// recordCheckin 记录一次结束的取出, 需持有p.mu
+func (p *ChannelPool) recordCheckin(m *connMeta) {
+	r := p.workload
+	if r == nil || !m.workloadPending {
+		return
+	}
+	m.workloadPending = false
+	getStart := m.acquired.Time.Add(-m.acquired.WaitDuration - m.acquired.DialDuration)
+	r.record(WorkloadRecord{
+		Start: getStart.Sub(r.start),
+		Wait:  m.acquired.Time.Sub(getStart),
+		Hold:  time.Since(m.acquired.Time),
+	})
+}
+
+// recordGetFailure 记录一次获取失败, start为开始获取的时间
+func (p *ChannelPool) recordGetFailure(start time.Time) {
+	r := p.workload
+	if r == nil {
+		return
+	}
+	r.record(WorkloadRecord{Start: start.Sub(r.start), Wait: time.Since(start), Failed: true})
+}
+
+// writeWorkload 后台写入记录, pool关闭时写完已缓冲的记录后退出
+func (p *ChannelPool) writeWorkload() {
+	r := p.workload
+	bw := bufio.NewWriter(r.w)
+	enc := json.NewEncoder(bw)
+	var failed bool
+	write := func(rec WorkloadRecord) {
+		if failed {
+			atomic.AddInt64(&r.dropped, 1)
+			return
+		}
+		if err := enc.Encode(rec); err != nil {
+			failed = true
+			atomic.AddInt64(&r.dropped, 1)
+		}
+	}
+	flush := func() {
+		if !failed && bw.Flush() != nil {
+			failed = true
+		}
+	}
+	for {
+		select {
+		case rec := <-r.queue:
+			write(rec)
+			if len(r.queue) == 0 {
+				flush()
+			}
+		case <-p.done:
+			for {
+				select {
+				case rec := <-r.queue:
+					write(rec)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// WorkloadDropped 开启WithWorkloadRecorder时丢弃的记录数, 不为0时回放的负载比实际的轻
+func (p *ChannelPool) WorkloadDropped() int64 {
+	if p.workload == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&p.workload.dropped)
+}
+
+// ReadWorkload 读取WithWorkloadRecorder写入的记录
+func ReadWorkload(r io.Reader) ([]WorkloadRecord, error) {
+	var recs []WorkloadRecord
+	dec := json.NewDecoder(r)
+	for {
+		var rec WorkloadRecord
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return recs, nil
+		}
+		if err != nil {
+			return recs, err
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// ReplayResult 回放的结果
+type ReplayResult struct {
+	Records int // 回放的记录数
+
+	Errors int // 回放时获取conn失败的次数
+
+	RecordedErrors int // 记录中获取失败的次数
+
+	SLO SLOIndicators // 回放期间pool的SLO指标
+}
+
+// ReplayWorkload 按记录的时间在p上获取conn并持有相同的时间后放回, speed为回放倍速, <= 0 按1计算;
+// 每次获取使用WithDefaultGetTimeout的超时; 记录按Start排序后回放, ctx结束时停止发起新的获取并等待已发起的完成.
+// 用于离线评估pool参数的调整
+func ReplayWorkload(ctx context.Context, p *ChannelPool, recs []WorkloadRecord, speed float64) ReplayResult {
+	if speed <= 0 {
+		speed = 1
+	}
+	scale := func(d time.Duration) time.Duration {
+		return time.Duration(float64(d) / speed)
+	}
+
+	res := ReplayResult{}
+	var errs int64
+	var wg sync.WaitGroup
+	before := p.Stats()
+	start := time.Now()
+	for _, rec := range sortedWorkload(recs) {
+		if d := time.Until(start.Add(scale(rec.Start))); d > 0 {
+			timer := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		res.Records++
+		if rec.Failed {
+			res.RecordedErrors++
+		}
+		wg.Add(1)
+		go func(hold time.Duration) {
+			defer wg.Done()
+			getCtx, cancel := ctx, context.CancelFunc(func() {})
+			if p.getTimeout > 0 {
+				getCtx, cancel = context.WithTimeout(ctx, p.getTimeout)
+			}
+			conn, err := p.GetWitchContext(getCtx)
+			cancel()
+			if err != nil {
+				atomic.AddInt64(&errs, 1)
+				return
+			}
+			time.Sleep(hold)
+			_ = p.Put(conn)
+		}(scale(rec.Hold))
+	}
+	wg.Wait()
+	res.Errors = int(errs)
+	res.SLO = ComputeSLO(before, p.Stats())
+	return res
+}
+
+// sortedWorkload 按Start排序的记录拷贝
+func sortedWorkload(recs []WorkloadRecord) []WorkloadRecord {
+	sorted := append([]WorkloadRecord(nil), recs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+	return sorted
+}
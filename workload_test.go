@@ -0,0 +1,81 @@
+package pool
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestChannelPool_WorkloadRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	p, err := NewChannelPool(1, 1, factory, WithWorkloadRecorder(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		conn, err := p.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if i == 2 {
+			// 容量已满, 获取超时
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			if _, err := p.GetWitchContext(ctx); err == nil {
+				t.Error("Get error. Expecting time out")
+			}
+			cancel()
+		}
+		_ = p.Put(conn)
+	}
+	_ = p.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.WaitStopped(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	recs, err := ReadWorkload(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 记录按取出结束的顺序写入
+	if len(recs) != 4 {
+		t.Fatalf("ReadWorkload error. Expecting %d records, got %d", 4, len(recs))
+	}
+	failed := 0
+	for _, rec := range recs {
+		if rec.Failed {
+			failed++
+			if rec.Hold != 0 || rec.Wait < 10*time.Millisecond {
+				t.Errorf("Record error. Expecting failed get waited 10ms, got %s hold %s", rec.Wait, rec.Hold)
+			}
+			continue
+		}
+		if rec.Hold < 20*time.Millisecond {
+			t.Errorf("Record error. Expecting hold >= 20ms, got %s", rec.Hold)
+		}
+	}
+	if failed != 1 {
+		t.Errorf("Record error. Expecting %d failed, got %d", 1, failed)
+	}
+	if p.WorkloadDropped() != 0 {
+		t.Errorf("WorkloadDropped error. Expecting %d, got %d", 0, p.WorkloadDropped())
+	}
+
+	// 在容量更大的pool上回放, 不再超时
+	q, err := NewChannelPool(2, 2, factory, WithDefaultGetTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	res := ReplayWorkload(context.Background(), q, recs, 2)
+	if res.Records != 4 || res.RecordedErrors != 1 || res.Errors != 0 {
+		t.Errorf("ReplayWorkload error. Expecting 4 records 1 recorded error 0 errors, got %d %d %d", res.Records, res.RecordedErrors, res.Errors)
+	}
+	if res.SLO.Acquires != 4 || res.SLO.AcquireSuccessRate != 1 {
+		t.Errorf("ReplayWorkload error. Expecting 4 acquires all succeeded, got %d %v", res.SLO.Acquires, res.SLO.AcquireSuccessRate)
+	}
+}